@@ -2,4 +2,4 @@ module github.com/thutasann/go-parser
 
 go 1.21.3
 
-require github.com/sanity-io/litter v1.5.8 // indirect
+require github.com/sanity-io/litter v1.5.8
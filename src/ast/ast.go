@@ -1,15 +1,51 @@
 package ast
 
+import "github.com/thutasann/go-parser/src/lexer"
+
+// Node is implemented by every AST node
+type Node interface {
+	Span() lexer.Span // full source range covered by the node
+}
+
 // Statement Interface
 type Stmt interface {
+	Node
 	stmt()
 }
 
 // Expression Interface
 type Expr interface {
+	Node
 	expr()
 }
 
 type Type interface {
+	Node
 	_type()
 }
+
+// Nodes defined outside this package, by a parser.Grammar extension, embed one of
+// CustomStmt, CustomExpr or CustomType to implement Stmt, Expr or Type.
+// They still provide their own Span, and a Children method if they have children:
+//
+//	type UnlessStmt struct {
+//		ast.CustomStmt
+//		Condition ast.Expr
+//		Body      ast.BlockStmt
+//		Loc       lexer.Span
+//	}
+
+// CustomStmt makes the type embedding it a Stmt
+type CustomStmt struct{}
+
+func (CustomStmt) stmt() {}
+
+// CustomExpr makes the type embedding it an Expr
+type CustomExpr struct{}
+
+func (CustomExpr) expr() {}
+
+// CustomType makes the type embedding it a Type
+type CustomType struct{}
+
+func (CustomType) _type() {}
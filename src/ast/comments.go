@@ -0,0 +1,32 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Consecutive `//` comments directly above a declaration
+type CommentGroup struct {
+	List []lexer.Comment
+}
+
+func (g CommentGroup) Span() lexer.Span {
+	return g.List[0].Span.To(g.List[len(g.List)-1].Span)
+}
+
+// Text returns the comment text without the comment markers, one line per comment.
+// A single space after the marker is dropped, so `// Adds a and b` becomes "Adds a and b".
+func (g CommentGroup) Text() string {
+	lines := make([]string, 0, len(g.List))
+	for _, comment := range g.List {
+		text := comment.Text
+		if comment.IsBlock() {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		} else {
+			text = strings.TrimPrefix(text, "//")
+		}
+		lines = append(lines, strings.TrimPrefix(text, " "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -6,29 +6,60 @@ import "github.com/thutasann/go-parser/src/lexer"
 // LITERAL EXPRESSIONS
 // -------------------
 
+// Number literal
+//
+// - Integer is true for literals without a fraction or exponent, e.g. 42, 0xFF, 1_000
+//
+// - Raw is the literal as written in the source, e.g. 0x1F or 1e-9
 type NumberExpr struct {
-	Value float64
+	Value   float64
+	Integer bool
+	Raw     string
+	Loc     lexer.Span
 }
 
 func (n NumberExpr) expr() {
 
 }
 
+func (n NumberExpr) Span() lexer.Span { return n.Loc }
+
 type StringExpr struct {
 	Value string
+	Loc   lexer.Span
 }
 
 func (n StringExpr) expr() {
 
 }
 
+func (n StringExpr) Span() lexer.Span { return n.Loc }
+
+// Template string `text ${expr} text`
+//
+// - Strings holds the decoded text around the interpolations and is always one longer than Expressions
+type TemplateExpr struct {
+	Strings     []string
+	Expressions []Expr
+	Loc         lexer.Span
+}
+
+func (n TemplateExpr) expr() {
+
+}
+
+func (n TemplateExpr) Span() lexer.Span { return n.Loc }
+
 type SymbolExpr struct {
 	Value string
+	Loc   lexer.Span
 }
 
 func (n SymbolExpr) expr() {
 }
 
+func (n SymbolExpr) Span() lexer.Span { return n.Loc }
+
 // -------------------
 // COMPLEX EXPRESSIONS
 // -------------------
@@ -37,25 +68,153 @@ type BinaryExpr struct {
 	Left     Expr
 	Operator lexer.Token
 	Right    Expr
+	Loc      lexer.Span
 }
 
 func (n BinaryExpr) expr() {
 
 }
 
+func (n BinaryExpr) Span() lexer.Span { return n.Loc }
+
 type PrefixExpr struct {
 	Operator  lexer.Token
 	RightExpr Expr
+	Loc       lexer.Span
 }
 
 func (n PrefixExpr) expr() {
 }
 
+func (n PrefixExpr) Span() lexer.Span { return n.Loc }
+
 type AssignmentExpr struct {
 	Assigne  Expr
 	Operator lexer.Token
 	Value    Expr
+	Loc      lexer.Span
 }
 
 func (n AssignmentExpr) expr() {
 }
+
+func (n AssignmentExpr) Span() lexer.Span { return n.Loc }
+
+// Placeholder for an expression that failed to parse
+type BadExpr struct {
+	Loc lexer.Span
+}
+
+func (n BadExpr) expr() {
+}
+
+func (n BadExpr) Span() lexer.Span { return n.Loc }
+
+// Anonymous function `fn (a: T): R { ... }`
+type FunctionExpr struct {
+	Parameters []Parameter
+	ReturnType Type // nil when not declared
+	Body       BlockStmt
+	Loc        lexer.Span
+}
+
+func (n FunctionExpr) expr() {
+}
+
+func (n FunctionExpr) Span() lexer.Span { return n.Loc }
+
+// Call `method(arguments...)`
+type CallExpr struct {
+	Method    Expr
+	Arguments []Expr
+	Loc       lexer.Span
+}
+
+func (n CallExpr) expr() {
+}
+
+func (n CallExpr) Span() lexer.Span { return n.Loc }
+
+// Member access `member.Property`
+type MemberExpr struct {
+	Member   Expr
+	Property string
+	Loc      lexer.Span
+}
+
+func (n MemberExpr) expr() {
+}
+
+func (n MemberExpr) Span() lexer.Span { return n.Loc }
+
+// Computed member access `member[property]`
+type ComputedExpr struct {
+	Member   Expr
+	Property Expr
+	Loc      lexer.Span
+}
+
+func (n ComputedExpr) expr() {
+}
+
+func (n ComputedExpr) Span() lexer.Span { return n.Loc }
+
+// Instantiation `new Class(arguments...)`
+type NewExpr struct {
+	Instantiation CallExpr
+	Loc           lexer.Span
+}
+
+func (n NewExpr) expr() {
+}
+
+func (n NewExpr) Span() lexer.Span { return n.Loc }
+
+// Range `lower..upper`
+type RangeExpr struct {
+	Lower Expr
+	Upper Expr
+	Loc   lexer.Span
+}
+
+func (n RangeExpr) expr() {
+}
+
+func (n RangeExpr) Span() lexer.Span { return n.Loc }
+
+// Array literal `[a, b, c]`
+type ArrayLiteral struct {
+	Contents []Expr
+	Loc      lexer.Span
+}
+
+func (n ArrayLiteral) expr() {
+}
+
+func (n ArrayLiteral) Span() lexer.Span { return n.Loc }
+
+// Single entry of an object literal
+//
+// - Key holds the name for `key: value`, `"key": value` and shorthand `key` entries
+//
+// - ComputedKey holds the expression for `[expr]: value` entries and is nil otherwise
+type ObjectProperty struct {
+	Key         string
+	ComputedKey Expr
+	Value       Expr
+	Shorthand   bool
+	Loc         lexer.Span
+}
+
+func (n ObjectProperty) Span() lexer.Span { return n.Loc }
+
+// Object literal `{ key: value, ... }`
+type ObjectLiteral struct {
+	Properties []ObjectProperty
+	Loc        lexer.Span
+}
+
+func (n ObjectLiteral) expr() {
+}
+
+func (n ObjectLiteral) Span() lexer.Span { return n.Loc }
@@ -0,0 +1,186 @@
+package ast
+
+import "fmt"
+
+// Rewrite returns a copy of the tree rooted at node where every node has been passed through f,
+// bottom-up: the children of a node are rewritten first, then f gets the node rebuilt from them.
+// Returning the node unchanged keeps it.
+//
+// - f may return any node that fits where the original was: an Expr for an Expr, a Stmt for a Stmt
+// and the same type for fields with a concrete type, such as the BlockStmt body of a function.
+// Anything else panics.
+//
+// - Returning nil removes the node from a list, such as a statement from a block,
+// and empties an optional field, such as the else branch of an if
+//
+// - Custom nodes are passed to f as they are, without rewriting their children
+//
+// The original tree is not modified.
+func Rewrite(node Node, f func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	// statements
+	case BlockStmt:
+		n.Body = rewriteList(n.Body, f)
+		node = n
+	case ExpressionStmt:
+		n.Expression = rewriteAs(n.Expression, f)
+		node = n
+	case VarDeclStmt:
+		n.Doc = rewriteDoc(n.Doc, f)
+		n.ExplicitType = rewriteAs(n.ExplicitType, f)
+		n.AssignedValue = rewriteAs(n.AssignedValue, f)
+		node = n
+	case Parameter:
+		n.Type = rewriteAs(n.Type, f)
+		node = n
+	case FunctionDeclStmt:
+		n.Doc = rewriteDoc(n.Doc, f)
+		n.Parameters = rewriteList(n.Parameters, f)
+		n.ReturnType = rewriteAs(n.ReturnType, f)
+		n.Body = rewriteAs(n.Body, f)
+		node = n
+	case ReturnStmt:
+		n.Value = rewriteAs(n.Value, f)
+		node = n
+	case ClassDeclStmt:
+		n.Doc = rewriteDoc(n.Doc, f)
+		n.Fields = rewriteList(n.Fields, f)
+		n.Methods = rewriteList(n.Methods, f)
+		node = n
+	case IfStmt:
+		n.Condition = rewriteAs(n.Condition, f)
+		n.Consequent = rewriteAs(n.Consequent, f)
+		n.Alternate = rewriteAs(n.Alternate, f)
+		node = n
+	case WhileStmt:
+		n.Condition = rewriteAs(n.Condition, f)
+		n.Body = rewriteAs(n.Body, f)
+		node = n
+	case ForStmt:
+		n.Init = rewriteAs(n.Init, f)
+		n.Condition = rewriteAs(n.Condition, f)
+		n.Post = rewriteAs(n.Post, f)
+		n.Body = rewriteAs(n.Body, f)
+		node = n
+	case ForeachStmt:
+		n.Iterable = rewriteAs(n.Iterable, f)
+		n.Body = rewriteAs(n.Body, f)
+		node = n
+
+	// expressions
+	case TemplateExpr:
+		n.Expressions = rewriteList(n.Expressions, f)
+		node = n
+	case BinaryExpr:
+		n.Left = rewriteAs(n.Left, f)
+		n.Right = rewriteAs(n.Right, f)
+		node = n
+	case PrefixExpr:
+		n.RightExpr = rewriteAs(n.RightExpr, f)
+		node = n
+	case AssignmentExpr:
+		n.Assigne = rewriteAs(n.Assigne, f)
+		n.Value = rewriteAs(n.Value, f)
+		node = n
+	case FunctionExpr:
+		n.Parameters = rewriteList(n.Parameters, f)
+		n.ReturnType = rewriteAs(n.ReturnType, f)
+		n.Body = rewriteAs(n.Body, f)
+		node = n
+	case CallExpr:
+		n.Method = rewriteAs(n.Method, f)
+		n.Arguments = rewriteList(n.Arguments, f)
+		node = n
+	case MemberExpr:
+		n.Member = rewriteAs(n.Member, f)
+		node = n
+	case ComputedExpr:
+		n.Member = rewriteAs(n.Member, f)
+		n.Property = rewriteAs(n.Property, f)
+		node = n
+	case NewExpr:
+		n.Instantiation = rewriteAs(n.Instantiation, f)
+		node = n
+	case RangeExpr:
+		n.Lower = rewriteAs(n.Lower, f)
+		n.Upper = rewriteAs(n.Upper, f)
+		node = n
+	case ArrayLiteral:
+		n.Contents = rewriteList(n.Contents, f)
+		node = n
+	case ObjectProperty:
+		n.ComputedKey = rewriteAs(n.ComputedKey, f)
+		n.Value = rewriteAs(n.Value, f)
+		node = n
+	case ObjectLiteral:
+		n.Properties = rewriteList(n.Properties, f)
+		node = n
+
+	// types
+	case ArrayType:
+		n.Underlying = rewriteAs(n.Underlying, f)
+		node = n
+	}
+
+	return f(node)
+}
+
+// Rewrites a field holding a T, which is left at its zero value when the node is removed
+func rewriteAs[T Node](node T, f func(Node) Node) T {
+	var zero T
+
+	result := Rewrite(node, f)
+	if result == nil {
+		return zero
+	}
+
+	typed, ok := result.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", node, result))
+	}
+	return typed
+}
+
+// Rewrites every node of a list, dropping the removed ones
+func rewriteList[T Node](list []T, f func(Node) Node) []T {
+	if list == nil {
+		return nil
+	}
+
+	rewritten := make([]T, 0, len(list))
+	for _, node := range list {
+		result := Rewrite(node, f)
+		if result == nil {
+			continue
+		}
+
+		typed, ok := result.(T)
+		if !ok {
+			panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", node, result))
+		}
+		rewritten = append(rewritten, typed)
+	}
+	return rewritten
+}
+
+// Rewrites a doc comment, which may be removed
+func rewriteDoc(doc *CommentGroup, f func(Node) Node) *CommentGroup {
+	if doc == nil {
+		return nil
+	}
+
+	result := Rewrite(*doc, f)
+	if result == nil {
+		return nil
+	}
+
+	rewritten, ok := result.(CommentGroup)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", *doc, result))
+	}
+	return &rewritten
+}
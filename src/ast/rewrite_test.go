@@ -0,0 +1,124 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/printer"
+)
+
+// Source of a rewritten program
+func printed(program ast.Node) string {
+	return strings.TrimSpace(string(printer.Source(program.(ast.BlockStmt), nil)))
+}
+
+func TestRewriteExpressions(t *testing.T) {
+	program := parse(t, "let y = x * (1 + 2);\nprintln(x, [x, 3 + 4]);")
+	original := printed(program)
+
+	// replace x with 10 and fold additions of two numbers, which sees the children already rewritten
+	rewritten := ast.Rewrite(program, func(node ast.Node) ast.Node {
+		switch n := node.(type) {
+		case ast.SymbolExpr:
+			if n.Value == "x" {
+				return ast.NumberExpr{Value: 10, Loc: n.Loc}
+			}
+		case ast.BinaryExpr:
+			left, leftOk := n.Left.(ast.NumberExpr)
+			right, rightOk := n.Right.(ast.NumberExpr)
+			if leftOk && rightOk && n.Operator.Kind == lexer.PLUS {
+				return ast.NumberExpr{Value: left.Value + right.Value, Loc: n.Loc}
+			}
+		}
+		return node
+	})
+
+	if got, want := printed(rewritten), "let y = 10 * 3;\nprintln(10, [10, 7]);"; got != want {
+		t.Errorf("rewritten:\n%s\nwant:\n%s", got, want)
+	}
+	if printed(program) != original {
+		t.Errorf("the original tree changed:\n%s", printed(program))
+	}
+}
+
+func TestRewriteStatements(t *testing.T) {
+	program := parse(t, "fn f(n: number) {\n  println(n);\n  if n > 1 { return n; } else { println(0); }\n  return 0;\n}")
+
+	// drop println calls, return twice the value, and remove else branches
+	rewritten := ast.Rewrite(program, func(node ast.Node) ast.Node {
+		switch n := node.(type) {
+		case ast.ExpressionStmt:
+			if call, ok := n.Expression.(ast.CallExpr); ok {
+				if method, ok := call.Method.(ast.SymbolExpr); ok && method.Value == "println" {
+					return nil
+				}
+			}
+		case ast.ReturnStmt:
+			n.Value = ast.BinaryExpr{Left: ast.NumberExpr{Value: 2}, Operator: lexer.NewToken(lexer.STAR, "*"), Right: n.Value}
+			return n
+		case ast.IfStmt:
+			n.Alternate = nil
+			return n
+		}
+		return node
+	})
+
+	want := "fn f(n: number) {\n  if n > 1 {\n    return 2 * n;\n  }\n  return 2 * 0;\n}"
+	if got := printed(rewritten); got != want {
+		t.Errorf("rewritten:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// A statement can't replace an expression, and a concretely typed field keeps its type
+func TestRewritePanicsOnTypeMismatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		replace func(ast.Node) ast.Node
+		want    string
+	}{
+		{
+			name: "statement for expression",
+			replace: func(node ast.Node) ast.Node {
+				if n, ok := node.(ast.NumberExpr); ok {
+					return ast.ReturnStmt{Loc: n.Loc}
+				}
+				return node
+			},
+			want: "ast.Rewrite: cannot replace ast.NumberExpr with ast.ReturnStmt",
+		},
+		{
+			name: "expression statement for function body",
+			replace: func(node ast.Node) ast.Node {
+				if n, ok := node.(ast.BlockStmt); ok && len(n.Body) == 1 {
+					return n.Body[0]
+				}
+				return node
+			},
+			want: "ast.Rewrite: cannot replace ast.BlockStmt with ast.ExpressionStmt",
+		},
+		{
+			name: "expression for field",
+			replace: func(node ast.Node) ast.Node {
+				if n, ok := node.(ast.VarDeclStmt); ok && n.VariableName == "field" {
+					return n.AssignedValue
+				}
+				return node
+			},
+			want: "ast.Rewrite: cannot replace ast.VarDeclStmt with ast.NumberExpr",
+		},
+	}
+
+	program := parse(t, "class C { let field = 1; }\nfn f() { g(); }")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if got := recover(); got != test.want {
+					t.Errorf("panic %v, want %q", got, test.want)
+				}
+			}()
+			ast.Rewrite(program, test.replace)
+		})
+	}
+}
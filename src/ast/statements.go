@@ -1,25 +1,143 @@
 package ast
 
+import "github.com/thutasann/go-parser/src/lexer"
+
 // Block Statement { ... []Stmt }
 type BlockStmt struct {
 	Body []Stmt
+	Loc  lexer.Span
 }
 
-func (n BlockStmt) stmt() {}
+func (n BlockStmt) stmt()            {}
+func (n BlockStmt) Span() lexer.Span { return n.Loc }
 
 // Expression Statement
 type ExpressionStmt struct {
 	Expression Expr
+	Loc        lexer.Span
 }
 
-func (n ExpressionStmt) stmt() {}
+func (n ExpressionStmt) stmt()            {}
+func (n ExpressionStmt) Span() lexer.Span { return n.Loc }
 
 // Variable Declaration Statement
 type VarDeclStmt struct {
 	VariableName  string
 	IsConstant    bool
+	IsExported    bool
 	AssignedValue Expr
 	ExplicitType  Type
+	Doc           *CommentGroup // nil when there are no doc comments
+	Loc           lexer.Span
+}
+
+func (n VarDeclStmt) stmt()            {}
+func (n VarDeclStmt) Span() lexer.Span { return n.Loc }
+
+// Bad Statement - placeholder for a statement that failed to parse
+type BadStmt struct {
+	Loc lexer.Span
+}
+
+func (n BadStmt) stmt()            {}
+func (n BadStmt) Span() lexer.Span { return n.Loc }
+
+// Function Parameter `name: T`
+type Parameter struct {
+	Name string
+	Type Type
+	Loc  lexer.Span
+}
+
+func (n Parameter) Span() lexer.Span { return n.Loc }
+
+// Function Declaration Statement `fn name(a: T): R { ... }`
+type FunctionDeclStmt struct {
+	Name       string
+	IsExported bool
+	Parameters []Parameter
+	ReturnType Type // nil when not declared
+	Body       BlockStmt
+	Doc        *CommentGroup // nil when there are no doc comments
+	Loc        lexer.Span
+}
+
+func (n FunctionDeclStmt) stmt()            {}
+func (n FunctionDeclStmt) Span() lexer.Span { return n.Loc }
+
+// Return Statement `return expr;`
+type ReturnStmt struct {
+	Value Expr // nil for a bare `return;`
+	Loc   lexer.Span
+}
+
+func (n ReturnStmt) stmt()            {}
+func (n ReturnStmt) Span() lexer.Span { return n.Loc }
+
+// Class Declaration Statement `class Name { fields... methods... }`
+type ClassDeclStmt struct {
+	Name       string
+	IsExported bool
+	Fields     []VarDeclStmt
+	Methods    []FunctionDeclStmt
+	Doc        *CommentGroup // nil when there are no doc comments
+	Loc        lexer.Span
+}
+
+func (n ClassDeclStmt) stmt()            {}
+func (n ClassDeclStmt) Span() lexer.Span { return n.Loc }
+
+// If Statement `if cond { ... } else ...`
+type IfStmt struct {
+	Condition  Expr
+	Consequent BlockStmt
+	Alternate  Stmt // nil, BlockStmt for `else { ... }` or IfStmt for `else if`
+	Loc        lexer.Span
+}
+
+func (n IfStmt) stmt()            {}
+func (n IfStmt) Span() lexer.Span { return n.Loc }
+
+// While Statement `while cond { ... }`
+type WhileStmt struct {
+	Condition Expr
+	Body      BlockStmt
+	Loc       lexer.Span
+}
+
+func (n WhileStmt) stmt()            {}
+func (n WhileStmt) Span() lexer.Span { return n.Loc }
+
+// For Statement `for init; cond; post { ... }`
+type ForStmt struct {
+	Init      Stmt // nil, VarDeclStmt or ExpressionStmt
+	Condition Expr // nil means loop forever
+	Post      Expr // nil when omitted
+	Body      BlockStmt
+	Loc       lexer.Span
+}
+
+func (n ForStmt) stmt()            {}
+func (n ForStmt) Span() lexer.Span { return n.Loc }
+
+// Foreach Statement `foreach value in iterable { ... }`
+type ForeachStmt struct {
+	Value    string
+	Iterable Expr // any expression, including a RangeExpr
+	Body     BlockStmt
+	Loc      lexer.Span
+}
+
+func (n ForeachStmt) stmt()            {}
+func (n ForeachStmt) Span() lexer.Span { return n.Loc }
+
+// Import Statement `import { a, b } from "path";` or `import alias from "path";`
+type ImportStmt struct {
+	Names []string // named imports, empty for an alias import
+	Alias string   // module alias, empty for named imports
+	From  string
+	Loc   lexer.Span
 }
 
-func (n VarDeclStmt) stmt() {}
+func (n ImportStmt) stmt()            {}
+func (n ImportStmt) Span() lexer.Span { return n.Loc }
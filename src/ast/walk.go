@@ -0,0 +1,142 @@
+package ast
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a syntax tree in depth-first order: it starts by calling v.Visit(node),
+// then walks the children returned by Children with the visitor it returned
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	for _, child := range Children(node) {
+		Walk(v, child)
+	}
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a syntax tree in depth-first order: it starts by calling f(node);
+// if f returns true, Inspect does the same for each child of node, followed by f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Children returns the direct children of node, leaving out the ones that are nil
+//
+// - Operators are tokens, not nodes, and are not included
+//
+// - A class lists its doc comment, then its fields, then its methods
+//
+// - Custom nodes list what their own Children method returns, if they have one
+func Children(node Node) []Node {
+	children := make([]Node, 0, 4)
+	add := func(nodes ...Node) {
+		for _, child := range nodes {
+			if child != nil {
+				children = append(children, child)
+			}
+		}
+	}
+
+	switch n := node.(type) {
+	// statements
+	case BlockStmt:
+		addAll(add, n.Body)
+	case ExpressionStmt:
+		add(n.Expression)
+	case VarDeclStmt:
+		addDoc(add, n.Doc)
+		add(n.ExplicitType, n.AssignedValue)
+	case Parameter:
+		add(n.Type)
+	case FunctionDeclStmt:
+		addDoc(add, n.Doc)
+		addAll(add, n.Parameters)
+		add(n.ReturnType, n.Body)
+	case ReturnStmt:
+		add(n.Value)
+	case ClassDeclStmt:
+		addDoc(add, n.Doc)
+		addAll(add, n.Fields)
+		addAll(add, n.Methods)
+	case IfStmt:
+		add(n.Condition, n.Consequent, n.Alternate)
+	case WhileStmt:
+		add(n.Condition, n.Body)
+	case ForStmt:
+		add(n.Init, n.Condition, n.Post, n.Body)
+	case ForeachStmt:
+		add(n.Iterable, n.Body)
+
+	// expressions
+	case TemplateExpr:
+		addAll(add, n.Expressions)
+	case BinaryExpr:
+		add(n.Left, n.Right)
+	case PrefixExpr:
+		add(n.RightExpr)
+	case AssignmentExpr:
+		add(n.Assigne, n.Value)
+	case FunctionExpr:
+		addAll(add, n.Parameters)
+		add(n.ReturnType, n.Body)
+	case CallExpr:
+		add(n.Method)
+		addAll(add, n.Arguments)
+	case MemberExpr:
+		add(n.Member)
+	case ComputedExpr:
+		add(n.Member, n.Property)
+	case NewExpr:
+		add(n.Instantiation)
+	case RangeExpr:
+		add(n.Lower, n.Upper)
+	case ArrayLiteral:
+		addAll(add, n.Contents)
+	case ObjectProperty:
+		add(n.ComputedKey, n.Value)
+	case ObjectLiteral:
+		addAll(add, n.Properties)
+
+	// types
+	case ArrayType:
+		add(n.Underlying)
+
+		// NumberExpr, StringExpr, SymbolExpr, BadExpr, BadStmt, ImportStmt, SymbolType
+		// and CommentGroup have no children
+
+	// custom nodes
+	case interface{ Children() []Node }:
+		add(n.Children()...)
+	}
+
+	return children
+}
+
+// Adds every node of a list
+func addAll[T Node](add func(...Node), list []T) {
+	for _, node := range list {
+		add(node)
+	}
+}
+
+// Adds a doc comment if there is one
+func addDoc(add func(...Node), doc *CommentGroup) {
+	if doc != nil {
+		add(*doc)
+	}
+}
@@ -0,0 +1,191 @@
+package ast_test
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Parses source, syntax errors included
+func parse(t *testing.T, source string) ast.BlockStmt {
+	t.Helper()
+	tokens, _ := lexer.Tokenize(source)
+	program, _ := parser.Parse(tokens)
+	return program
+}
+
+// Names of the types in package ast with a Span method, which are the node types
+func declaredNodeTypes(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := goparser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*goast.FuncDecl); ok && fn.Recv != nil && fn.Name.Name == "Span" {
+				names = append(names, fn.Recv.List[0].Type.(*goast.Ident).Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Programs that together hold a node of every type
+var every_node_source = `
+// doc
+export class Point {
+  let x: number = 0;
+  let tags: []string = [];
+  fn norm(): number { return this.x * this.x; }
+}
+import { a } from "./a";
+fn f(n: number) {
+  for let i = 0; i < n; i += 1 {
+    if -i > 2 { return; } else { println(` + "`i=${i}`" + `); }
+  }
+  while false {}
+  foreach k in 0..3 { println(k, "k"); }
+  let p = new Point();
+  let o = { x: p.x, [a]: [1, 2][0], g: fn () {} };
+}
+let broken = ;
+let = 5;
+`
+
+// Every node type is visited by Walk and listed as a child by Children
+func TestWalkVisitsEveryNodeType(t *testing.T) {
+	program := parse(t, every_node_source)
+
+	visited := map[string]bool{}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if node != nil {
+			visited[reflect.TypeOf(node).Name()] = true
+		}
+		return true
+	})
+
+	for _, name := range declaredNodeTypes(t) {
+		if !visited[name] {
+			t.Errorf("%s is never visited", name)
+		}
+	}
+}
+
+// Walk visits a node, then its children in order, then calls Visit(nil)
+func TestWalkOrder(t *testing.T) {
+	var got []string
+	ast.Inspect(parse(t, "let y = f(x, 1) + -2;"), func(node ast.Node) bool {
+		if node == nil {
+			got = append(got, ")")
+		} else {
+			got = append(got, reflect.TypeOf(node).Name())
+		}
+		return true
+	})
+
+	want := "BlockStmt VarDeclStmt BinaryExpr CallExpr SymbolExpr ) SymbolExpr ) NumberExpr ) ) PrefixExpr NumberExpr ) ) ) ) )"
+	if strings.Join(got, " ") != want {
+		t.Errorf("visited\n%s\nwant\n%s", strings.Join(got, " "), want)
+	}
+}
+
+// Returning false from Inspect skips the children of a node, and the Visit(nil) after them
+func TestInspectCutsOffDescent(t *testing.T) {
+	program := parse(t, "let a = 1;\nfn f() { let b = fn () { let c = 2; }; }\nlet d = fn () { let e = 3; };")
+
+	var names []string
+	nils := 0
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case nil:
+			nils++
+		case ast.VarDeclStmt:
+			names = append(names, n.VariableName)
+		case ast.FunctionDeclStmt, ast.FunctionExpr:
+			return false
+		}
+		return true
+	})
+
+	if got := strings.Join(names, " "); got != "a d" {
+		t.Errorf("declarations outside functions %q, want \"a d\"", got)
+	}
+	// BlockStmt, two VarDeclStmt and a NumberExpr end with nil, the functions don't
+	if nils != 4 {
+		t.Errorf("%d calls with nil, want 4", nils)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	program := parse(t, "// doc\nclass C { fn m() {} let x = 1; }\nif a { } \nreturn;")
+	class, ifStmt, returnStmt := program.Body[0], program.Body[1], program.Body[2]
+
+	names := func(nodes []ast.Node) string {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, reflect.TypeOf(node).Name())
+		}
+		return strings.Join(names, " ")
+	}
+
+	// a class lists its doc comment, then its fields, then its methods
+	if got := names(ast.Children(class)); got != "CommentGroup VarDeclStmt FunctionDeclStmt" {
+		t.Errorf("children of class: %s", got)
+	}
+	// missing optional children are left out
+	if got := names(ast.Children(ifStmt)); got != "SymbolExpr BlockStmt" {
+		t.Errorf("children of if without else: %s", got)
+	}
+	if got := ast.Children(returnStmt); len(got) != 0 {
+		t.Errorf("children of return without value: %s", names(got))
+	}
+}
+
+// A node defined outside package ast, with children of its own
+type unlessStmt struct {
+	ast.CustomStmt
+	Condition ast.Expr
+	Body      ast.BlockStmt
+	Loc       lexer.Span
+}
+
+func (n unlessStmt) Span() lexer.Span { return n.Loc }
+
+func (n unlessStmt) Children() []ast.Node { return []ast.Node{n.Condition, n.Body} }
+
+// Walk descends into custom nodes through their Children method
+func TestWalkCustomNode(t *testing.T) {
+	body := parse(t, "println(x);")
+	program := ast.BlockStmt{Body: []ast.Stmt{unlessStmt{Condition: ast.SymbolExpr{Value: "done"}, Body: body}}}
+
+	var symbols []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if symbol, ok := node.(ast.SymbolExpr); ok {
+			symbols = append(symbols, symbol.Value)
+		}
+		return true
+	})
+	if got := strings.Join(symbols, " "); got != "done println x" {
+		t.Errorf("visited symbols %q, want \"done println x\"", got)
+	}
+}
@@ -0,0 +1,99 @@
+// Package astjson converts tokens and syntax trees to and from JSON.
+//
+// The encoding follows the Go types one to one, so it stays stable as long as they do:
+//
+// - Every ast node is an object whose "kind" member is the Go type name, e.g. "VarDeclStmt",
+// followed by its fields in declaration order
+//
+// - Field names are the Go field names starting with a lowercase letter: "variableName", "loc"
+//
+// - Token kinds are their lexer.TokenKindString names: "identifier", "plus_equals"
+//
+// - Spans are {"file", "start", "end"} with positions {"offset", "line", "column"}
+//
+// - Missing interface and pointer values are null, nil lists are null and empty lists are []
+//
+// - Custom nodes defined outside package ast are encoded without "kind" and cannot be decoded
+//
+// Example: `x + 1` encodes as
+//
+//	{"kind": "BinaryExpr",
+//	 "left": {"kind": "SymbolExpr", "value": "x", "loc": {...}},
+//	 "operator": {"kind": "plus", "value": "+", "span": {...}, "comments": null},
+//	 "right": {"kind": "NumberExpr", "value": 1, "integer": true, "raw": "1", "loc": {...}},
+//	 "loc": {...}}
+package astjson
+
+import (
+	"reflect"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Every concrete node type, keyed by the name used as "kind"
+var node_types = func() map[string]reflect.Type {
+	nodes := []ast.Node{
+		// statements
+		ast.BlockStmt{},
+		ast.ExpressionStmt{},
+		ast.VarDeclStmt{},
+		ast.BadStmt{},
+		ast.Parameter{},
+		ast.FunctionDeclStmt{},
+		ast.ReturnStmt{},
+		ast.ClassDeclStmt{},
+		ast.IfStmt{},
+		ast.WhileStmt{},
+		ast.ForStmt{},
+		ast.ForeachStmt{},
+		ast.ImportStmt{},
+
+		// expressions
+		ast.NumberExpr{},
+		ast.StringExpr{},
+		ast.TemplateExpr{},
+		ast.SymbolExpr{},
+		ast.BinaryExpr{},
+		ast.PrefixExpr{},
+		ast.AssignmentExpr{},
+		ast.BadExpr{},
+		ast.FunctionExpr{},
+		ast.CallExpr{},
+		ast.MemberExpr{},
+		ast.ComputedExpr{},
+		ast.NewExpr{},
+		ast.RangeExpr{},
+		ast.ArrayLiteral{},
+		ast.ObjectProperty{},
+		ast.ObjectLiteral{},
+
+		// types
+		ast.SymbolType{},
+		ast.ArrayType{},
+
+		// comments
+		ast.CommentGroup{},
+	}
+
+	types := make(map[string]reflect.Type, len(nodes))
+	for _, node := range nodes {
+		types[reflect.TypeOf(node).Name()] = reflect.TypeOf(node)
+	}
+	return types
+}()
+
+var (
+	astPackage    = reflect.TypeOf(ast.BlockStmt{}).PkgPath()
+	tokenKindType = reflect.TypeOf(lexer.TokenKind(0))
+)
+
+// Reports whether t is an ast node type, which is encoded with a "kind" member
+func isNodeType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == astPackage
+}
+
+// JSON member name of a struct field: VariableName -> variableName
+func memberName(field reflect.StructField) string {
+	return string(field.Name[0]+'a'-'A') + field.Name[1:]
+}
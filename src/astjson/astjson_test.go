@@ -0,0 +1,145 @@
+package astjson
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Programs with every kind of node, syntax errors included
+var round_trip_sources = []string{
+	"let x = 0x1F + 1_000 * 2.5e-3 - 0.1; const big = 9007199254740993;",
+	`let s = "tab\t \"quoted\" é 😀" + 'single' + r"raw\n";`,
+	"let t = `a ${1 + 2} b ${`nested ${x}`} c`;",
+	"/// Doc comment\n/* block */ fn add(a: number, b: number): number { return a + b; } // trailing",
+	"export class Point { let x: number = 0; let tags: []string = []; fn constructor(x: number) { this.x = x; } fn norm(): number { return this.x * this.x; } }",
+	"import { a, b } from \"./lib\"; import lib from \"./lib\"; export let c = lib.f(a)[b];",
+	"let o = { a: 1, [\"b\" + \"c\"]: [1, { d: null }], e: fn (n: any) { return !n; } };",
+	"foreach i in 0..10 { if i % 2 == 0 { continue_(); } else if i > 5 { break_(); } else { x += -i; } }",
+	"for let i = 0; i < 3; i += 1 { while i < 2 { i++; } }",
+	"let kind = typeof -x + typeof typeof x;",
+	"let broken = ;\nprintln(1 +);\nfn ( {}\nlet fine = [1, 2][0];",
+}
+
+// Sources of the round trip tests: the programs above and the examples
+func roundTripSources(t *testing.T) map[string]string {
+	t.Helper()
+	sources := map[string]string{}
+	for i, source := range round_trip_sources {
+		sources[string(rune('a'+i))+".lang"] = source
+	}
+
+	files, _ := filepath.Glob("../../examples/*.lang")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources[file] = string(content)
+	}
+	return sources
+}
+
+// Decoding an encoded syntax tree gives back the same tree, positions and comments included
+func TestRoundTrip(t *testing.T) {
+	for file, source := range roundTripSources(t) {
+		t.Run(file, func(t *testing.T) {
+			tokens, _ := lexer.TokenizeFile(file, source)
+			program, _ := parser.Parse(tokens)
+
+			data, err := Marshal(program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Unmarshal(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, ast.Node(program)) {
+				again, _ := Marshal(decoded)
+				t.Errorf("decoded tree differs\nencoded:\n%s\nencoded again:\n%s", data, again)
+			}
+
+			indented, err := MarshalIndent(program, "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded, err := Unmarshal(indented); err != nil || !reflect.DeepEqual(decoded, ast.Node(program)) {
+				t.Errorf("indented tree decodes differently: %v", err)
+			}
+		})
+	}
+}
+
+func TestRoundTripTokens(t *testing.T) {
+	for file, source := range roundTripSources(t) {
+		t.Run(file, func(t *testing.T) {
+			tokens, _ := lexer.TokenizeFile(file, source)
+
+			data, err := MarshalTokens(tokens)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := UnmarshalTokens(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tokens) {
+				t.Errorf("decoded tokens differ\nencoded:\n%s", data)
+			}
+		})
+	}
+}
+
+// Keywords of extensions are encoded by name, including those named like the language's token kinds
+func TestRoundTripExtensionTokens(t *testing.T) {
+	g := parser.NewGrammar()
+	unless, and := g.Keyword("unless"), g.Keyword("and")
+
+	tokens, diagnostics := g.Syntax().Tokenize("test.lang", "unless a and b && c")
+	if len(diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", diagnostics)
+	}
+	data, err := MarshalTokens(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"unless"`) || !strings.Contains(string(data), `"extension:and"`) {
+		t.Errorf("extension kinds not encoded by name: %s", data)
+	}
+
+	decoded, err := UnmarshalTokens(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, tokens) {
+		t.Errorf("decoded tokens differ\nencoded:\n%s", data)
+	}
+	if decoded[0].Kind != unless || decoded[2].Kind != and || decoded[4].Kind != lexer.AND {
+		t.Errorf("decoded kinds %v %v %v, want unless, extension:and, and", decoded[0].Kind, decoded[2].Kind, decoded[4].Kind)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		want string // part of the error message
+	}{
+		{`{"kind": "BlockStmt", "body": [{"kind": "NoSuchStmt"}]}`, "$.body[0]"},
+		{`{"kind": "BlockStmt", "body": {}}`, "$.body"},
+		{`{"kind": "BinaryExpr", "operator": {"kind": "no_such_kind"}}`, "no_such_kind"},
+		{`{"kind": "BlockStmt"`, "astjson"},
+	}
+
+	for _, test := range tests {
+		if _, err := Unmarshal([]byte(test.data)); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Unmarshal(%s) error = %v, want one mentioning %q", test.data, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Unmarshal decodes a syntax tree encoded by Marshal. The "kind" of the outermost object
+// decides the type of the returned node, e.g. ast.BlockStmt for a whole program.
+//
+// - Unknown members are ignored and missing ones are left at their zero value
+func Unmarshal(data []byte) (ast.Node, error) {
+	var node ast.Node
+	if err := decode(data, &node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// UnmarshalTokens decodes a token list encoded by MarshalTokens
+func UnmarshalTokens(data []byte) ([]lexer.Token, error) {
+	var tokens []lexer.Token
+	if err := decode(data, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Parses data generically, keeping numbers exact, then fills target by reflection
+func decode(data []byte, target any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw any
+	if err := decoder.Decode(&raw); err != nil {
+		return fmt.Errorf("astjson: %w", err)
+	}
+	return decodeValue(raw, reflect.ValueOf(target).Elem(), "$")
+}
+
+// Stores raw into v. path locates raw in the document for error messages, e.g. $.body[2].loc
+func decodeValue(raw any, v reflect.Value, path string) error {
+	if v.Type() == tokenKindType {
+		name, ok := raw.(string)
+		if !ok {
+			return mismatch(path, "token kind name", raw)
+		}
+		kind, exists := lexer.ParseTokenKind(name)
+		if !exists {
+			return fmt.Errorf("astjson: %s: unknown token kind %q", path, name)
+		}
+		v.SetInt(int64(kind))
+		return nil
+	}
+
+	if raw == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return decodeNode(raw, v, path)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(raw, elem.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		return decodeObject(raw, v, path)
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			return mismatch(path, "array", raw)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.String:
+		text, ok := raw.(string)
+		if !ok {
+			return mismatch(path, "string", raw)
+		}
+		v.SetString(text)
+		return nil
+	case reflect.Bool:
+		flag, ok := raw.(bool)
+		if !ok {
+			return mismatch(path, "boolean", raw)
+		}
+		v.SetBool(flag)
+		return nil
+	case reflect.Int:
+		number, ok := raw.(json.Number)
+		if !ok {
+			return mismatch(path, "integer", raw)
+		}
+		integer, err := number.Int64()
+		if err != nil {
+			return fmt.Errorf("astjson: %s: %w", path, err)
+		}
+		v.SetInt(integer)
+		return nil
+	case reflect.Float64:
+		number, ok := raw.(json.Number)
+		if !ok {
+			return mismatch(path, "number", raw)
+		}
+		float, err := number.Float64()
+		if err != nil {
+			return fmt.Errorf("astjson: %s: %w", path, err)
+		}
+		v.SetFloat(float)
+		return nil
+	default:
+		return fmt.Errorf("astjson: %s: cannot decode into %s", path, v.Type())
+	}
+}
+
+// Decodes an object into an interface such as ast.Expr, choosing the concrete type from "kind"
+func decodeNode(raw any, v reflect.Value, path string) error {
+	object, ok := raw.(map[string]any)
+	if !ok {
+		return mismatch(path, "node object", raw)
+	}
+
+	kind, _ := object["kind"].(string)
+	nodeType, exists := node_types[kind]
+	if !exists {
+		return fmt.Errorf("astjson: %s: unknown node kind %q", path, kind)
+	}
+	if !nodeType.Implements(v.Type()) {
+		return fmt.Errorf("astjson: %s: %s is not a valid %s", path, kind, v.Type())
+	}
+
+	node := reflect.New(nodeType).Elem()
+	if err := decodeObject(object, node, path); err != nil {
+		return err
+	}
+	v.Set(node)
+	return nil
+}
+
+// Decodes an object into a struct field by field
+func decodeObject(raw any, v reflect.Value, path string) error {
+	object, ok := raw.(map[string]any)
+	if !ok {
+		return mismatch(path, "object", raw)
+	}
+
+	if isNodeType(v.Type()) {
+		if kind, ok := object["kind"].(string); !ok || kind != v.Type().Name() {
+			return fmt.Errorf("astjson: %s: expected kind %q, found %v", path, v.Type().Name(), object["kind"])
+		}
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name := memberName(field)
+		if err := decodeValue(object[name], v.Field(i), path+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mismatch(path string, expected string, raw any) error {
+	return fmt.Errorf("astjson: %s: expected %s, found %T", path, expected, raw)
+}
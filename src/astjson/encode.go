@@ -0,0 +1,148 @@
+package astjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Marshal encodes a syntax tree, usually the ast.BlockStmt returned by the parser
+func Marshal(node ast.Node) ([]byte, error) {
+	return encode(node)
+}
+
+// MarshalTokens encodes a token list as a JSON array
+func MarshalTokens(tokens []lexer.Token) ([]byte, error) {
+	return encode(tokens)
+}
+
+// MarshalIndent is like Marshal but indents the output for reading
+func MarshalIndent(node ast.Node, indent string) ([]byte, error) {
+	data, err := Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	return indentJSON(data, indent)
+}
+
+// MarshalTokensIndent is like MarshalTokens but indents the output for reading
+func MarshalTokensIndent(tokens []lexer.Token, indent string) ([]byte, error) {
+	data, err := MarshalTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return indentJSON(data, indent)
+}
+
+func indentJSON(data []byte, indent string) ([]byte, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Writes the encoding of a value, walking it by reflection
+type encoder struct {
+	buf bytes.Buffer
+}
+
+func encode(value any) ([]byte, error) {
+	e := &encoder{}
+	if err := e.value(reflect.ValueOf(value)); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+func (e *encoder) value(v reflect.Value) error {
+	if v.Type() == tokenKindType {
+		return e.scalar(lexer.TokenKindString(lexer.TokenKind(v.Int())))
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			e.buf.WriteString("null")
+			return nil
+		}
+		return e.value(v.Elem())
+	case reflect.Struct:
+		return e.object(v)
+	case reflect.Slice:
+		return e.array(v)
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Float64:
+		return e.scalar(v.Interface())
+	default:
+		return fmt.Errorf("astjson: cannot encode %s", v.Type())
+	}
+}
+
+// Structs become objects, with the type name under "kind" for ast nodes
+func (e *encoder) object(v reflect.Value) error {
+	e.buf.WriteByte('{')
+
+	first := true
+	if isNodeType(v.Type()) {
+		e.buf.WriteString(`"kind":`)
+		e.scalar(v.Type().Name())
+		first = false
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		if !first {
+			e.buf.WriteByte(',')
+		}
+		first = false
+
+		e.scalar(memberName(field))
+		e.buf.WriteByte(':')
+		if err := e.value(v.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	e.buf.WriteByte('}')
+	return nil
+}
+
+func (e *encoder) array(v reflect.Value) error {
+	if v.IsNil() {
+		e.buf.WriteString("null")
+		return nil
+	}
+
+	e.buf.WriteByte('[')
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.buf.WriteByte(',')
+		}
+		if err := e.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	e.buf.WriteByte(']')
+	return nil
+}
+
+// Strings, numbers and booleans, without escaping < > & so file names like <stdin> stay readable
+func (e *encoder) scalar(value any) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("astjson: %w", err)
+	}
+	e.buf.Write(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+	return nil
+}
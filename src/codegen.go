@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/codegen/golang"
+	"github.com/thutasann/go-parser/src/codegen/js"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+	"github.com/thutasann/go-parser/src/types"
+)
+
+// `js [-o file] [-map] [file]` translates a module to JavaScript. Imports refer to the
+// .js files of the imported modules, which are translated separately.
+func runJS(args []string) int {
+	flags := flag.NewFlagSet("js", flag.ContinueOnError)
+	output := flags.String("o", "", "write the module to `file` instead of standard output")
+	sourceMap := flags.Bool("map", false, "write a source map to the output file name plus .map (requires -o)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *sourceMap && *output == "" {
+		fmt.Fprintln(os.Stderr, "js: -map requires -o")
+		return 2
+	}
+
+	program, source, r, exit := parse("js", flags.Args())
+	if exit != 0 {
+		return exit
+	}
+
+	options := js.Options{SourceMap: *sourceMap}
+	if *sourceMap {
+		options.File = filepath.Base(*output)
+		options.SourceRoot = sourceRoot(*output, program.Loc.File)
+		options.Sources = map[string][]byte{program.Loc.File: source}
+	}
+
+	generated, diagnostics := js.Generate(program, options)
+	if r.diagnostics(diagnostics) {
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(generated.Code)
+		return 0
+	}
+
+	code := generated.Code
+	if *sourceMap {
+		code = append(code, "//# sourceMappingURL="+options.File+".map\n"...)
+		if err := os.WriteFile(*output+".map", generated.SourceMap, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "js:", err)
+			return 1
+		}
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "js:", err)
+		return 1
+	}
+	return 0
+}
+
+// `go [-o file] [file]` translates a program to a Go main package. The program is type
+// checked first, since the Go types of its values come from the checker.
+func runGo(args []string) int {
+	flags := flag.NewFlagSet("go", flag.ContinueOnError)
+	output := flags.String("o", "", "write the package to `file` instead of standard output")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	program, _, r, exit := parse("go", flags.Args())
+	if exit != 0 {
+		return exit
+	}
+
+	info, typeErrors := types.CheckInfo(program)
+	if r.diagnostics(typeErrors) {
+		return 1
+	}
+
+	code, diagnostics := golang.Generate(program, info)
+	if r.diagnostics(diagnostics) {
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(code)
+		return 0
+	}
+	if err := os.WriteFile(*output, code, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "go:", err)
+		return 1
+	}
+	return 0
+}
+
+// Parses the single file named by args and prints the syntax errors.
+// Returns the program with the source it was parsed from, and a non-zero exit status when the
+// file could not be read or has errors.
+func parse(command string, args []string) (ast.BlockStmt, []byte, *reporter, int) {
+	path, source, exit := readInput(command, args)
+	if exit != 0 {
+		return ast.BlockStmt{}, nil, nil, exit
+	}
+
+	r := newReporter(os.Stderr)
+	r.addSource(path, source)
+
+	tokens, lexErrors := lexer.TokenizeFile(path, string(source))
+	program, parseErrors := parser.Parse(tokens)
+	return program, source, r, status(r.diagnostics(append(lexErrors, parseErrors...)))
+}
+
+// Path from the directory of the generated file to the directory source paths are relative to,
+// so tools reading the source map next to the output find the source
+func sourceRoot(output string, source string) string {
+	if filepath.IsAbs(source) {
+		return ""
+	}
+
+	outputDir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return ""
+	}
+	workingDir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	root, err := filepath.Rel(outputDir, workingDir)
+	if err != nil || root == "." {
+		return ""
+	}
+	return filepath.ToSlash(root) + "/"
+}
@@ -0,0 +1,722 @@
+package golang
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/types"
+)
+
+// Go operator precedence. Unlike the source language, && binds tighter than ||.
+// Calls, conversions and type assertions are primary expressions.
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precCompare
+	precAdditive
+	precMultiplicative
+	precUnary
+	precPrimary
+)
+
+// Precedence of the Go expression expr is printed as
+func precedence(expr ast.Expr) int {
+	switch n := expr.(type) {
+	case ast.BinaryExpr:
+		switch n.Operator.Kind {
+		case lexer.OR:
+			return precOr
+		case lexer.AND:
+			return precAnd
+		case lexer.PLUS, lexer.DASH:
+			return precAdditive
+		case lexer.STAR, lexer.SLASH:
+			return precMultiplicative
+		case lexer.PERCENT:
+			// math.Mod(a, b)
+			return precPrimary
+		default:
+			return precCompare
+		}
+	case ast.PrefixExpr:
+		return precUnary
+	case ast.TemplateExpr:
+		if len(templateParts(n)) > 1 {
+			return precAdditive
+		}
+		return precPrimary
+	default:
+		return precPrimary
+	}
+}
+
+// Prints expr as a Go value of the type the checker found for it, adding parentheses when
+// its operator binds looser than outer
+func (g *generator) expr(expr ast.Expr, outer int) {
+	if precedence(expr) < outer {
+		g.write("(")
+		g.exprBody(expr)
+		g.write(")")
+		return
+	}
+	g.exprBody(expr)
+}
+
+// Prints expr converted to the type want: values of type any are asserted to it,
+// null becomes nil and array literals are built with want's element type
+func (g *generator) value(expr ast.Expr, want types.Type, outer int) {
+	have := g.typeOf(expr)
+
+	switch {
+	case have == types.Null && (isNull(expr) || want != types.Any):
+		g.write("nil")
+	case isArray(want) && isArrayLiteral(expr):
+		g.arrayLiteral(expr.(ast.ArrayLiteral), want.(*types.Array).Elem)
+	case have == types.Any && want != types.Any && want != types.Null && want != types.Void:
+		g.expr(expr, precPrimary)
+		g.write(".(" + g.goType(want) + ")")
+	case isArray(have) && isArray(want) && g.goType(have) != g.goType(want):
+		g.errorf(CodeUnsupported, expr.Span(), "cannot use %s as %s in Go, slices aren't converted", have, want)
+		g.write("nil")
+	default:
+		g.expr(expr, outer)
+	}
+}
+
+// Prints expr as a string, formatting values of other types the way println does
+func (g *generator) text(expr ast.Expr, outer int) {
+	if g.typeOf(expr) == types.String {
+		g.expr(expr, outer)
+		return
+	}
+	g.helper("stringify")
+	g.write("stringify(")
+	g.expr(expr, precLowest)
+	g.write(")")
+}
+
+func (g *generator) exprBody(expr ast.Expr) {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		g.write(number(n.Value))
+	case ast.StringExpr:
+		g.write(strconv.Quote(n.Value))
+	case ast.TemplateExpr:
+		parts := templateParts(n)
+		if len(parts) == 0 {
+			g.write(`""`)
+		}
+		for i, part := range parts {
+			if i > 0 {
+				g.write(" + ")
+			}
+			if text, ok := part.(string); ok {
+				g.write(strconv.Quote(text))
+			} else {
+				g.text(part.(ast.Expr), precAdditive+1)
+			}
+		}
+	case ast.SymbolExpr:
+		g.symbol(n)
+	case ast.BinaryExpr:
+		g.binary(n)
+	case ast.PrefixExpr:
+		if n.Operator.Kind == lexer.TYPEOF {
+			g.helper("typeOf")
+			g.write("typeOf(")
+			g.value(n.RightExpr, types.Any, precLowest)
+			g.write(")")
+			break
+		}
+		g.write(n.Operator.Value)
+		want := types.Type(types.Number)
+		if n.Operator.Kind == lexer.NOT {
+			want = types.Boolean
+		}
+		if _, nested := n.RightExpr.(ast.PrefixExpr); nested {
+			// - -x would read as the decrement operator
+			g.write("(")
+			g.value(n.RightExpr, want, precLowest)
+			g.write(")")
+		} else {
+			g.value(n.RightExpr, want, precUnary)
+		}
+	case ast.AssignmentExpr:
+		g.errorf(CodeUnsupported, n.Span(), "assignments are statements in Go and can't be used as values")
+		g.write("nil")
+	case ast.FunctionExpr:
+		fn, _ := g.typeOf(n).(*types.Function)
+		g.write("func")
+		g.function(n.Parameters, fn, n.Body)
+	case ast.CallExpr:
+		g.call(n)
+	case ast.MemberExpr:
+		g.member(n)
+	case ast.ComputedExpr:
+		g.computed(n)
+	case ast.NewExpr:
+		g.instantiate(n)
+	case ast.RangeExpr:
+		g.errorf(CodeUnsupported, n.Span(), "ranges can only be used in foreach loops")
+		g.write("nil")
+	case ast.ArrayLiteral:
+		elem := types.Type(types.Any)
+		if array, ok := g.typeOf(n).(*types.Array); ok {
+			elem = array.Elem
+		}
+		g.arrayLiteral(n, elem)
+	case ast.ObjectLiteral:
+		g.objectLiteral(n)
+	case ast.BadExpr:
+		g.errorf(CodeSyntaxError, n.Span(), "cannot translate an expression that failed to parse")
+		g.write("nil")
+	default:
+		g.errorf(CodeUnsupported, expr.Span(), "cannot translate %T to Go", expr)
+		g.write("nil")
+	}
+}
+
+func (g *generator) symbol(n ast.SymbolExpr) {
+	switch {
+	case n.Value == "null":
+		g.write("nil")
+	case n.Value == "true" || n.Value == "false" || n.Value == "this":
+		g.write(n.Value)
+	case (n.Value == "println" || n.Value == "print") && !g.declared[n.Value]:
+		g.helper(n.Value)
+		g.write(n.Value)
+	case n.Value == "len" && !g.declared[n.Value]:
+		g.errorf(CodeUnsupported, n.Span(), "len can only be called in Go, not used as a value")
+		g.write("nil")
+	default:
+		if _, isClass := g.typeOf(n).(*types.ClassRef); isClass {
+			g.errorf(CodeUnsupported, n.Span(), "classes can only be used with new in Go, not as values")
+		}
+		g.write(g.identifier(n.Value))
+	}
+}
+
+func (g *generator) binary(n ast.BinaryExpr) {
+	prec := precedence(n)
+	left, right := g.typeOf(n.Left), g.typeOf(n.Right)
+	operator := " " + n.Operator.Value + " "
+
+	switch n.Operator.Kind {
+	case lexer.EQUALS, lexer.NOT_EQUALS:
+		g.equality(n, left, right)
+	case lexer.AND, lexer.OR:
+		g.value(n.Left, types.Boolean, prec)
+		g.write(operator)
+		g.value(n.Right, types.Boolean, prec+1)
+	case lexer.LESS, lexer.LESS_EQUALS, lexer.GREATER, lexer.GREATER_EQUALS:
+		operand := types.Type(types.Number)
+		if left == types.String || right == types.String {
+			operand = types.String
+		}
+		g.value(n.Left, operand, prec)
+		g.write(operator)
+		g.value(n.Right, operand, prec+1)
+	case lexer.PERCENT:
+		g.imports["math"] = true
+		g.write("math.Mod(")
+		g.value(n.Left, types.Number, precLowest)
+		g.write(", ")
+		g.value(n.Right, types.Number, precLowest)
+		g.write(")")
+	default:
+		if n.Operator.Kind == lexer.PLUS && g.typeOf(n) == types.String {
+			g.text(n.Left, prec)
+			g.write(operator)
+			g.text(n.Right, prec+1)
+			return
+		}
+		if constant(n.Left) && constant(n.Right) {
+			// Go evaluates untyped constants exactly, a typed one rounds every step like float64 math at run time
+			g.write("float64(")
+			g.expr(n.Left, precLowest)
+			g.write(")")
+		} else {
+			g.value(n.Left, types.Number, prec)
+		}
+		g.write(operator)
+		g.value(n.Right, types.Number, prec+1)
+	}
+}
+
+// Reports whether Go would evaluate expr as an untyped constant
+func constant(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		return true
+	case ast.PrefixExpr:
+		return n.Operator.Kind == lexer.DASH && constant(n.RightExpr)
+	case ast.BinaryExpr:
+		switch n.Operator.Kind {
+		case lexer.PLUS, lexer.DASH, lexer.STAR, lexer.SLASH:
+			return constant(n.Left) && constant(n.Right)
+		}
+	}
+	return false
+}
+
+// == and != compare numbers, strings and booleans by value and instances by identity, like
+// the interpreter. Comparisons Go rejects for mismatched types are done on any values instead.
+func (g *generator) equality(n ast.BinaryExpr, left types.Type, right types.Type) {
+	prec := precedence(n)
+	operator := " " + n.Operator.Value + " "
+
+	for _, side := range []types.Type{left, right} {
+		switch side.(type) {
+		case *types.Array, *types.Object, *types.Function:
+			if left != types.Null && right != types.Null {
+				g.errorf(CodeUnsupported, n.Span(), "Go can only compare %s values with null", side)
+				g.write("false")
+				return
+			}
+		}
+	}
+
+	if isNull(n.Left) && isNull(n.Right) {
+		// nil == nil doesn't compile
+		g.write(strconv.FormatBool(n.Operator.Kind == lexer.EQUALS))
+		return
+	}
+
+	if left == right || left == types.Any || right == types.Any || left == types.Null && nillable(right) || right == types.Null && nillable(left) {
+		g.expr(n.Left, prec)
+		g.write(operator)
+		g.expr(n.Right, prec+1)
+		return
+	}
+
+	g.write("any(")
+	g.expr(n.Left, precLowest)
+	g.write(")" + operator + "any(")
+	g.expr(n.Right, precLowest)
+	g.write(")")
+}
+
+func (g *generator) call(n ast.CallExpr) {
+	if symbol, ok := n.Method.(ast.SymbolExpr); ok && !g.declared[symbol.Value] {
+		switch symbol.Value {
+		case "println", "print":
+			g.helper(symbol.Value)
+			g.write(symbol.Value + "(")
+			for i, argument := range n.Arguments {
+				if i > 0 {
+					g.write(", ")
+				}
+				g.expr(argument, precLowest)
+			}
+			g.write(")")
+			return
+		case "len":
+			g.length(n)
+			return
+		}
+	}
+
+	if member, ok := n.Method.(ast.MemberExpr); ok && member.Property == "push" && isArray(g.typeOf(member.Member)) {
+		g.errorf(CodeUnsupported, n.Span(), "push can only be used as a statement in Go")
+		g.write("nil")
+		return
+	}
+
+	fn, ok := g.typeOf(n.Method).(*types.Function)
+	if !ok {
+		g.errorf(CodeUnknownType, n.Method.Span(), "cannot call a value of type %s in Go", g.typeOf(n.Method))
+		g.write("nil")
+		return
+	}
+
+	g.expr(n.Method, precPrimary)
+	g.arguments(n.Arguments, fn.Params)
+}
+
+// `(a, b, c)` with each argument converted to its parameter type
+func (g *generator) arguments(arguments []ast.Expr, params []types.Type) {
+	g.write("(")
+	for i, argument := range arguments {
+		if i > 0 {
+			g.write(", ")
+		}
+		want := types.Type(types.Any)
+		if i < len(params) {
+			want = params[i]
+		}
+		g.value(argument, want, precLowest)
+	}
+	g.write(")")
+}
+
+// len(value) counts the characters of a string or the elements of an array
+func (g *generator) length(n ast.CallExpr) {
+	if len(n.Arguments) != 1 {
+		g.errorf(CodeUnsupported, n.Span(), "len expects 1 argument but received %d", len(n.Arguments))
+		g.write("0.0")
+		return
+	}
+
+	argument := n.Arguments[0]
+	switch t := g.typeOf(argument); {
+	case t == types.String:
+		g.imports["unicode/utf8"] = true
+		g.write("float64(utf8.RuneCountInString(")
+		g.expr(argument, precLowest)
+		g.write("))")
+	case isArray(t):
+		g.write("float64(len(")
+		g.expr(argument, precLowest)
+		g.write("))")
+	default:
+		g.errorf(CodeUnknownType, argument.Span(), "cannot take the length of a value of type %s in Go", t)
+		g.write("0.0")
+	}
+}
+
+func (g *generator) member(n ast.MemberExpr) {
+	switch t := g.typeOf(n.Member).(type) {
+	case *types.Class:
+		g.expr(n.Member, precPrimary)
+		g.write("." + g.identifier(n.Property))
+	case *types.Object:
+		g.expr(n.Member, precPrimary)
+		g.write("[" + strconv.Quote(n.Property) + "]")
+		if field := t.Fields[n.Property]; field != nil && field != types.Any {
+			g.write(".(" + g.goType(field) + ")")
+		}
+	case *types.Array:
+		if n.Property != "length" {
+			g.errorf(CodeUnsupported, n.Span(), "%s can only be called in Go, not used as a value", n.Property)
+			g.write("nil")
+			return
+		}
+		g.write("float64(len(")
+		g.expr(n.Member, precLowest)
+		g.write("))")
+	default:
+		g.errorf(CodeUnknownType, n.Member.Span(), "cannot access %s on a value of type %s in Go", n.Property, t)
+		g.write("nil")
+	}
+}
+
+func (g *generator) computed(n ast.ComputedExpr) {
+	switch t := g.typeOf(n.Member); {
+	case isArray(t):
+		g.expr(n.Member, precPrimary)
+		g.index(n.Property)
+	case t == types.String:
+		// strings are indexed by character
+		g.write("string([]rune(")
+		g.expr(n.Member, precLowest)
+		g.write(")")
+		g.index(n.Property)
+		g.write(")")
+	case isObject(t):
+		g.expr(n.Member, precPrimary)
+		g.write("[")
+		g.value(n.Property, types.String, precLowest)
+		g.write("]")
+	default:
+		g.errorf(CodeUnknownType, n.Member.Span(), "cannot index a value of type %s in Go", t)
+		g.write("nil")
+	}
+}
+
+// `[i]`, converting the number to an int
+func (g *generator) index(property ast.Expr) {
+	if literal, ok := property.(ast.NumberExpr); ok && literal.Value == math.Trunc(literal.Value) && literal.Value < 1<<31 {
+		g.write("[" + strconv.Itoa(int(literal.Value)) + "]")
+		return
+	}
+	g.write("[int(")
+	g.value(property, types.Number, precLowest)
+	g.write(")]")
+}
+
+// `new Name(args...)` calls the newName function declared with the class
+func (g *generator) instantiate(n ast.NewExpr) {
+	ref, ok := g.typeOf(n.Instantiation.Method).(*types.ClassRef)
+	if !ok {
+		g.errorf(CodeUnknownType, n.Instantiation.Method.Span(), "cannot instantiate a value of type %s in Go", g.typeOf(n.Instantiation.Method))
+		g.write("nil")
+		return
+	}
+
+	var params []types.Type
+	if constructor, exists := ref.Class.Methods["constructor"]; exists {
+		params = constructor.Params
+	}
+	g.write("new" + ref.Class.Name)
+	g.arguments(n.Instantiation.Arguments, params)
+}
+
+// `[]T{a, b, c}`
+func (g *generator) arrayLiteral(n ast.ArrayLiteral, elem types.Type) {
+	g.write("[]" + g.goType(elem) + "{")
+	for i, element := range n.Contents {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.value(element, elem, precLowest)
+	}
+	g.write("}")
+}
+
+// `map[string]any{"key": value, computed: value}`
+func (g *generator) objectLiteral(n ast.ObjectLiteral) {
+	g.write("map[string]any{")
+	for i, property := range n.Properties {
+		if i > 0 {
+			g.write(", ")
+		}
+		if property.ComputedKey != nil {
+			g.value(property.ComputedKey, types.String, precLowest)
+		} else {
+			g.write(strconv.Quote(property.Key))
+		}
+		g.write(": ")
+		g.expr(property.Value, precLowest)
+	}
+	g.write("}")
+}
+
+// Assignments are statements in Go. Compound assignments to map entries are spelled out,
+// since the entry has to be asserted to a number or string first.
+func (g *generator) assignment(n ast.AssignmentExpr) {
+	target := g.typeOf(n.Assigne)
+	compound := n.Operator.Kind != lexer.ASSIGNMENT
+
+	entry := false
+	switch assigne := n.Assigne.(type) {
+	case ast.SymbolExpr:
+		g.write(g.identifier(assigne.Value))
+	case ast.MemberExpr:
+		switch object := g.typeOf(assigne.Member); {
+		case isObject(object):
+			g.expr(assigne.Member, precPrimary)
+			g.write("[" + strconv.Quote(assigne.Property) + "]")
+			entry = true
+		case isClass(object):
+			g.expr(assigne.Member, precPrimary)
+			g.write("." + g.identifier(assigne.Property))
+		default:
+			g.errorf(CodeUnknownType, assigne.Member.Span(), "cannot assign %s on a value of type %s in Go", assigne.Property, object)
+			return
+		}
+	case ast.ComputedExpr:
+		switch object := g.typeOf(assigne.Member); {
+		case isArray(object):
+			g.expr(assigne.Member, precPrimary)
+			g.index(assigne.Property)
+		case isObject(object):
+			g.expr(assigne.Member, precPrimary)
+			g.write("[")
+			g.value(assigne.Property, types.String, precLowest)
+			g.write("]")
+			entry = true
+		default:
+			g.errorf(CodeUnknownType, assigne.Member.Span(), "cannot assign an element of a value of type %s in Go", object)
+			return
+		}
+	default:
+		g.errorf(CodeUnsupported, n.Assigne.Span(), "invalid assignment target")
+		return
+	}
+
+	if !compound {
+		g.write(" = ")
+		g.value(n.Value, target, precLowest)
+		return
+	}
+
+	// the operation the compound assignment performs: string concatenation or arithmetic
+	operation := ast.BinaryExpr{
+		Left:     n.Assigne,
+		Operator: lexer.NewTokenAt(lexer.DASH, "-", n.Operator.Span),
+		Right:    n.Value,
+		Loc:      n.Loc,
+	}
+	if n.Operator.Kind == lexer.PLUS_EQUALS {
+		operation.Operator = lexer.NewTokenAt(lexer.PLUS, "+", n.Operator.Span)
+	}
+
+	switch {
+	case entry || target == types.Any:
+		// the variable or entry holds an any, which Go can't add to directly
+		g.write(" = ")
+		g.binary(operation)
+	case target == types.String:
+		g.write(" += ")
+		g.text(n.Value, precLowest)
+	default:
+		g.write(" " + n.Operator.Value + " ")
+		g.value(n.Value, types.Number, precLowest)
+	}
+}
+
+// Pieces a template is made of: strings for the text, expressions for the interpolations.
+// Empty text is left out.
+func templateParts(n ast.TemplateExpr) []any {
+	var parts []any
+	for i, text := range n.Strings {
+		if text != "" {
+			parts = append(parts, text)
+		}
+		if i < len(n.Expressions) {
+			parts = append(parts, n.Expressions[i])
+		}
+	}
+	return parts
+}
+
+// Number literal in floating point form, so Go doesn't treat it as an integer constant
+// and divide 7 / 2 as integers
+func number(value float64) string {
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	if value == math.Trunc(value) && math.Abs(value) < 1e21 {
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	if !strings.ContainsAny(text, ".eE") {
+		text += ".0"
+	}
+	return text
+}
+
+// Go type of a checked type
+func (g *generator) goType(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Array:
+		return "[]" + g.goType(t.Elem)
+	case *types.Object:
+		return "map[string]any"
+	case *types.Class:
+		return "*" + g.identifier(t.Name)
+	case *types.Function:
+		result := types.Type(nil)
+		if t.Return != types.Void {
+			result = t.Return
+		}
+		return g.funcType(t, result)
+	}
+
+	switch t {
+	case types.Number:
+		return "float64"
+	case types.String:
+		return "string"
+	case types.Boolean:
+		return "bool"
+	default:
+		return "any"
+	}
+}
+
+// `func(float64, string) result`, without a result when result is nil
+func (g *generator) funcType(fn *types.Function, result types.Type) string {
+	if fn == nil {
+		return "func()"
+	}
+	params := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		params[i] = g.goType(param)
+	}
+	text := "func(" + strings.Join(params, ", ") + ")"
+	if result != nil {
+		text += " " + g.goType(result)
+	}
+	return text
+}
+
+// Value a variable of type t starts with when declared without one, and functions return
+// when they run off their end
+func zeroValue(t types.Type) string {
+	switch t {
+	case types.Number:
+		return "0.0"
+	case types.String:
+		return `""`
+	case types.Boolean:
+		return "false"
+	default:
+		return "nil"
+	}
+}
+
+// Reports whether values of type t can be compared with nil in Go
+func nillable(t types.Type) bool {
+	switch t {
+	case types.Number, types.String, types.Boolean:
+		return false
+	default:
+		return true
+	}
+}
+
+func isArray(t types.Type) bool {
+	_, ok := t.(*types.Array)
+	return ok
+}
+
+func isObject(t types.Type) bool {
+	_, ok := t.(*types.Object)
+	return ok
+}
+
+func isClass(t types.Type) bool {
+	_, ok := t.(*types.Class)
+	return ok
+}
+
+func isNull(expr ast.Expr) bool {
+	symbol, ok := expr.(ast.SymbolExpr)
+	return ok && symbol.Value == "null"
+}
+
+func isArrayLiteral(expr ast.Expr) bool {
+	_, ok := expr.(ast.ArrayLiteral)
+	return ok
+}
+
+// Go keywords, predeclared names and names the output uses itself, which are
+// identifiers in the source language
+var reserved_words = map[string]bool{
+	// keywords
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+
+	// predeclared
+	"any": true, "append": true, "bool": true, "byte": true, "cap": true,
+	"clear": true, "close": true, "comparable": true, "complex": true, "complex64": true,
+	"complex128": true, "copy": true, "delete": true, "error": true, "float32": true,
+	"float64": true, "imag": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "iota": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "nil": true, "panic": true,
+	"print": true, "println": true, "real": true, "recover": true, "rune": true,
+	"string": true, "uint": true, "uint8": true, "uint16": true, "uint32": true,
+	"uint64": true, "uintptr": true,
+
+	// the output's own names
+	"main": true, "init": true, "end": true, "fmt": true, "math": true,
+	"reflect": true, "runtime": true, "sort": true, "strconv": true, "strings": true,
+	"utf8": true, "join": true, "format": true, "stringify": true, "formatNumber": true,
+	"typeOf": true,
+}
+
+// Name of a variable, field or type in the output. Reserved names, including the new<Class>
+// functions of the program's classes, get a _ suffix, and so do names already ending in one,
+// so that len and len_ don't both become len_. Names the output makes up for itself end in a
+// single _ after a word that isn't reserved, e.g. index_, which leaves them free.
+func (g *generator) identifier(name string) string {
+	if reserved_words[name] || g.constructors[name] || strings.HasSuffix(name, "_") {
+		return name + "_"
+	}
+	return name
+}
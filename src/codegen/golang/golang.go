@@ -0,0 +1,692 @@
+// Package golang translates type checked programs to Go source.
+//
+// A program becomes a main package: functions and classes become Go declarations, top-level
+// variables become package variables, and the remaining top-level statements the body of main.
+//
+// - number is float64, []T a slice, classes are structs used through pointers and built by a
+// newName function that sets the fields and runs the constructor, objects are map[string]any
+//
+// - Values of type any are converted with type assertions wherever a specific type is needed
+//
+// - println and print print values the way the interpreter does, except that object keys
+// come out sorted
+//
+// Some programs have no Go equivalent and are reported instead: imports, classes declared
+// inside functions, assignments used as values and operations on values of unknown type.
+// Functions returning values of unknown type can't be called either, which includes closures
+// returned from functions. Dividing by zero gives an infinity rather than a runtime error,
+// a function that runs off its end returns the zero value of its result type instead of null,
+// and push appends to the variable or field it is called on, since Go slices are values.
+package golang
+
+import (
+	"bytes"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/types"
+)
+
+// Code generator diagnostic codes
+const (
+	CodeSyntaxError lexer.DiagnosticCode = "G0001" // statement or expression that failed to parse
+	CodeUnsupported lexer.DiagnosticCode = "G0002" // construct without a Go equivalent
+	CodeUnknownType lexer.DiagnosticCode = "G0003" // operation on a value of unknown type, which Go can't express
+	CodeFormat      lexer.DiagnosticCode = "G0004" // generated code that go/format rejects
+)
+
+// Holds the output being built
+// out: the section being written, decls or main
+// result: Go result type of the function being written, nil when it has none
+// declared: names the program declares, see declaredNames
+// constructors: names of the new<Class> functions of the program's classes
+// predeclared: local functions declared ahead of their declaration, for functions before them that call them
+// helpers: runtime functions the output needs
+type generator struct {
+	info         *types.Info
+	decls        bytes.Buffer
+	main         bytes.Buffer
+	out          *bytes.Buffer
+	result       types.Type
+	declared     map[string]bool
+	constructors map[string]bool
+	predeclared  map[lexer.Span]bool
+	imports      map[string]bool
+	helpers      map[string]bool
+	diagnostics  []lexer.Diagnostic
+}
+
+// Generate translates a program checked with types.CheckInfo into a formatted Go main package.
+// The program should be free of type errors; what cannot be translated is reported as diagnostics.
+func Generate(program ast.BlockStmt, info *types.Info) ([]byte, []lexer.Diagnostic) {
+	g := &generator{
+		info:         info,
+		declared:     declaredNames(program),
+		constructors: map[string]bool{},
+		predeclared:  map[lexer.Span]bool{},
+		imports:      map[string]bool{},
+		helpers:      map[string]bool{},
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if class, ok := node.(ast.ClassDeclStmt); ok {
+			g.constructors["new"+class.Name] = true
+		}
+		return true
+	})
+
+	for _, stmt := range program.Body {
+		g.topLevel(stmt)
+	}
+
+	var file bytes.Buffer
+	file.WriteString("// Code generated by lang go from " + program.Loc.File + ". DO NOT EDIT.\n\npackage main\n\n")
+	if len(g.imports) > 0 {
+		paths := make([]string, 0, len(g.imports))
+		for path := range g.imports {
+			paths = append(paths, strconv.Quote(path))
+		}
+		sort.Strings(paths)
+		file.WriteString("import (\n" + strings.Join(paths, "\n") + "\n)\n\n")
+	}
+	file.Write(g.decls.Bytes())
+	file.WriteString("func main() {\n")
+	file.Write(g.main.Bytes())
+	file.WriteString("}\n")
+	for _, name := range sortedKeys(g.helpers) {
+		file.WriteString("\n" + runtime_functions[name].code + "\n")
+	}
+
+	formatted, err := format.Source(file.Bytes())
+	if err != nil {
+		g.errorf(CodeFormat, program.Loc, "generated code does not parse: %v", err)
+		return file.Bytes(), g.diagnostics
+	}
+	return formatted, g.diagnostics
+}
+
+// Records an error
+func (g *generator) errorf(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	g.diagnostics = append(g.diagnostics, lexer.Errorf(code, span, format, args...))
+}
+
+func (g *generator) write(text string) {
+	g.out.WriteString(text)
+}
+
+// Type the checker found for an expression
+func (g *generator) typeOf(expr ast.Expr) types.Type {
+	if t, exists := g.info.Types[expr.Span()]; exists {
+		return t
+	}
+	return types.Any
+}
+
+// Type the checker gave the name declared at span
+func (g *generator) defOf(span lexer.Span) types.Type {
+	if t, exists := g.info.Defs[span]; exists {
+		return t
+	}
+	return types.Any
+}
+
+// Prints a doc comment as // lines
+func (g *generator) doc(doc *ast.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		g.write(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// Translates a top-level statement. Declarations go to the package level, the rest into main.
+func (g *generator) topLevel(stmt ast.Stmt) {
+	switch n := stmt.(type) {
+	case ast.FunctionDeclStmt:
+		g.out = &g.decls
+		g.doc(n.Doc)
+		fn, _ := g.defOf(n.Span()).(*types.Function)
+		g.write("func " + g.identifier(n.Name))
+		g.function(n.Parameters, fn, n.Body)
+		g.write("\n\n")
+	case ast.ClassDeclStmt:
+		g.out = &g.decls
+		g.classDecl(n)
+	case ast.VarDeclStmt:
+		// package variables, so functions can use them, set in order when main runs
+		g.out = &g.decls
+		g.doc(n.Doc)
+		g.write("var " + g.identifier(n.VariableName) + " " + g.varType(n) + "\n\n")
+		if n.AssignedValue != nil {
+			g.out = &g.main
+			g.write(g.identifier(n.VariableName) + " = ")
+			g.value(n.AssignedValue, g.defOf(n.Span()), precLowest)
+			g.write("\n")
+		}
+	default:
+		g.out = &g.main
+		g.stmt(stmt, nil)
+	}
+}
+
+// Go type of a declared variable. A variable holding a function literal takes the literal's
+// exact signature, which only has a result when the function returns a value.
+func (g *generator) varType(n ast.VarDeclStmt) string {
+	if literal, ok := n.AssignedValue.(ast.FunctionExpr); ok {
+		if fn, ok := g.typeOf(literal).(*types.Function); ok {
+			return g.funcType(fn, g.resultType(fn, literal.Body))
+		}
+	}
+	return g.goType(g.defOf(n.Span()))
+}
+
+// Prints `(a float64, b string) result { ... }`, the part of a function after its name
+func (g *generator) function(parameters []ast.Parameter, fn *types.Function, body ast.BlockStmt) {
+	g.write("(")
+	for i, param := range parameters {
+		if i > 0 {
+			g.write(", ")
+		}
+		typ := types.Type(types.Any)
+		if fn != nil && i < len(fn.Params) {
+			typ = fn.Params[i]
+		}
+		g.write(g.identifier(param.Name) + " " + g.goType(typ))
+	}
+	g.write(")")
+
+	result := g.resultType(fn, body)
+	if result != nil {
+		g.write(" " + g.goType(result))
+	}
+
+	outer := g.result
+	g.result = result
+	g.write(" {\n")
+	g.stmts(body.Body)
+	if result != nil && !terminates(body) {
+		// the interpreter returns null when a function runs off its end
+		g.write("return " + zeroValue(result) + "\n")
+	}
+	g.write("}")
+	g.result = outer
+}
+
+// Go result type of a function, nil when it has none. Functions without a declared
+// return type only get an any result when they return a value.
+func (g *generator) resultType(fn *types.Function, body ast.BlockStmt) types.Type {
+	if fn == nil || fn.Return == types.Void || fn.Return == types.Any && !returnsValue(body) {
+		return nil
+	}
+	return fn.Return
+}
+
+// `type Name struct { ... }`, its constructor function and its methods
+func (g *generator) classDecl(n ast.ClassDeclStmt) {
+	ref, ok := g.defOf(n.Span()).(*types.ClassRef)
+	if !ok {
+		g.errorf(CodeUnknownType, n.Span(), "class %s was not type checked", n.Name)
+		return
+	}
+	class := ref.Class
+	name := g.identifier(n.Name)
+
+	g.doc(n.Doc)
+	g.write("type " + name + " struct {\n")
+	for _, field := range n.Fields {
+		g.doc(field.Doc)
+		g.write(g.identifier(field.VariableName) + " " + g.goType(class.Fields[field.VariableName]) + "\n")
+	}
+	g.write("}\n\n")
+
+	// new<Name> sets the fields with initializers, then runs the constructor
+	constructor, hasConstructor := class.Methods["constructor"]
+	g.write("// new" + n.Name + " is `new " + n.Name + "(...)`\n")
+	g.write("func new" + n.Name + "(")
+	var arguments []string
+	for _, method := range n.Methods {
+		if method.Name != "constructor" {
+			continue
+		}
+		for i, param := range method.Parameters {
+			if i > 0 {
+				g.write(", ")
+			}
+			g.write(g.identifier(param.Name) + " " + g.goType(constructor.Params[i]))
+			arguments = append(arguments, g.identifier(param.Name))
+		}
+	}
+	g.write(") *" + name + " {\n")
+	g.write("this := &" + name + "{")
+	for _, field := range n.Fields {
+		if field.AssignedValue == nil {
+			continue
+		}
+		g.write("\n" + g.identifier(field.VariableName) + ": ")
+		g.value(field.AssignedValue, class.Fields[field.VariableName], precLowest)
+		g.write(",")
+	}
+	if len(n.Fields) > 0 {
+		g.write("\n")
+	}
+	g.write("}\n")
+	if hasConstructor {
+		g.write("this.constructor(" + strings.Join(arguments, ", ") + ")\n")
+	}
+	g.write("return this\n}\n\n")
+
+	for _, method := range n.Methods {
+		g.doc(method.Doc)
+		g.write("func (this *" + name + ") " + g.identifier(method.Name))
+		g.function(method.Parameters, class.Methods[method.Name], method.Body)
+		g.write("\n\n")
+	}
+}
+
+// Prints the statements of a block, marking variables nothing reads as used, which Go requires
+func (g *generator) stmts(body []ast.Stmt) {
+	for i, stmt := range body {
+		g.stmt(stmt, body[i+1:])
+	}
+}
+
+// Prints a statement followed by a line break. rest holds the statements after it in its block.
+func (g *generator) stmt(stmt ast.Stmt, rest []ast.Stmt) {
+	switch n := stmt.(type) {
+	case ast.BlockStmt:
+		g.write("{\n")
+		g.stmts(n.Body)
+		g.write("}")
+	case ast.ExpressionStmt:
+		g.exprStmt(n.Expression)
+	case ast.VarDeclStmt:
+		g.varDecl(n)
+		if !reads(rest, n.VariableName) {
+			g.write("\n_ = " + g.identifier(n.VariableName))
+		}
+	case ast.FunctionDeclStmt:
+		g.localFunction(n, rest)
+		if !g.predeclared[n.Span()] && !reads(rest, n.Name) {
+			g.write("\n_ = " + g.identifier(n.Name))
+		}
+	case ast.ReturnStmt:
+		g.returnStmt(n)
+	case ast.IfStmt:
+		g.ifStmt(n)
+	case ast.WhileStmt:
+		g.write("for ")
+		if symbol, ok := n.Condition.(ast.SymbolExpr); !ok || symbol.Value != "true" {
+			g.value(n.Condition, types.Boolean, precLowest)
+			g.write(" ")
+		}
+		g.write("{\n")
+		g.stmts(n.Body.Body)
+		g.write("}")
+	case ast.ForStmt:
+		g.forStmt(n)
+	case ast.ForeachStmt:
+		g.foreachStmt(n)
+	case ast.ClassDeclStmt:
+		g.errorf(CodeUnsupported, n.Span(), "Go methods can only be declared at the top level, move class %s there", n.Name)
+	case ast.ImportStmt:
+		g.errorf(CodeUnsupported, n.Span(), "imports cannot be translated to Go, programs must be a single module")
+	case ast.BadStmt:
+		g.errorf(CodeSyntaxError, n.Span(), "cannot translate a statement that failed to parse")
+	default:
+		g.errorf(CodeUnsupported, stmt.Span(), "cannot translate %T to Go", stmt)
+	}
+	g.write("\n")
+}
+
+// Go expression statements must be calls; anything else is assigned to _
+func (g *generator) exprStmt(expr ast.Expr) {
+	switch n := expr.(type) {
+	case ast.AssignmentExpr:
+		g.assignment(n)
+	case ast.CallExpr:
+		if member, ok := n.Method.(ast.MemberExpr); ok && member.Property == "push" {
+			if array, ok := g.typeOf(member.Member).(*types.Array); ok {
+				g.push(member.Member, array, n.Arguments)
+				return
+			}
+		}
+		g.expr(n, precLowest)
+	case ast.NewExpr:
+		g.expr(n, precLowest)
+	default:
+		g.write("_ = ")
+		g.expr(expr, precLowest)
+	}
+}
+
+// `array.push(values...)` as a statement: `array = append(array, values...)`
+func (g *generator) push(target ast.Expr, array *types.Array, values []ast.Expr) {
+	g.expr(target, precPrimary)
+	g.write(" = append(")
+	g.expr(target, precLowest)
+	for _, value := range values {
+		g.write(", ")
+		g.value(value, array.Elem, precLowest)
+	}
+	g.write(")")
+}
+
+// `name := value`, or `var name T = value` when the type is spelled out
+func (g *generator) varDecl(n ast.VarDeclStmt) {
+	typ := g.defOf(n.Span())
+	name := g.identifier(n.VariableName)
+
+	switch {
+	case n.AssignedValue == nil:
+		g.write("var " + name + " " + g.goType(typ))
+	case n.ExplicitType != nil:
+		g.write("var " + name + " " + g.goType(typ) + " = ")
+		g.value(n.AssignedValue, typ, precLowest)
+	default:
+		g.write(name + " := ")
+		g.value(n.AssignedValue, typ, precLowest)
+	}
+}
+
+// Functions declared inside functions become function values. One calling itself, or called
+// by a function before it in its block, is declared before it is assigned, so their bodies can
+// refer to it. rest holds the statements after n in its block.
+func (g *generator) localFunction(n ast.FunctionDeclStmt, rest []ast.Stmt) {
+	for _, stmt := range rest {
+		if later, ok := stmt.(ast.FunctionDeclStmt); ok && !g.predeclared[later.Span()] && reads(n.Body.Body, later.Name) {
+			g.declareFunction(later)
+			g.write("\n")
+		}
+	}
+
+	name := g.identifier(n.Name)
+	switch {
+	case g.predeclared[n.Span()]:
+		g.write(name + " = func")
+	case reads(n.Body.Body, n.Name):
+		g.declareFunction(n)
+		g.write("\n" + name + " = func")
+	default:
+		g.write(name + " := func")
+	}
+	fn, _ := g.defOf(n.Span()).(*types.Function)
+	g.function(n.Parameters, fn, n.Body)
+}
+
+// Declares the variable holding local function n, to be assigned where n is declared
+func (g *generator) declareFunction(n ast.FunctionDeclStmt) {
+	fn, _ := g.defOf(n.Span()).(*types.Function)
+	g.write("var " + g.identifier(n.Name) + " " + g.funcType(fn, g.resultType(fn, n.Body)))
+	g.predeclared[n.Span()] = true
+}
+
+func (g *generator) returnStmt(n ast.ReturnStmt) {
+	switch {
+	case g.result == nil && n.Value != nil:
+		// returning a value from a function whose calls are only used as statements
+		g.exprStmt(n.Value)
+		g.write("\nreturn")
+	case g.result == nil:
+		g.write("return")
+	case n.Value == nil:
+		g.write("return " + zeroValue(g.result))
+	default:
+		g.write("return ")
+		g.value(n.Value, g.result, precLowest)
+	}
+}
+
+func (g *generator) ifStmt(n ast.IfStmt) {
+	g.write("if ")
+	g.value(n.Condition, types.Boolean, precLowest)
+	g.write(" {\n")
+	g.stmts(n.Consequent.Body)
+	g.write("}")
+
+	switch alternate := n.Alternate.(type) {
+	case ast.IfStmt:
+		g.write(" else ")
+		g.ifStmt(alternate)
+	case ast.BlockStmt:
+		g.write(" else {\n")
+		g.stmts(alternate.Body)
+		g.write("}")
+	}
+}
+
+// `for init; condition; post { ... }`. The init declares with := since Go doesn't allow var there.
+func (g *generator) forStmt(n ast.ForStmt) {
+	g.write("for ")
+	switch init := n.Init.(type) {
+	case ast.VarDeclStmt:
+		g.write(g.identifier(init.VariableName) + " := ")
+		if init.AssignedValue != nil {
+			g.value(init.AssignedValue, g.defOf(init.Span()), precLowest)
+		} else {
+			g.write(zeroValue(g.defOf(init.Span())))
+		}
+	case ast.ExpressionStmt:
+		g.exprStmt(init.Expression)
+	}
+	g.write("; ")
+
+	if n.Condition != nil {
+		g.value(n.Condition, types.Boolean, precLowest)
+	}
+	g.write("; ")
+
+	if n.Post != nil {
+		g.exprStmt(n.Post)
+	}
+	g.write(" {\n")
+	g.stmts(n.Body.Body)
+	g.write("}")
+}
+
+// `foreach value in lower..upper` counts with a for loop whose bound is evaluated once.
+// Arrays are ranged over by element and strings by character. The loop variable gets a
+// copy per iteration when the body assigns to it or creates closures that could capture it.
+func (g *generator) foreachStmt(n ast.ForeachStmt) {
+	name := g.identifier(n.Value)
+	copied := assigns(n.Body, n.Value) || hasClosure(n.Body)
+
+	if r, isRange := n.Iterable.(ast.RangeExpr); isRange {
+		counter := name
+		if copied {
+			counter = "index_"
+		}
+
+		g.write("for " + counter)
+		upper, literal := r.Upper.(ast.NumberExpr)
+		if literal {
+			g.write(" := ")
+			g.value(r.Lower, types.Number, precLowest)
+		} else {
+			g.write(", end := ")
+			g.value(r.Lower, types.Number, precLowest)
+			g.write(", ")
+			g.value(r.Upper, types.Number, precLowest)
+		}
+
+		g.write("; " + counter + " < ")
+		if literal {
+			g.expr(upper, precCompare+1)
+		} else {
+			g.write("end")
+		}
+		g.write("; " + counter + "++ {\n")
+		if copied {
+			g.write(name + " := " + counter + "\n")
+		}
+		g.stmts(n.Body.Body)
+		g.write("}")
+		return
+	}
+
+	iterable := g.typeOf(n.Iterable)
+	used := reads(n.Body.Body, n.Value) || assigns(n.Body, n.Value)
+
+	switch {
+	case iterable == types.String:
+		if used {
+			g.write("for _, char_ := range ")
+		} else {
+			g.write("for range ")
+		}
+		g.expr(n.Iterable, precLowest)
+		g.write(" {\n")
+		if used {
+			g.write(name + " := string(char_)\n")
+		}
+	case isArray(iterable):
+		if used {
+			g.write("for _, " + name + " := range ")
+		} else {
+			g.write("for range ")
+		}
+		g.expr(n.Iterable, precLowest)
+		g.write(" {\n")
+		if used && copied {
+			g.write(name + " := " + name + "\n")
+		}
+	default:
+		g.errorf(CodeUnknownType, n.Iterable.Span(), "cannot iterate over a value of type %s in Go", iterable)
+		return
+	}
+
+	g.stmts(n.Body.Body)
+	g.write("}")
+}
+
+// Names declared anywhere in a program, apart from class members. Builtins whose name
+// the program declares itself are not used.
+func declaredNames(program ast.BlockStmt) map[string]bool {
+	declared := map[string]bool{}
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case ast.VarDeclStmt:
+			declared[n.VariableName] = true
+		case ast.FunctionDeclStmt:
+			declared[n.Name] = true
+		case ast.Parameter:
+			declared[n.Name] = true
+		case ast.ForeachStmt:
+			declared[n.Value] = true
+		case ast.ClassDeclStmt:
+			declared[n.Name] = true
+			for _, field := range n.Fields {
+				if field.AssignedValue != nil {
+					ast.Inspect(field.AssignedValue, visit)
+				}
+			}
+			for _, method := range n.Methods {
+				for _, param := range method.Parameters {
+					declared[param.Name] = true
+				}
+				ast.Inspect(method.Body, visit)
+			}
+			return false
+		}
+		return true
+	}
+	ast.Inspect(program, visit)
+	return declared
+}
+
+// Reports whether stmts read the variable name. Being assigned to doesn't count.
+func reads(stmts []ast.Stmt, name string) bool {
+	found := false
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case ast.SymbolExpr:
+			found = found || n.Value == name
+		case ast.AssignmentExpr:
+			if symbol, ok := n.Assigne.(ast.SymbolExpr); ok && symbol.Value == name {
+				ast.Inspect(n.Value, visit)
+				return false
+			}
+		}
+		return !found
+	}
+
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, visit)
+	}
+	return found
+}
+
+// Reports whether body assigns to the variable name, shadowing declarations aside
+func assigns(body ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if assignment, ok := node.(ast.AssignmentExpr); ok {
+			if symbol, ok := assignment.Assigne.(ast.SymbolExpr); ok && symbol.Value == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether body creates functions, which could capture its variables
+func hasClosure(body ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case ast.FunctionExpr, ast.FunctionDeclStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether a function body returns a value, not counting the functions nested in it
+func returnsValue(body ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case ast.ReturnStmt:
+			found = found || n.Value != nil
+		case ast.FunctionExpr, ast.FunctionDeclStmt, ast.ClassDeclStmt:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether a statement always ends in a return, so Go doesn't need one after it.
+// `while true` loops count too: the source language has no break.
+func terminates(stmt ast.Stmt) bool {
+	switch n := stmt.(type) {
+	case ast.ReturnStmt:
+		return true
+	case ast.BlockStmt:
+		return len(n.Body) > 0 && terminates(n.Body[len(n.Body)-1])
+	case ast.IfStmt:
+		return n.Alternate != nil && terminates(n.Consequent) && terminates(n.Alternate)
+	case ast.WhileStmt:
+		symbol, ok := n.Condition.(ast.SymbolExpr)
+		return ok && symbol.Value == "true"
+	default:
+		return false
+	}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
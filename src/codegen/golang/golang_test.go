@@ -0,0 +1,139 @@
+package golang
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/interp"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+	"github.com/thutasann/go-parser/src/types"
+)
+
+var update = flag.Bool("update", false, "rewrite the .golden files in testdata with the generated code")
+
+// Parses, checks and translates source. Returns the program's tokens and the Go code.
+func generate(t *testing.T, file string, source string) ([]byte, []lexer.Diagnostic) {
+	t.Helper()
+	tokens, lexErrors := lexer.TokenizeFile(file, source)
+	program, parseErrors := parser.Parse(tokens)
+	info, typeErrors := types.CheckInfo(program)
+	if errors := append(append(lexErrors, parseErrors...), typeErrors...); len(errors) > 0 {
+		t.Fatalf("errors in %s: %v", file, errors)
+	}
+	return Generate(program, info)
+}
+
+func testPrograms(t *testing.T) []string {
+	t.Helper()
+	programs, err := filepath.Glob("testdata/*.lang")
+	if err != nil || len(programs) == 0 {
+		t.Fatalf("no programs in testdata: %v", err)
+	}
+	return programs
+}
+
+// The code generated for the programs in testdata is the .golden file next to them
+func TestGenerateGolden(t *testing.T) {
+	for _, path := range testPrograms(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, diagnostics := generate(t, path, string(source))
+			if len(diagnostics) > 0 {
+				t.Fatalf("diagnostics: %v", diagnostics)
+			}
+
+			golden := strings.TrimSuffix(path, ".lang") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, code, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(code, want) {
+				t.Errorf("generated code differs from %s (run with -update to see the changes in git diff):\n%s", golden, code)
+			}
+		})
+	}
+}
+
+// The generated programs build and print what the interpreter prints
+func TestGeneratedProgramsRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds every program with the go command")
+	}
+	goCommand, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	for _, path := range testPrograms(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			code, diagnostics := generate(t, path, string(source))
+			if len(diagnostics) > 0 {
+				t.Fatalf("diagnostics: %v", diagnostics)
+			}
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), code, 0644); err != nil {
+				t.Fatal(err)
+			}
+			run := exec.Command(goCommand, "run", "main.go")
+			run.Dir = dir
+			var stderr bytes.Buffer
+			run.Stderr = &stderr
+			compiled, err := run.Output()
+			if err != nil {
+				t.Fatalf("go run: %v\n%s", err, stderr.Bytes())
+			}
+
+			tokens, _ := lexer.TokenizeFile(path, string(source))
+			program, _ := parser.Parse(tokens)
+			var interpreted bytes.Buffer
+			if err := interp.New(&interpreted).Run(program); err != nil {
+				t.Fatalf("interpreter: %v", err)
+			}
+
+			if string(compiled) != interpreted.String() {
+				t.Errorf("go run:\n%s\ninterpreter:\n%s", compiled, interpreted.String())
+			}
+		})
+	}
+}
+
+// Programs the generator can't translate are reported instead
+func TestGenerateUnsupported(t *testing.T) {
+	tests := []struct {
+		source string
+		want   lexer.DiagnosticCode
+	}{
+		{"import { f } from \"./lib\";\nf();", CodeUnsupported},
+		{"let o: any = null;\nprintln(o.field);", CodeUnknownType},
+		{"let items: any = [1];\nprintln(items[0]);", CodeUnknownType},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			_, diagnostics := generate(t, "test.lang", test.source)
+			if len(diagnostics) == 0 || diagnostics[0].Code != test.want {
+				t.Errorf("diagnostics %v, want %s", diagnostics, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package golang
+
+// Go definition of a function the output calls
+// imports: packages the definition uses
+// uses: other runtime functions it calls
+type runtimeFunction struct {
+	code    string
+	imports []string
+	uses    []string
+}
+
+// Runtime functions, keyed by name. println and print stand in for the builtins.
+var runtime_functions = map[string]runtimeFunction{
+	"println": {
+		code: `// println prints the values separated by spaces, followed by a newline
+func println(values ...any) {
+	fmt.Println(join(values))
+}`,
+		imports: []string{"fmt"},
+		uses:    []string{"join"},
+	},
+	"print": {
+		code: `// print prints the values separated by spaces
+func print(values ...any) {
+	fmt.Print(join(values))
+}`,
+		imports: []string{"fmt"},
+		uses:    []string{"join"},
+	},
+	"join": {
+		code: `// join formats values the way println shows them, separated by spaces
+func join(values []any) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = stringify(value)
+	}
+	return strings.Join(parts, " ")
+}`,
+		imports: []string{"strings"},
+		uses:    []string{"stringify"},
+	},
+	"stringify": {
+		code: `// stringify formats a value the way println shows it
+func stringify(value any) string {
+	return format(reflect.ValueOf(value))
+}
+
+func format(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Invalid:
+		return "null"
+	case reflect.Float64:
+		return formatNumber(value.Float())
+	case reflect.String:
+		return value.String()
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool())
+	case reflect.Interface:
+		return format(value.Elem())
+	case reflect.Slice:
+		elements := make([]string, value.Len())
+		for i := range elements {
+			elements[i] = format(value.Index(i))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case reflect.Map:
+		keys := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		fields := make([]string, len(keys))
+		for i, key := range keys {
+			fields[i] = key + ": " + format(value.MapIndex(reflect.ValueOf(key)))
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	case reflect.Pointer:
+		if value.IsNil() {
+			return "null"
+		}
+		instance := value.Elem()
+		fields := make([]string, instance.NumField())
+		for i := range fields {
+			fields[i] = strings.TrimSuffix(instance.Type().Field(i).Name, "_") + ": " + format(instance.Field(i))
+		}
+		return strings.TrimSuffix(instance.Type().Name(), "_") + " { " + strings.Join(fields, ", ") + " }"
+	case reflect.Func:
+		if value.IsNil() {
+			return "null"
+		}
+		// main.name for functions, main.outer.func1 for function literals
+		name := runtime.FuncForPC(value.Pointer()).Name()
+		name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+		if strings.HasPrefix(name, "func") {
+			return "fn "
+		}
+		return "fn " + strings.TrimSuffix(name, "_")
+	default:
+		return "unknown"
+	}
+}`,
+		imports: []string{"reflect", "runtime", "sort", "strconv", "strings"},
+		uses:    []string{"formatNumber"},
+	},
+	"typeOf": {
+		code: `// typeOf returns the result of typeof for a value, which matches JavaScript's
+func typeOf(value any) string {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Func:
+		if !v.IsNil() {
+			return "function"
+		}
+	}
+	return "object"
+}`,
+		imports: []string{"reflect"},
+	},
+	"formatNumber": {
+		code: `// formatNumber prints integral numbers without a fraction, everything else in the shortest exact form
+func formatNumber(n float64) string {
+	if n == math.Trunc(n) && math.Abs(n) < 1e21 {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}`,
+		imports: []string{"math", "strconv"},
+	},
+}
+
+// Adds a runtime function and what it needs to the output
+func (g *generator) helper(name string) {
+	if g.helpers[name] {
+		return
+	}
+	g.helpers[name] = true
+
+	function := runtime_functions[name]
+	for _, path := range function.imports {
+		g.imports[path] = true
+	}
+	for _, use := range function.uses {
+		g.helper(use)
+	}
+}
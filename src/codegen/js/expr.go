@@ -0,0 +1,337 @@
+package js
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// JavaScript operator precedence. Unlike the source language, && binds tighter than ||
+// and equality looser than the other comparisons, so parentheses follow these levels.
+// Arrow functions rank with assignments, new with arguments with calls and member accesses.
+const (
+	precLowest = iota
+	precAssignment
+	precOr
+	precAnd
+	precEquality
+	precRelational
+	precAdditive
+	precMultiplicative
+	precUnary
+	precCall
+	precPrimary
+)
+
+// JavaScript spelling of the binary operators that differ from the source
+var binary_operators = map[lexer.TokenKind]string{
+	lexer.EQUALS:     "===",
+	lexer.NOT_EQUALS: "!==",
+}
+
+// Precedence of the operator at the top of expr
+func precedence(expr ast.Expr) int {
+	switch n := expr.(type) {
+	case ast.AssignmentExpr, ast.FunctionExpr:
+		return precAssignment
+	case ast.BinaryExpr:
+		switch n.Operator.Kind {
+		case lexer.OR:
+			return precOr
+		case lexer.AND:
+			return precAnd
+		case lexer.EQUALS, lexer.NOT_EQUALS:
+			return precEquality
+		case lexer.PLUS, lexer.DASH:
+			return precAdditive
+		case lexer.STAR, lexer.SLASH, lexer.PERCENT:
+			return precMultiplicative
+		default:
+			return precRelational
+		}
+	case ast.PrefixExpr:
+		return precUnary
+	case ast.CallExpr, ast.MemberExpr, ast.ComputedExpr, ast.NewExpr:
+		return precCall
+	default:
+		return precPrimary
+	}
+}
+
+// Prints expr where only operators binding at least as tight as outer are read as part of it,
+// adding parentheses otherwise
+func (g *generator) expr(expr ast.Expr, outer int) {
+	if precedence(expr) < outer {
+		g.write("(")
+		g.exprBody(expr)
+		g.write(")")
+		return
+	}
+	g.exprBody(expr)
+}
+
+func (g *generator) exprBody(expr ast.Expr) {
+	g.mark(expr.Span())
+
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		g.write(number(n))
+	case ast.StringExpr:
+		g.write(quote(n.Value, '"'))
+	case ast.TemplateExpr:
+		g.write("`" + quote(n.Strings[0], '`'))
+		for i, part := range n.Expressions {
+			g.write("${")
+			g.expr(part, precLowest)
+			g.write("}" + quote(n.Strings[i+1], '`'))
+		}
+		g.write("`")
+	case ast.SymbolExpr:
+		g.write(identifier(n.Value))
+	case ast.BinaryExpr:
+		prec := precedence(n)
+		operator, exists := binary_operators[n.Operator.Kind]
+		if !exists {
+			operator = n.Operator.Value
+		}
+		g.expr(n.Left, prec)
+		g.write(" " + operator + " ")
+		g.expr(n.Right, prec+1)
+	case ast.AssignmentExpr:
+		g.expr(n.Assigne, precCall)
+		g.write(" " + n.Operator.Value + " ")
+		g.expr(n.Value, precAssignment)
+	case ast.PrefixExpr:
+		g.write(n.Operator.Value)
+		if n.Operator.Kind == lexer.TYPEOF {
+			g.write(" ")
+		}
+		if _, nested := n.RightExpr.(ast.PrefixExpr); nested {
+			// - -x would read as the decrement operator
+			g.write("(")
+			g.exprBody(n.RightExpr)
+			g.write(")")
+		} else {
+			g.expr(n.RightExpr, precUnary)
+		}
+	case ast.FunctionExpr:
+		// arrow functions keep `this` of the method they are written in, like the interpreter
+		g.parameters(n.Parameters)
+		g.write(" => ")
+		g.block(n.Body)
+	case ast.CallExpr:
+		g.object(n.Method)
+		g.arguments(n.Arguments)
+	case ast.MemberExpr:
+		g.object(n.Member)
+		g.write("." + n.Property)
+	case ast.ComputedExpr:
+		g.object(n.Member)
+		g.write("[")
+		g.expr(n.Property, precLowest)
+		g.write("]")
+	case ast.NewExpr:
+		// the class expression ends at the first argument list
+		g.write("new ")
+		if hasCall(n.Instantiation.Method) {
+			g.write("(")
+			g.exprBody(n.Instantiation.Method)
+			g.write(")")
+		} else {
+			g.object(n.Instantiation.Method)
+		}
+		g.arguments(n.Instantiation.Arguments)
+	case ast.RangeExpr:
+		g.errorf(CodeInvalidRange, n.Span(), "ranges can only be used in foreach loops")
+		g.write("/* range */")
+	case ast.ArrayLiteral:
+		g.write("[")
+		g.list(n.Contents)
+		g.write("]")
+	case ast.ObjectLiteral:
+		g.objectLiteral(n)
+	case ast.BadExpr:
+		g.errorf(CodeSyntaxError, n.Span(), "cannot translate an expression that failed to parse")
+		g.write("/* invalid expression */")
+	default:
+		g.errorf(CodeUnsupported, expr.Span(), "cannot translate %T to JavaScript", expr)
+		g.write("/* unsupported expression */")
+	}
+}
+
+// Prints the expression a call, member access or new applies to.
+// A number needs parentheses too, or the dot would be read as its decimal point.
+func (g *generator) object(expr ast.Expr) {
+	if _, isNumber := expr.(ast.NumberExpr); isNumber {
+		g.write("(")
+		g.exprBody(expr)
+		g.write(")")
+		return
+	}
+	g.expr(expr, precCall)
+}
+
+// `(a, b, c)`
+func (g *generator) arguments(arguments []ast.Expr) {
+	g.write("(")
+	g.list(arguments)
+	g.write(")")
+}
+
+// Comma separated expressions
+func (g *generator) list(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.expr(expr, precAssignment)
+	}
+}
+
+// `{ key: value, [computed]: value, shorthand }`
+func (g *generator) objectLiteral(n ast.ObjectLiteral) {
+	if len(n.Properties) == 0 {
+		g.write("{}")
+		return
+	}
+
+	g.write("{ ")
+	for i, property := range n.Properties {
+		if i > 0 {
+			g.write(", ")
+		}
+		g.mark(property.Span())
+
+		switch {
+		case property.ComputedKey != nil:
+			g.write("[")
+			g.expr(property.ComputedKey, precAssignment)
+			g.write("]")
+		case isIdentifier(property.Key):
+			g.write(property.Key)
+		default:
+			g.write(quote(property.Key, '"'))
+		}
+
+		// shorthand only works when the variable keeps its name
+		if !property.Shorthand || identifier(property.Key) != property.Key {
+			g.write(": ")
+			g.expr(property.Value, precAssignment)
+		}
+	}
+	g.write(" }")
+}
+
+// Reports whether the printed expression would begin with `{`
+func startsWithObject(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case ast.ObjectLiteral:
+		return true
+	case ast.BinaryExpr:
+		return startsWithObject(n.Left)
+	case ast.AssignmentExpr:
+		return startsWithObject(n.Assigne)
+	case ast.CallExpr:
+		return startsWithObject(n.Method)
+	case ast.MemberExpr:
+		return startsWithObject(n.Member)
+	case ast.ComputedExpr:
+		return startsWithObject(n.Member)
+	default:
+		return false
+	}
+}
+
+// Reports whether expr contains a call along its chain of member accesses
+func hasCall(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case ast.CallExpr:
+		return true
+	case ast.MemberExpr:
+		return hasCall(n.Member)
+	case ast.ComputedExpr:
+		return hasCall(n.Member)
+	default:
+		return false
+	}
+}
+
+// Number literal as written when JavaScript reads it the same way. Decimal literals with
+// leading zeros would be legacy octal, so they are printed from their value instead.
+func number(n ast.NumberExpr) string {
+	raw := n.Raw
+	if raw == "" || len(raw) > 1 && raw[0] == '0' && raw[1] >= '0' && raw[1] <= '9' {
+		return strconv.FormatFloat(n.Value, 'g', -1, 64)
+	}
+	return raw
+}
+
+// Words JavaScript reserves that are identifiers in the source language
+var reserved_words = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true,
+	"continue": true, "debugger": true, "default": true, "delete": true, "do": true,
+	"enum": true, "eval": true, "extends": true, "finally": true, "function": true,
+	"implements": true, "instanceof": true, "interface": true, "let": true, "package": true,
+	"private": true, "protected": true, "public": true, "static": true, "super": true,
+	"switch": true, "throw": true, "try": true, "var": true, "void": true,
+	"with": true, "yield": true,
+}
+
+// Name of a variable in the output. Reserved words get a $ suffix, which can't
+// clash with other names since the source language doesn't allow $ in identifiers.
+func identifier(name string) string {
+	if reserved_words[name] {
+		return name + "$"
+	}
+	return name
+}
+
+// Reports whether name can be written without quotes as an object key
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, ch := range name {
+		if !(ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Escapes value for a JavaScript literal delimited by delim. The delimiters themselves are only
+// added for quotes, template pieces are written between the backtick and ${ by the caller.
+func quote(value string, delim byte) string {
+	var out strings.Builder
+	if delim != '`' {
+		out.WriteByte(delim)
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '\\' || ch == delim:
+			out.WriteByte('\\')
+			out.WriteByte(ch)
+		case ch == '$' && delim == '`' && i+1 < len(value) && value[i+1] == '{':
+			out.WriteString(`\$`)
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case ch == '\r':
+			out.WriteString(`\r`)
+		case ch < ' ' || ch == 0x7f:
+			fmt.Fprintf(&out, `\x%02X`, ch)
+		default:
+			out.WriteByte(ch)
+		}
+	}
+
+	if delim != '`' {
+		out.WriteByte(delim)
+	}
+	return out.String()
+}
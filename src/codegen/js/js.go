@@ -0,0 +1,520 @@
+// Package js translates programs to JavaScript ES modules.
+//
+// The output keeps the shape of the source: declarations stay declarations, classes become
+// JavaScript classes and imports become ES imports of the translated modules.
+//
+// - `foreach x in a..b` becomes a counting for loop, `foreach x in items` a for...of loop
+//
+// - println, print and len are defined at the end of the modules that use them, and print
+// values the way the interpreter does, apart from imported modules, which print their exports.
+// Template strings and + use the JavaScript formatting.
+//
+// - Methods taken from an instance without calling them lose `this`, as usual in JavaScript
+//
+// - Runtime errors of the interpreter are not reproduced: dividing by zero gives Infinity
+// and conditions are not checked to be booleans
+package js
+
+import (
+	"bytes"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Code generator diagnostic codes
+const (
+	CodeSyntaxError  lexer.DiagnosticCode = "J0001" // statement or expression that failed to parse
+	CodeUnsupported  lexer.DiagnosticCode = "J0002" // node the generator doesn't know, like a custom statement
+	CodeInvalidRange lexer.DiagnosticCode = "J0003" // range outside of a foreach loop
+)
+
+// Indentation used for every nesting level
+const indentation = "  "
+
+// Options controls the output of Generate
+//
+// - File is the name of the generated file, recorded in the source map
+//
+// - SourceRoot is prepended to the source file names by the tools reading the source map
+//
+// - Sources holds the text of the source files by name. Source maps count columns in UTF-16
+// code units, which the source map works out from the text; without it the columns of
+// positions after non-ASCII characters are off.
+type Options struct {
+	SourceMap  bool
+	File       string
+	SourceRoot string
+	Sources    map[string][]byte
+}
+
+// Output of Generate. SourceMap is a version 3 source map in JSON, nil unless requested.
+type Output struct {
+	Code      []byte
+	SourceMap []byte
+}
+
+// Holds the output being built
+// line, column: position in the output, the column counted in UTF-16 code units as source maps require
+// lastLine: source line where the last statement ended, used to keep blank lines
+// first: nothing has been printed in the current block yet
+// builtins: builtins the module refers to without declaring them itself
+type generator struct {
+	buf         bytes.Buffer
+	indent      int
+	line        int
+	column      int
+	lastLine    int
+	first       bool
+	sourceMap   *sourceMap
+	builtins    map[string]bool
+	diagnostics []lexer.Diagnostic
+}
+
+// Generate translates a program to a JavaScript module.
+// The program should be free of syntax errors; the parts that failed to parse are reported as diagnostics.
+func Generate(program ast.BlockStmt, options Options) (Output, []lexer.Diagnostic) {
+	g := &generator{first: true, builtins: usedBuiltins(program)}
+	if options.SourceMap {
+		g.sourceMap = newSourceMap(options.Sources)
+	}
+
+	for _, stmt := range program.Body {
+		g.stmt(stmt)
+	}
+	g.runtime()
+
+	output := Output{Code: g.buf.Bytes()}
+	if g.sourceMap != nil {
+		output.SourceMap = g.sourceMap.encode(options.File, options.SourceRoot)
+	}
+	return output, g.diagnostics
+}
+
+// Records an error
+func (g *generator) errorf(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	g.diagnostics = append(g.diagnostics, lexer.Errorf(code, span, format, args...))
+}
+
+// Appends text to the output, keeping track of the output position
+func (g *generator) write(text string) {
+	g.buf.WriteString(text)
+
+	for _, ch := range text {
+		if ch == '\n' {
+			g.line++
+			g.column = 0
+		} else {
+			g.column += utf16Length(ch)
+		}
+	}
+}
+
+// Maps the current output position to the start of span
+func (g *generator) mark(span lexer.Span) {
+	if g.sourceMap != nil && span.File != "" {
+		g.sourceMap.add(g.line, g.column, span)
+	}
+}
+
+// Starts a new output line at the current indentation
+func (g *generator) startLine() {
+	g.write(strings.Repeat(indentation, g.indent))
+}
+
+// Keeps a single blank line if the source had at least one before line
+func (g *generator) separate(line int) {
+	if !g.first && line > g.lastLine+1 {
+		g.write("\n")
+	}
+	g.first = false
+}
+
+// Prints a statement on its own line(s), preceded by its doc comment
+func (g *generator) stmt(stmt ast.Stmt) {
+	span := stmt.Span()
+
+	g.separate(span.Start.Line)
+	g.doc(stmt)
+
+	g.startLine()
+	g.stmtBody(stmt)
+	g.write("\n")
+	g.lastLine = span.End.Line
+}
+
+// Prints the doc comment of a declaration, one // line per line of text
+func (g *generator) doc(stmt ast.Stmt) {
+	var doc *ast.CommentGroup
+	switch n := stmt.(type) {
+	case ast.VarDeclStmt:
+		doc = n.Doc
+	case ast.FunctionDeclStmt:
+		doc = n.Doc
+	case ast.ClassDeclStmt:
+		doc = n.Doc
+	}
+	if doc == nil {
+		return
+	}
+
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		g.startLine()
+		g.write(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// Prints `{ statements }`, leaving the cursor after the closing brace
+func (g *generator) block(block ast.BlockStmt) {
+	g.blockWith(block, nil)
+}
+
+// Prints a block, running prologue first on the first line inside it
+func (g *generator) blockWith(block ast.BlockStmt, prologue func()) {
+	if len(block.Body) == 0 && prologue == nil {
+		g.write("{}")
+		return
+	}
+
+	g.write("{\n")
+	g.indent++
+	g.first, g.lastLine = true, block.Span().Start.Line
+
+	if prologue != nil {
+		g.startLine()
+		prologue()
+		g.write("\n")
+	}
+	for _, stmt := range block.Body {
+		g.stmt(stmt)
+	}
+
+	g.indent--
+	g.startLine()
+	g.write("}")
+}
+
+// Prints a statement without indentation or the line break after it
+func (g *generator) stmtBody(stmt ast.Stmt) {
+	g.mark(stmt.Span())
+
+	switch n := stmt.(type) {
+	case ast.BlockStmt:
+		g.block(n)
+	case ast.ExpressionStmt:
+		if startsWithObject(n.Expression) {
+			// a statement starting with { would be read as a block
+			g.write("(")
+			g.expr(n.Expression, precLowest)
+			g.write(");")
+		} else {
+			g.expr(n.Expression, precLowest)
+			g.write(";")
+		}
+	case ast.VarDeclStmt:
+		g.varDecl(n)
+	case ast.FunctionDeclStmt:
+		if n.IsExported {
+			g.write("export ")
+		}
+		g.write("function " + identifier(n.Name))
+		g.function(n.Parameters, n.Body)
+	case ast.ReturnStmt:
+		if n.Value == nil {
+			g.write("return;")
+		} else {
+			g.write("return ")
+			g.expr(n.Value, precLowest)
+			g.write(";")
+		}
+	case ast.ClassDeclStmt:
+		g.classDecl(n)
+	case ast.IfStmt:
+		g.write("if (")
+		g.expr(n.Condition, precLowest)
+		g.write(") ")
+		g.block(n.Consequent)
+		if n.Alternate != nil {
+			g.write(" else ")
+			g.stmtBody(n.Alternate)
+		}
+	case ast.WhileStmt:
+		g.write("while (")
+		g.expr(n.Condition, precLowest)
+		g.write(") ")
+		g.block(n.Body)
+	case ast.ForStmt:
+		g.forStmt(n)
+	case ast.ForeachStmt:
+		g.foreachStmt(n)
+	case ast.ImportStmt:
+		g.write("import ")
+		if n.Alias != "" {
+			g.write("* as " + identifier(n.Alias))
+		} else {
+			names := make([]string, len(n.Names))
+			for i, name := range n.Names {
+				names[i] = identifier(name)
+			}
+			g.write("{ " + strings.Join(names, ", ") + " }")
+		}
+		g.write(" from " + quote(modulePath(n.From), '"') + ";")
+	case ast.BadStmt:
+		g.errorf(CodeSyntaxError, n.Span(), "cannot translate a statement that failed to parse")
+		g.write("/* invalid statement */")
+	default:
+		g.errorf(CodeUnsupported, stmt.Span(), "cannot translate %T to JavaScript", stmt)
+		g.write("/* unsupported statement */")
+	}
+}
+
+// `let name = value;`. Declarations without a value start at the zero value of their type.
+func (g *generator) varDecl(n ast.VarDeclStmt) {
+	if n.IsExported {
+		g.write("export ")
+	}
+	if n.IsConstant {
+		g.write("const ")
+	} else {
+		g.write("let ")
+	}
+
+	g.write(identifier(n.VariableName) + " = ")
+	if n.AssignedValue != nil {
+		g.expr(n.AssignedValue, precAssignment)
+	} else {
+		g.write(zeroValue(n.ExplicitType))
+	}
+	g.write(";")
+}
+
+// Initial value of a variable declared without one, as the interpreter sets it
+func zeroValue(t ast.Type) string {
+	switch n := t.(type) {
+	case ast.SymbolType:
+		switch n.Name {
+		case "number":
+			return "0"
+		case "string":
+			return `""`
+		case "boolean":
+			return "false"
+		}
+	case ast.ArrayType:
+		return "[]"
+	}
+	return "null"
+}
+
+// `(a, b) { ... }`, the part of a function after its name
+func (g *generator) function(parameters []ast.Parameter, body ast.BlockStmt) {
+	g.parameters(parameters)
+	g.write(" ")
+	g.block(body)
+}
+
+// `(a, b)`
+func (g *generator) parameters(parameters []ast.Parameter) {
+	names := make([]string, len(parameters))
+	for i, param := range parameters {
+		names[i] = identifier(param.Name)
+	}
+	g.write("(" + strings.Join(names, ", ") + ")")
+}
+
+// `class Name { fields... methods... }` with the members in source order
+func (g *generator) classDecl(n ast.ClassDeclStmt) {
+	if n.IsExported {
+		g.write("export ")
+	}
+	g.write("class " + identifier(n.Name) + " ")
+
+	members := make([]ast.Stmt, 0, len(n.Fields)+len(n.Methods))
+	for _, field := range n.Fields {
+		members = append(members, field)
+	}
+	for _, method := range n.Methods {
+		members = append(members, method)
+	}
+	if len(members) == 0 {
+		g.write("{}")
+		return
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Span().Start.Offset < members[j].Span().Start.Offset
+	})
+
+	g.write("{\n")
+	g.indent++
+	g.first, g.lastLine = true, n.Span().Start.Line
+
+	for _, member := range members {
+		span := member.Span()
+		g.separate(span.Start.Line)
+		g.doc(member)
+
+		g.startLine()
+		g.mark(span)
+		switch m := member.(type) {
+		case ast.VarDeclStmt:
+			g.write(m.VariableName + " = ")
+			if m.AssignedValue != nil {
+				g.expr(m.AssignedValue, precAssignment)
+			} else {
+				g.write(zeroValue(m.ExplicitType))
+			}
+			g.write(";")
+		case ast.FunctionDeclStmt:
+			g.write(m.Name)
+			g.function(m.Parameters, m.Body)
+		}
+		g.write("\n")
+		g.lastLine = span.End.Line
+	}
+
+	g.indent--
+	g.startLine()
+	g.write("}")
+}
+
+// `for (init; condition; post) { ... }`
+//
+// A variable declared in the init of a JavaScript for loop is copied for every iteration,
+// while the source language has one variable for the whole loop. When closures could tell
+// the difference, the declaration moves into a block around the loop.
+func (g *generator) forStmt(n ast.ForStmt) {
+	if init, declares := n.Init.(ast.VarDeclStmt); declares && hasClosure(n.Body) {
+		g.write("{\n")
+		g.indent++
+		g.startLine()
+		g.mark(init.Span())
+		g.varDecl(init)
+		g.write("\n")
+		g.startLine()
+		g.forStmt(ast.ForStmt{Condition: n.Condition, Post: n.Post, Body: n.Body, Loc: n.Loc})
+		g.write("\n")
+		g.indent--
+		g.startLine()
+		g.write("}")
+		return
+	}
+
+	g.write("for (")
+	switch init := n.Init.(type) {
+	case nil:
+		g.write(";")
+	case ast.VarDeclStmt:
+		g.mark(init.Span())
+		g.varDecl(init)
+	case ast.ExpressionStmt:
+		g.mark(init.Span())
+		g.expr(init.Expression, precLowest)
+		g.write(";")
+	}
+
+	if n.Condition != nil {
+		g.write(" ")
+		g.expr(n.Condition, precLowest)
+	}
+	g.write(";")
+
+	if n.Post != nil {
+		g.write(" ")
+		g.expr(n.Post, precLowest)
+	}
+	g.write(") ")
+	g.block(n.Body)
+}
+
+// `foreach value in lower..upper` counts with a for loop whose bound is evaluated once.
+// Any other foreach becomes a for...of loop, which goes through arrays by element and
+// through strings by character, like the interpreter.
+//
+// The loop variable is fresh in every iteration. When the body assigns to it, the count is
+// kept in a separate variable so the assignment doesn't change the number of iterations.
+func (g *generator) foreachStmt(n ast.ForeachStmt) {
+	name := identifier(n.Value)
+
+	r, isRange := n.Iterable.(ast.RangeExpr)
+	if !isRange {
+		keyword := "const "
+		if assigns(n.Body, n.Value) {
+			keyword = "let "
+		}
+		g.write("for (" + keyword + name + " of ")
+		g.expr(n.Iterable, precAssignment)
+		g.write(") ")
+		g.block(n.Body)
+		return
+	}
+
+	counter := name
+	if assigns(n.Body, n.Value) {
+		counter = "$" + name
+	}
+
+	g.mark(r.Span())
+	g.write("for (let " + counter + " = ")
+	g.expr(r.Lower, precAssignment)
+
+	// a number bound reads the same on every iteration, anything else is evaluated up front
+	upper, literal := r.Upper.(ast.NumberExpr)
+	if !literal {
+		g.write(", $end = ")
+		g.expr(r.Upper, precAssignment)
+	}
+
+	g.write("; " + counter + " < ")
+	if literal {
+		g.exprBody(upper)
+	} else {
+		g.write("$end")
+	}
+	g.write("; " + counter + "++) ")
+
+	if counter == name {
+		g.block(n.Body)
+		return
+	}
+	g.blockWith(n.Body, func() { g.write("let " + name + " = " + counter + ";") })
+}
+
+// Reports whether body assigns to the variable name, shadowing declarations aside
+func assigns(body ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if assignment, ok := node.(ast.AssignmentExpr); ok {
+			if symbol, ok := assignment.Assigne.(ast.SymbolExpr); ok && symbol.Value == name {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// Reports whether body creates functions or classes, which could capture its variables
+func hasClosure(body ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case ast.FunctionExpr, ast.FunctionDeclStmt, ast.ClassDeclStmt:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// Import path of the JavaScript module translated from the module at from.
+// Relative paths get the ./ prefix ES modules require.
+func modulePath(from string) string {
+	p := strings.TrimSuffix(from, path.Ext(from)) + ".js"
+	if !strings.HasPrefix(p, "/") && !strings.HasPrefix(p, "./") && !strings.HasPrefix(p, "../") {
+		p = "./" + p
+	}
+	return p
+}
@@ -0,0 +1,124 @@
+package js
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/interp"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/loader"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the .golden files in testdata with the generated code")
+
+// Parses and translates the module at path
+func generate(t *testing.T, path string) []byte {
+	t.Helper()
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, lexErrors := lexer.TokenizeFile(path, string(source))
+	program, parseErrors := parser.Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+		t.Fatalf("syntax errors in %s: %v", path, errors)
+	}
+
+	output, diagnostics := Generate(program, Options{})
+	if len(diagnostics) > 0 {
+		t.Fatalf("diagnostics for %s: %v", path, diagnostics)
+	}
+	return output.Code
+}
+
+func testPrograms(t *testing.T) []string {
+	t.Helper()
+	programs, err := filepath.Glob("testdata/*.lang")
+	if err != nil || len(programs) == 0 {
+		t.Fatalf("no programs in testdata: %v", err)
+	}
+	return programs
+}
+
+// The code generated for the programs in testdata is the .golden file next to them
+func TestGenerateGolden(t *testing.T) {
+	for _, path := range testPrograms(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			code := generate(t, path)
+
+			golden := strings.TrimSuffix(path, ".lang") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, code, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(code, want) {
+				t.Errorf("generated code differs from %s (run with -update to see the changes in git diff):\n%s", golden, code)
+			}
+		})
+	}
+}
+
+// The generated modules run on node and print what the interpreter prints
+func TestGeneratedProgramsRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs every program with node")
+	}
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found")
+	}
+
+	for _, path := range testPrograms(t) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			graph, diagnostics := loader.Load(path)
+			if len(diagnostics) > 0 {
+				t.Fatalf("loading: %v", diagnostics)
+			}
+
+			// every module is translated to the .js file its importers refer to
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"type": "module"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			for _, module := range graph.Order {
+				file := filepath.Join(dir, strings.TrimSuffix(module.Path, loader.Extension)+".js")
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(file, generate(t, module.Path), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			run := exec.Command(node, strings.TrimSuffix(path, loader.Extension)+".js")
+			run.Dir = dir
+			var stderr bytes.Buffer
+			run.Stderr = &stderr
+			translated, err := run.Output()
+			if err != nil {
+				t.Fatalf("node: %v\n%s", err, stderr.Bytes())
+			}
+
+			var interpreted bytes.Buffer
+			if err := interp.New(&interpreted).RunGraph(graph); err != nil {
+				t.Fatalf("interpreter: %v", err)
+			}
+
+			if string(translated) != interpreted.String() {
+				t.Errorf("node:\n%s\ninterpreter:\n%s", translated, interpreted.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package js
+
+import (
+	"sort"
+
+	"github.com/thutasann/go-parser/src/ast"
+)
+
+// JavaScript definitions of the builtins, keyed by name
+var runtime_functions = map[string]string{
+	"println": `function println(...values) {
+  $write(values.map($str).join(" ") + "\n");
+}`,
+	"print": `function print(...values) {
+  $write(values.map($str).join(" "));
+}`,
+	"len": `function len(value) {
+  return typeof value === "string" ? [...value].length : value.length;
+}`,
+	"$str": `function $str(value) {
+  if (value === null || value === undefined) {
+    return "null";
+  }
+  if (Array.isArray(value)) {
+    return "[" + value.map($str).join(", ") + "]";
+  }
+  if (typeof value === "function") {
+    return (/^class\b/.test(value.toString()) ? "class " : "fn ") + value.name;
+  }
+  if (typeof value === "object") {
+    const fields = Object.entries(value).map(([key, field]) => key + ": " + $str(field));
+    const name = value.constructor === Object ? "" : value.constructor ? value.constructor.name + " " : "module ";
+    return name + "{ " + fields.join(", ") + " }";
+  }
+  return String(value);
+}`,
+	"$write": `function $write(text) {
+  if (typeof process !== "undefined") {
+    process.stdout.write(text);
+  } else {
+    console.log(text.replace(/\n$/, ""));
+  }
+}`,
+}
+
+// Builtins that need other definitions
+var runtime_dependencies = map[string][]string{
+	"println": {"$str", "$write"},
+	"print":   {"$str", "$write"},
+}
+
+// Names of the builtins a program refers to without declaring them at the top level
+func usedBuiltins(program ast.BlockStmt) map[string]bool {
+	declared := map[string]bool{}
+	for _, stmt := range program.Body {
+		switch n := stmt.(type) {
+		case ast.VarDeclStmt:
+			declared[n.VariableName] = true
+		case ast.FunctionDeclStmt:
+			declared[n.Name] = true
+		case ast.ClassDeclStmt:
+			declared[n.Name] = true
+		case ast.ImportStmt:
+			declared[n.Alias] = true
+			for _, name := range n.Names {
+				declared[name] = true
+			}
+		}
+	}
+
+	used := map[string]bool{}
+	var use func(name string)
+	use = func(name string) {
+		used[name] = true
+		for _, dependency := range runtime_dependencies[name] {
+			use(dependency)
+		}
+	}
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if symbol, ok := node.(ast.SymbolExpr); ok {
+			if _, builtin := runtime_functions[symbol.Value]; builtin && !declared[symbol.Value] {
+				use(symbol.Value)
+			}
+		}
+		return true
+	})
+	return used
+}
+
+// Appends the definitions of the builtins the program uses. Function declarations are
+// hoisted, so they can come after the code calling them.
+func (g *generator) runtime() {
+	names := make([]string, 0, len(g.builtins))
+	for name := range g.builtins {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	g.write("\n// runtime support\n")
+	for i, name := range names {
+		if i > 0 {
+			g.write("\n")
+		}
+		g.write(runtime_functions[name] + "\n")
+	}
+}
@@ -0,0 +1,144 @@
+package js
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Characters of the base64 VLQ encoding used by source map mappings
+const base64_digits = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Position in the output mapped to a position in a source file. Lines and columns start at 0.
+type mapping struct {
+	line, column             int
+	source                   int
+	sourceLine, sourceColumn int
+}
+
+// Collects the mappings of a source map while the output is written
+// texts: text of the source files by name, to count source columns in UTF-16 code units
+type sourceMap struct {
+	mappings []mapping
+	sources  []string
+	indices  map[string]int
+	texts    map[string][]byte
+}
+
+func newSourceMap(texts map[string][]byte) *sourceMap {
+	return &sourceMap{indices: map[string]int{}, texts: texts}
+}
+
+// Maps the output position line:column to the start of span. A later mapping for
+// the same output position replaces the earlier one, so the innermost node wins.
+func (m *sourceMap) add(line int, column int, span lexer.Span) {
+	source, exists := m.indices[span.File]
+	if !exists {
+		source = len(m.sources)
+		m.sources = append(m.sources, span.File)
+		m.indices[span.File] = source
+	}
+
+	next := mapping{
+		line:         line,
+		column:       column,
+		source:       source,
+		sourceLine:   span.Start.Line - 1,
+		sourceColumn: m.column(span.File, span.Start),
+	}
+	if n := len(m.mappings); n > 0 && m.mappings[n-1].line == line && m.mappings[n-1].column == column {
+		m.mappings[n-1] = next
+		return
+	}
+	m.mappings = append(m.mappings, next)
+}
+
+// Column of position in UTF-16 code units, counted from 0. Without the text of file,
+// or if position is not in it, the byte column is used instead.
+func (m *sourceMap) column(file string, position lexer.Position) int {
+	text := m.texts[file]
+	start := position.Offset - (position.Column - 1)
+	if start < 0 || position.Offset > len(text) {
+		return position.Column - 1
+	}
+
+	column := 0
+	for _, ch := range string(text[start:position.Offset]) {
+		column += utf16Length(ch)
+	}
+	return column
+}
+
+// Number of UTF-16 code units encoding ch
+func utf16Length(ch rune) int {
+	if ch >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// Encodes the source map as version 3 JSON
+func (m *sourceMap) encode(file string, sourceRoot string) []byte {
+	data, _ := json.Marshal(struct {
+		Version    int      `json:"version"`
+		File       string   `json:"file,omitempty"`
+		SourceRoot string   `json:"sourceRoot,omitempty"`
+		Sources    []string `json:"sources"`
+		Names      []string `json:"names"`
+		Mappings   string   `json:"mappings"`
+	}{
+		Version:    3,
+		File:       file,
+		SourceRoot: sourceRoot,
+		Sources:    append([]string{}, m.sources...),
+		Names:      []string{},
+		Mappings:   m.encodeMappings(),
+	})
+	return data
+}
+
+// Encodes the mappings: a ; per output line and a , between the segments of a line.
+// Each segment holds the output column, source index, source line and source column,
+// as differences to the previous segment. The output column starts over on every line.
+func (m *sourceMap) encodeMappings() string {
+	var out strings.Builder
+	var previous mapping
+	line := 0
+
+	for i, mapping := range m.mappings {
+		if mapping.line != line {
+			out.WriteString(strings.Repeat(";", mapping.line-line))
+			line, previous.column = mapping.line, 0
+		} else if i > 0 {
+			out.WriteByte(',')
+		}
+
+		writeVLQ(&out, mapping.column-previous.column)
+		writeVLQ(&out, mapping.source-previous.source)
+		writeVLQ(&out, mapping.sourceLine-previous.sourceLine)
+		writeVLQ(&out, mapping.sourceColumn-previous.sourceColumn)
+		previous = mapping
+	}
+	return out.String()
+}
+
+// Writes n as base64 VLQ: the sign in the lowest bit, then 5 bits per digit with bit 6 marking continuation
+func writeVLQ(out *strings.Builder, n int) {
+	v := n << 1
+	if n < 0 {
+		v = -n<<1 | 1
+	}
+
+	for {
+		digit := v & 0x1f
+		v >>= 5
+		if v > 0 {
+			digit |= 0x20
+		}
+		out.WriteByte(base64_digits[digit])
+		if v == 0 {
+			return
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package js
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// A decoded mapping, with lines and columns as in the source map
+type segment struct {
+	line, column             int
+	source                   int
+	sourceLine, sourceColumn int
+}
+
+// Decodes the mappings of a source map, inverting encodeMappings
+func decodeMappings(t *testing.T, mappings string) []segment {
+	t.Helper()
+	var segments []segment
+	var previous segment
+
+	for line, group := range strings.Split(mappings, ";") {
+		previous.column = 0
+		if group == "" {
+			continue
+		}
+		for _, encoded := range strings.Split(group, ",") {
+			var fields []int
+			for value, shift := 0, 0; encoded != ""; encoded = encoded[1:] {
+				digit := strings.IndexByte(base64_digits, encoded[0])
+				if digit < 0 {
+					t.Fatalf("invalid base64 digit %q in %q", encoded[0], mappings)
+				}
+				value |= (digit & 0x1f) << shift
+				shift += 5
+				if digit&0x20 == 0 {
+					if value&1 == 1 {
+						fields = append(fields, -(value >> 1))
+					} else {
+						fields = append(fields, value>>1)
+					}
+					value, shift = 0, 0
+				}
+			}
+			if len(fields) != 4 {
+				t.Fatalf("segment with %d fields in %q, want 4", len(fields), mappings)
+			}
+
+			previous = segment{
+				line:         line,
+				column:       previous.column + fields[0],
+				source:       previous.source + fields[1],
+				sourceLine:   previous.sourceLine + fields[2],
+				sourceColumn: previous.sourceColumn + fields[3],
+			}
+			segments = append(segments, previous)
+		}
+	}
+	return segments
+}
+
+// Text of line from the given UTF-16 column on
+func fromColumn(t *testing.T, line string, column int) string {
+	t.Helper()
+	units := utf16.Encode([]rune(line))
+	if column > len(units) {
+		t.Fatalf("column %d is past the end of %q", column, line)
+	}
+	return string(utf16.Decode(units[column:]))
+}
+
+func TestSourceMap(t *testing.T) {
+	file := "test.lang"
+	source := "let s = \"héllo 😀\"; let t = s;\nprintln(\"ü😀\", t, len(s));\n"
+
+	tokens, lexErrors := lexer.TokenizeFile(file, source)
+	program, parseErrors := parser.Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+		t.Fatalf("syntax errors: %v", errors)
+	}
+
+	output, diagnostics := Generate(program, Options{
+		SourceMap: true,
+		File:      "test.js",
+		Sources:   map[string][]byte{file: []byte(source)},
+	})
+	if len(diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", diagnostics)
+	}
+
+	var sourceMap struct {
+		Version  int      `json:"version"`
+		File     string   `json:"file"`
+		Sources  []string `json:"sources"`
+		Mappings string   `json:"mappings"`
+	}
+	if err := json.Unmarshal(output.SourceMap, &sourceMap); err != nil {
+		t.Fatal(err)
+	}
+	if sourceMap.Version != 3 || sourceMap.File != "test.js" || len(sourceMap.Sources) != 1 || sourceMap.Sources[0] != file {
+		t.Fatalf("source map header: %s", output.SourceMap)
+	}
+
+	sourceLines, generatedLines := strings.Split(source, "\n"), strings.Split(string(output.Code), "\n")
+	segments := decodeMappings(t, sourceMap.Mappings)
+	if len(segments) == 0 {
+		t.Fatal("no mappings")
+	}
+
+	mapped := map[string]bool{}
+	for _, segment := range segments {
+		if segment.source != 0 || segment.sourceLine >= len(sourceLines) || segment.line >= len(generatedLines) {
+			t.Fatalf("mapping %+v is outside of the files", segment)
+		}
+
+		// each mapping goes from a node in the output to the same node in the source
+		original := fromColumn(t, sourceLines[segment.sourceLine], segment.sourceColumn)
+		generated := fromColumn(t, generatedLines[segment.line], segment.column)
+		if original[0] != generated[0] {
+			t.Errorf("output %q is mapped to source %q", generated, original)
+		}
+		mapped[original] = true
+	}
+
+	// positions after characters of two bytes or two UTF-16 code units
+	for _, want := range []string{"let t = s;", "s;", "t, len(s));", "len(s));"} {
+		if !mapped[want] {
+			t.Errorf("no mapping to %q in %+v", want, segments)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sanity-io/litter"
+	"github.com/thutasann/go-parser/src/astjson"
+	"github.com/thutasann/go-parser/src/compiler"
+	"github.com/thutasann/go-parser/src/interp"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/loader"
+	"github.com/thutasann/go-parser/src/parser"
+	"github.com/thutasann/go-parser/src/types"
+	"github.com/thutasann/go-parser/src/vm"
+)
+
+// Name used for standard input in diagnostics
+const stdinName = "<stdin>"
+
+// Reads the single file argument of a command, or standard input when there is none or it is -
+//
+// - Returns a non-zero exit status after printing the problem when there is no usable input
+func readInput(command string, args []string) (path string, source []byte, exit int) {
+	if len(args) > 1 {
+		fmt.Fprintf(os.Stderr, "%s: expected a single file\n", command)
+		return "", nil, 2
+	}
+
+	var err error
+	if len(args) == 0 || args[0] == "-" {
+		path = stdinName
+		source, err = io.ReadAll(os.Stdin)
+	} else {
+		path = args[0]
+		source, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+		return "", nil, 1
+	}
+	return path, source, 0
+}
+
+// Converts "did anything fail" into an exit status
+func status(failed bool) int {
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// `tokens [-json] [file]` prints one token per line as line:column, kind and value
+func runTokens(args []string) int {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the tokens as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	path, source, exit := readInput("tokens", flags.Args())
+	if exit != 0 {
+		return exit
+	}
+
+	tokens, diagnostics := lexer.TokenizeFile(path, string(source))
+	if *asJSON {
+		if exit := printJSON(astjson.MarshalTokensIndent(tokens, "  ")); exit != 0 {
+			return exit
+		}
+	} else {
+		for _, token := range tokens {
+			fmt.Printf("%d:%d\t%s\t%q\n", token.Span.Start.Line, token.Span.Start.Column, lexer.TokenKindString(token.Kind), token.Value)
+		}
+	}
+
+	r := newReporter(os.Stderr)
+	r.addSource(path, source)
+	return status(r.diagnostics(diagnostics))
+}
+
+// `ast [-json] [file]` dumps the syntax tree, even when it has errors
+func runAst(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	path, source, exit := readInput("ast", flags.Args())
+	if exit != 0 {
+		return exit
+	}
+
+	tokens, lexErrors := lexer.TokenizeFile(path, string(source))
+	program, parseErrors := parser.Parse(tokens)
+	if *asJSON {
+		if exit := printJSON(astjson.MarshalIndent(program, "  ")); exit != 0 {
+			return exit
+		}
+	} else {
+		litter.Dump(program)
+	}
+
+	r := newReporter(os.Stderr)
+	r.addSource(path, source)
+	return status(r.diagnostics(append(lexErrors, parseErrors...)))
+}
+
+// Prints encoded JSON on its own line
+func printJSON(data []byte, err error) int {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+// `check [file]` loads the program with its imports and type checks every module
+func runCheck(args []string) int {
+	graph, r, exit := load("check", args)
+	if exit != 0 {
+		return exit
+	}
+
+	failed := false
+	for _, module := range graph.Order {
+		failed = r.diagnostics(types.Check(module.Program)) || failed
+	}
+	return status(failed)
+}
+
+// `run [-vm] [file]` loads the program with its imports and runs it, walking the
+// syntax tree or, with -vm, compiling every module to bytecode first
+func runRun(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	useVM := flags.Bool("vm", false, "compile to bytecode and run it on the virtual machine")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	graph, r, exit := load("run", flags.Args())
+	if exit != 0 {
+		return exit
+	}
+
+	if !*useVM {
+		if err := interp.New(os.Stdout).RunGraph(graph); err != nil {
+			r.runtimeError(err)
+			return 1
+		}
+		return 0
+	}
+
+	functions, exit := compileGraph(graph, r)
+	if exit != 0 {
+		return exit
+	}
+
+	machine := vm.New(os.Stdout)
+	for i, module := range graph.Order {
+		if err := machine.RunModule(module.Path, functions[i]); err != nil {
+			r.runtimeError(err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// `disasm [file]` compiles the program with its imports and prints the bytecode of every module
+func runDisasm(args []string) int {
+	graph, r, exit := load("disasm", args)
+	if exit != 0 {
+		return exit
+	}
+
+	functions, exit := compileGraph(graph, r)
+	if exit != 0 {
+		return exit
+	}
+
+	for i, module := range graph.Order {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("# %s\n", module.Path)
+		compiler.Disassemble(os.Stdout, functions[i])
+	}
+	return 0
+}
+
+// Compiles the modules of a program in graph.Order and prints the compile errors
+func compileGraph(graph *loader.Graph, r *reporter) ([]*compiler.Function, int) {
+	functions := make([]*compiler.Function, len(graph.Order))
+	failed := false
+	for i, module := range graph.Order {
+		fn, diagnostics := compiler.Compile(module.Program)
+		functions[i] = fn
+		failed = r.diagnostics(diagnostics) || failed
+	}
+	return functions, status(failed)
+}
+
+// Loads the program named by args and prints the loading diagnostics.
+// Returns a non-zero exit status when the program could not be loaded without errors.
+func load(command string, args []string) (*loader.Graph, *reporter, int) {
+	path, source, exit := readInput(command, args)
+	if exit != 0 {
+		return nil, nil, exit
+	}
+
+	r := newReporter(os.Stderr)
+	r.addSource(path, source)
+
+	graph, diagnostics := loader.LoadSource(path, string(source))
+	return graph, r, status(r.diagnostics(diagnostics))
+}
@@ -0,0 +1,228 @@
+package compiler
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Opcode is the first byte of an instruction, followed by its operands
+type Opcode byte
+
+const (
+	// constants and the stack
+	OpConstant  Opcode = iota // idx: push constants[idx]
+	OpNull                    // push null
+	OpTrue                    // push true
+	OpFalse                   // push false
+	OpUndefined               // push the marker of a variable that is not declared yet
+	OpPop                     // drop the top of the stack
+	OpDup                     // a → a a
+	OpDup2                    // a b → a b a b
+
+	// variables
+	OpDefineLocal // slot: pop into a local that is being declared
+	OpGetLocal    // slot: push a local of the current function
+	OpSetLocal    // slot: store the top of the stack into a local, keeping it
+	OpGetUpvalue  // idx: push a variable captured by the current closure
+	OpSetUpvalue  // idx: store the top of the stack into a captured variable, keeping it
+	OpGetGlobal   // name: push a builtin
+	OpSetGlobal   // name: always fails, builtins are constants
+	OpEndScope    // n: drop the n topmost locals, closing the ones captured by closures
+
+	// operators
+	OpAdd          // a b → a + b, numbers or string concatenation
+	OpSubtract     // a b → a - b
+	OpMultiply     // a b → a * b
+	OpDivide       // a b → a / b
+	OpModulo       // a b → a % b
+	OpEqual        // a b → a == b
+	OpNotEqual     // a b → a != b
+	OpLess         // a b → a < b
+	OpLessEqual    // a b → a <= b
+	OpGreater      // a b → a > b
+	OpGreaterEqual // a b → a >= b
+	OpNegate       // a → -a
+	OpNot          // a → !a
+	OpTypeof       // a → typeof a
+	OpTemplate     // n: n values → their text, concatenated
+
+	// control flow
+	OpJump        // offset: skip forward
+	OpJumpIfFalse // offset: pop a boolean, skip forward if it is false
+	OpJumpIfTrue  // offset: pop a boolean, skip forward if it is true
+	OpLoop        // offset: jump backward
+	OpIterator    // iterable → iterator over its elements
+	OpRange       // lower upper → iterator over the numbers from lower up to upper
+	OpIterNext    // offset: push the next element of the iterator on top of the stack, or skip forward when there is none
+
+	// functions
+	OpClosure // idx: push a closure of the function constants[idx], capturing its upvalues
+	OpCall    // argc: call the callee below the argc arguments
+	OpReturn  // pop the result, leave the current function and push it for the caller
+
+	// objects
+	OpArray        // n: n values → array
+	OpObject       // push an empty object
+	OpInitProperty // object key value → object, with the property set
+	OpGetMember    // name: object → object.name
+	OpSetMember    // name: object value → value, with object.name set
+	OpGetIndex     // object index → object[index]
+	OpSetIndex     // object index value → value, with object[index] set
+	OpClass        // idx: methods (and initializer) → class described by constants[idx]
+	OpInitField    // name: this value → this, with the field set
+	OpNew          // class → instance with its fields initialized
+	OpConstruct    // argc: instance args → instance, after running the constructor
+	OpImport       // path: push the module loaded from path
+	OpExport       // name: pop a value and export it from the current module
+)
+
+// Name, and size in bytes of each operand, of every opcode
+var definitions = [...]struct {
+	name     string
+	operands []int
+}{
+	OpConstant:     {"constant", []int{2}},
+	OpNull:         {"null", nil},
+	OpTrue:         {"true", nil},
+	OpFalse:        {"false", nil},
+	OpUndefined:    {"undefined", nil},
+	OpPop:          {"pop", nil},
+	OpDup:          {"dup", nil},
+	OpDup2:         {"dup2", nil},
+	OpDefineLocal:  {"define_local", []int{2}},
+	OpGetLocal:     {"get_local", []int{2}},
+	OpSetLocal:     {"set_local", []int{2}},
+	OpGetUpvalue:   {"get_upvalue", []int{2}},
+	OpSetUpvalue:   {"set_upvalue", []int{2}},
+	OpGetGlobal:    {"get_global", []int{2}},
+	OpSetGlobal:    {"set_global", []int{2}},
+	OpEndScope:     {"end_scope", []int{2}},
+	OpAdd:          {"add", nil},
+	OpSubtract:     {"subtract", nil},
+	OpMultiply:     {"multiply", nil},
+	OpDivide:       {"divide", nil},
+	OpModulo:       {"modulo", nil},
+	OpEqual:        {"equal", nil},
+	OpNotEqual:     {"not_equal", nil},
+	OpLess:         {"less", nil},
+	OpLessEqual:    {"less_equal", nil},
+	OpGreater:      {"greater", nil},
+	OpGreaterEqual: {"greater_equal", nil},
+	OpNegate:       {"negate", nil},
+	OpNot:          {"not", nil},
+	OpTypeof:       {"typeof", nil},
+	OpTemplate:     {"template", []int{2}},
+	OpJump:         {"jump", []int{2}},
+	OpJumpIfFalse:  {"jump_if_false", []int{2}},
+	OpJumpIfTrue:   {"jump_if_true", []int{2}},
+	OpLoop:         {"loop", []int{2}},
+	OpIterator:     {"iterator", nil},
+	OpRange:        {"range", nil},
+	OpIterNext:     {"iter_next", []int{2}},
+	OpClosure:      {"closure", []int{2}},
+	OpCall:         {"call", []int{1}},
+	OpReturn:       {"return", nil},
+	OpArray:        {"array", []int{2}},
+	OpObject:       {"object", nil},
+	OpInitProperty: {"init_property", nil},
+	OpGetMember:    {"get_member", []int{2}},
+	OpSetMember:    {"set_member", []int{2}},
+	OpGetIndex:     {"get_index", nil},
+	OpSetIndex:     {"set_index", nil},
+	OpClass:        {"class", []int{2}},
+	OpInitField:    {"init_field", []int{2}},
+	OpNew:          {"new", nil},
+	OpConstruct:    {"construct", []int{1}},
+	OpImport:       {"import", []int{2}},
+	OpExport:       {"export", []int{2}},
+}
+
+// String returns the name of the opcode as shown by the disassembler, e.g. "get_local"
+func (op Opcode) String() string {
+	if int(op) < len(definitions) && definitions[op].name != "" {
+		return definitions[op].name
+	}
+	return "unknown"
+}
+
+// Width returns the size of the instruction starting with op, operands included
+func (op Opcode) Width() int {
+	width := 1
+	if int(op) < len(definitions) {
+		for _, size := range definitions[op].operands {
+			width += size
+		}
+	}
+	return width
+}
+
+// ReadUint16 decodes a two byte operand
+func ReadUint16(code []byte) int {
+	return int(binary.BigEndian.Uint16(code))
+}
+
+// Function is a compiled function: its bytecode, the constants the code refers to
+// and what it captures from the functions around it
+//
+// - Slot 0 holds `this` in methods and the function itself otherwise; the parameters follow
+//
+// - Constants are float64, string, *Function and *Class values
+type Function struct {
+	Name      string
+	Arity     int
+	Method    bool // slot 0 holds `this`
+	Code      []byte
+	Constants []any
+	Upvalues  []Upvalue
+	Locals    []Local     // names of the local slots, for error messages and the disassembler
+	Spans     []SpanEntry // source positions of the instructions, ordered by offset
+}
+
+// Upvalue tells a closure where to capture a variable from when it is created
+type Upvalue struct {
+	Name  string
+	Local bool // a local slot of the enclosing function, rather than one of its upvalues
+	Index int
+}
+
+// Local names the variable held by a slot while the code from Start up to End runs
+type Local struct {
+	Name       string
+	Slot       int
+	Start, End int
+}
+
+// SpanEntry locates the instructions from Offset up to the next entry in the source
+type SpanEntry struct {
+	Offset int
+	Span   lexer.Span
+}
+
+// Class describes a class declaration. The closures of its methods, followed by the
+// closure of its field initializer when it has fields, are on the stack when OpClass runs.
+type Class struct {
+	Name    string
+	Fields  []string // in declaration order
+	Methods []string // names of the method closures, in order
+}
+
+// LocalName returns the name of the variable in slot when the instruction at offset runs
+func (fn *Function) LocalName(slot int, offset int) string {
+	for _, local := range fn.Locals {
+		if local.Slot == slot && local.Start <= offset && offset < local.End {
+			return local.Name
+		}
+	}
+	return ""
+}
+
+// SpanAt returns the source position of the instruction at offset
+func (fn *Function) SpanAt(offset int) lexer.Span {
+	i := sort.Search(len(fn.Spans), func(i int) bool { return fn.Spans[i].Offset > offset })
+	if i == 0 {
+		return lexer.Span{}
+	}
+	return fn.Spans[i-1].Span
+}
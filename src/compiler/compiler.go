@@ -0,0 +1,386 @@
+// Package compiler lowers syntax trees to bytecode for the stack machine in package vm.
+//
+// Every function, the top level of a program included, becomes a Function holding its
+// instructions and constants pool. Variables are resolved while compiling:
+//
+// - Locals live in numbered stack slots of their function
+//
+// - Variables of enclosing functions are captured as upvalues, which closures keep alive
+//
+// - Names that are not declared anywhere are looked up among the builtins when the code runs
+//
+// The declarations of a block are reserved when the block starts, so functions can call
+// functions declared after them. Reading a variable before its declaration has run fails.
+package compiler
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Compiler diagnostic codes
+const (
+	CodeRedeclared       lexer.DiagnosticCode = "C0001" // name declared twice in the same scope
+	CodeAssignToConstant lexer.DiagnosticCode = "C0002" // assignment to a const variable, function, class or import
+	CodeInvalidTarget    lexer.DiagnosticCode = "C0003" // assignment to something that is not a variable, member or index
+	CodeInvalidReturn    lexer.DiagnosticCode = "C0004" // return outside of a function
+	CodeInvalidRange     lexer.DiagnosticCode = "C0005" // range outside of a foreach loop
+	CodeSyntaxError      lexer.DiagnosticCode = "C0006" // statement or expression that failed to parse
+	CodeUnsupported      lexer.DiagnosticCode = "C0007" // node the compiler doesn't know, like a custom statement
+	CodeLimitExceeded    lexer.DiagnosticCode = "C0008" // too many constants, arguments or instructions in a function
+)
+
+// A local variable of the function being compiled
+// depth: scope depth the variable was declared at
+// start: offset at which the variable's slot was reserved
+type local struct {
+	name     string
+	depth    int
+	constant bool
+	start    int
+}
+
+// Compilation state of one function, linked to the function it is nested in
+// constants: index of every number and string constant, so each one is stored once
+// upconst: whether each upvalue refers to a constant, parallel to fn.Upvalues
+type funcState struct {
+	enclosing *funcState
+	fn        *Function
+	locals    []local
+	depth     int
+	constants map[any]int
+	upconst   []bool
+}
+
+// Holds the compilation state
+type compiler struct {
+	fs          *funcState
+	diagnostics []lexer.Diagnostic
+}
+
+// Compile lowers a program to the function running its top-level statements.
+// Exported declarations are published when it finishes, for the modules importing it.
+//
+// - Errors are reported as diagnostics, and the function must not be run when there are any
+func Compile(program ast.BlockStmt) (*Function, []lexer.Diagnostic) {
+	c := &compiler{}
+	c.begin("<script>", false)
+	c.fs.depth = 1
+
+	c.reserve(program.Body)
+	for _, stmt := range program.Body {
+		c.stmt(stmt)
+	}
+
+	for _, stmt := range program.Body {
+		if name, exported := exportedName(stmt); exported {
+			c.getVariable(name, stmt.Span())
+			c.emit(OpExport, stmt.Span(), c.constant(name))
+		}
+	}
+
+	end := lexer.Span{File: program.Loc.File, Start: program.Loc.End, End: program.Loc.End}
+	c.emit(OpNull, end)
+	c.emit(OpReturn, end)
+	return c.end(), c.diagnostics
+}
+
+// Name of a top-level declaration marked with export
+func exportedName(stmt ast.Stmt) (string, bool) {
+	switch decl := stmt.(type) {
+	case ast.VarDeclStmt:
+		return decl.VariableName, decl.IsExported
+	case ast.FunctionDeclStmt:
+		return decl.Name, decl.IsExported
+	case ast.ClassDeclStmt:
+		return decl.Name, decl.IsExported
+	default:
+		return "", false
+	}
+}
+
+// Records an error
+func (c *compiler) errorf(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, lexer.Errorf(code, span, format, args...))
+}
+
+// Starts compiling a nested function. Slot 0 is named `this` for methods and left unnamed otherwise.
+func (c *compiler) begin(name string, method bool) {
+	c.fs = &funcState{
+		enclosing: c.fs,
+		fn:        &Function{Name: name, Method: method},
+		constants: map[any]int{},
+	}
+
+	receiver := ""
+	if method {
+		receiver = "this"
+	}
+	c.fs.locals = append(c.fs.locals, local{name: receiver, constant: true})
+}
+
+// Finishes the current function, which must end with a return, and goes back to the enclosing one
+func (c *compiler) end() *Function {
+	fs := c.fs
+	for slot, l := range fs.locals {
+		fs.fn.Locals = append(fs.fn.Locals, Local{Name: l.name, Slot: slot, Start: l.start, End: len(fs.fn.Code)})
+	}
+
+	c.fs = fs.enclosing
+	return fs.fn
+}
+
+// Appends an instruction located at span. Operands are two bytes wide, except for the argument count of calls.
+func (c *compiler) emit(op Opcode, span lexer.Span, operands ...int) int {
+	fn := c.fs.fn
+	offset := len(fn.Code)
+
+	if n := len(fn.Spans); n == 0 || fn.Spans[n-1].Span != span {
+		fn.Spans = append(fn.Spans, SpanEntry{Offset: offset, Span: span})
+	}
+
+	fn.Code = append(fn.Code, byte(op))
+	for i, operand := range operands {
+		if definitions[op].operands[i] == 1 {
+			fn.Code = append(fn.Code, byte(operand))
+		} else {
+			fn.Code = binary.BigEndian.AppendUint16(fn.Code, uint16(operand))
+		}
+	}
+	return offset
+}
+
+// Adds a value to the constants pool and returns its index. Numbers and strings are only stored once.
+func (c *compiler) constant(value any) int {
+	switch value.(type) {
+	case float64, string:
+		if index, exists := c.fs.constants[value]; exists {
+			return index
+		}
+	}
+
+	fn := c.fs.fn
+	index := len(fn.Constants)
+	if index > math.MaxUint16 {
+		c.errorf(CodeLimitExceeded, c.lastSpan(), "%s has more than %d constants", fn.Name, math.MaxUint16+1)
+		return 0
+	}
+
+	fn.Constants = append(fn.Constants, value)
+	switch value.(type) {
+	case float64, string:
+		c.fs.constants[value] = index
+	}
+	return index
+}
+
+// Span of the last instruction, for errors found after the fact
+func (c *compiler) lastSpan() lexer.Span {
+	if n := len(c.fs.fn.Spans); n > 0 {
+		return c.fs.fn.Spans[n-1].Span
+	}
+	return lexer.Span{}
+}
+
+// Emits a forward jump whose target is filled in by patchJump, and returns where its operand is
+func (c *compiler) emitJump(op Opcode, span lexer.Span) int {
+	return c.emit(op, span, 0xffff) + 1
+}
+
+// Points the jump whose operand is at operand to the next instruction
+func (c *compiler) patchJump(operand int) {
+	code := c.fs.fn.Code
+	distance := len(code) - operand - 2
+	if distance > math.MaxUint16 {
+		c.errorf(CodeLimitExceeded, c.lastSpan(), "%s is too large to jump over", c.fs.fn.Name)
+	}
+	binary.BigEndian.PutUint16(code[operand:], uint16(distance))
+}
+
+// Emits a backward jump to start
+func (c *compiler) emitLoop(start int, span lexer.Span) {
+	distance := len(c.fs.fn.Code) + OpLoop.Width() - start
+	if distance > math.MaxUint16 {
+		c.errorf(CodeLimitExceeded, span, "loop body is too large")
+	}
+	c.emit(OpLoop, span, distance)
+}
+
+func (c *compiler) beginScope() {
+	c.fs.depth++
+}
+
+// Drops the locals of the innermost scope
+func (c *compiler) endScope(span lexer.Span) {
+	fs := c.fs
+	n := 0
+	for len(fs.locals) > 0 && fs.locals[len(fs.locals)-1].depth == fs.depth {
+		l := fs.locals[len(fs.locals)-1]
+		fs.fn.Locals = append(fs.fn.Locals, Local{Name: l.name, Slot: len(fs.locals) - 1, Start: l.start, End: len(fs.fn.Code)})
+		fs.locals = fs.locals[:len(fs.locals)-1]
+		n++
+	}
+
+	if n > 0 {
+		c.emit(OpEndScope, span, n)
+	}
+	fs.depth--
+}
+
+// Adds a local to the innermost scope, whose value the caller has pushed or is about to push
+func (c *compiler) addLocal(name string, constant bool, span lexer.Span) {
+	fs := c.fs
+	for i := len(fs.locals) - 1; i >= 0 && fs.locals[i].depth == fs.depth; i-- {
+		if name != "" && fs.locals[i].name == name {
+			c.errorf(CodeRedeclared, span, "%s is already declared in this scope", name)
+			break
+		}
+	}
+
+	fs.locals = append(fs.locals, local{name: name, depth: fs.depth, constant: constant, start: len(fs.fn.Code)})
+}
+
+// Reserves a slot for every name the statements of a block declare, so that they can
+// refer to each other whatever the order of their declarations
+func (c *compiler) reserve(body []ast.Stmt) {
+	for _, stmt := range body {
+		switch decl := stmt.(type) {
+		case ast.VarDeclStmt:
+			c.addLocal(decl.VariableName, decl.IsConstant, decl.Span())
+			c.emit(OpUndefined, decl.Span())
+		case ast.FunctionDeclStmt:
+			c.addLocal(decl.Name, true, decl.Span())
+			c.emit(OpUndefined, decl.Span())
+		case ast.ClassDeclStmt:
+			c.addLocal(decl.Name, true, decl.Span())
+			c.emit(OpUndefined, decl.Span())
+		case ast.ImportStmt:
+			names := decl.Names
+			if decl.Alias != "" {
+				names = []string{decl.Alias}
+			}
+			for _, name := range names {
+				c.addLocal(name, true, decl.Span())
+				c.emit(OpUndefined, decl.Span())
+			}
+		}
+	}
+}
+
+// Stores the value on top of the stack into the reserved slot of a declaration in the innermost scope
+func (c *compiler) define(name string, span lexer.Span) {
+	fs := c.fs
+	for i := len(fs.locals) - 1; i >= 0 && fs.locals[i].depth == fs.depth; i-- {
+		if fs.locals[i].name == name {
+			c.emit(OpDefineLocal, span, i)
+			return
+		}
+	}
+}
+
+// Returns the slot of the innermost local called name in fs, or -1
+func resolveLocal(fs *funcState, name string) int {
+	for i := len(fs.locals) - 1; i >= 0; i-- {
+		if fs.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the index of the upvalue through which fs reaches name in an enclosing function, or -1
+func resolveUpvalue(fs *funcState, name string) int {
+	if fs.enclosing == nil {
+		return -1
+	}
+
+	if slot := resolveLocal(fs.enclosing, name); slot >= 0 {
+		return addUpvalue(fs, name, true, slot, fs.enclosing.locals[slot].constant)
+	}
+
+	if index := resolveUpvalue(fs.enclosing, name); index >= 0 {
+		return addUpvalue(fs, name, false, index, fs.enclosing.upconst[index])
+	}
+	return -1
+}
+
+// Adds an upvalue to fs unless it already captures the same variable
+func addUpvalue(fs *funcState, name string, isLocal bool, index int, constant bool) int {
+	for i, upvalue := range fs.fn.Upvalues {
+		if upvalue.Local == isLocal && upvalue.Index == index {
+			return i
+		}
+	}
+
+	fs.fn.Upvalues = append(fs.fn.Upvalues, Upvalue{Name: name, Local: isLocal, Index: index})
+	fs.upconst = append(fs.upconst, constant)
+	return len(fs.fn.Upvalues) - 1
+}
+
+// Pushes the value of a variable: a local, an upvalue, true/false/null or a builtin
+func (c *compiler) getVariable(name string, span lexer.Span) {
+	if slot := resolveLocal(c.fs, name); slot >= 0 {
+		c.emit(OpGetLocal, span, slot)
+		return
+	}
+	if index := resolveUpvalue(c.fs, name); index >= 0 {
+		c.emit(OpGetUpvalue, span, index)
+		return
+	}
+
+	switch name {
+	case "true":
+		c.emit(OpTrue, span)
+	case "false":
+		c.emit(OpFalse, span)
+	case "null":
+		c.emit(OpNull, span)
+	default:
+		c.emit(OpGetGlobal, span, c.constant(name))
+	}
+}
+
+// Stores the value on top of the stack into a variable, keeping it on the stack
+func (c *compiler) setVariable(name string, span lexer.Span) {
+	if slot := resolveLocal(c.fs, name); slot >= 0 {
+		if c.fs.locals[slot].constant {
+			c.errorf(CodeAssignToConstant, span, "cannot assign to constant %s", name)
+		}
+		c.emit(OpSetLocal, span, slot)
+		return
+	}
+	if index := resolveUpvalue(c.fs, name); index >= 0 {
+		if c.fs.upconst[index] {
+			c.errorf(CodeAssignToConstant, span, "cannot assign to constant %s", name)
+		}
+		c.emit(OpSetUpvalue, span, index)
+		return
+	}
+
+	c.emit(OpSetGlobal, span, c.constant(name))
+}
+
+// Compiles a function and emits the closure creating it
+func (c *compiler) function(name string, parameters []ast.Parameter, body ast.BlockStmt, method bool, span lexer.Span) {
+	c.begin(name, method)
+	c.fs.depth = 1
+	c.fs.fn.Arity = len(parameters)
+
+	for _, parameter := range parameters {
+		c.addLocal(parameter.Name, false, parameter.Span())
+	}
+
+	c.reserve(body.Body)
+	for _, stmt := range body.Body {
+		c.stmt(stmt)
+	}
+
+	end := lexer.Span{File: body.Loc.File, Start: body.Loc.End, End: body.Loc.End}
+	c.emit(OpNull, end)
+	c.emit(OpReturn, end)
+	fn := c.end()
+	c.emit(OpClosure, span, c.constant(fn))
+}
@@ -0,0 +1,59 @@
+package compiler
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the .disasm files in testdata with the current disassembly")
+
+// The programs in testdata disassemble to the .disasm file next to them
+func TestDisassemble(t *testing.T) {
+	programs, _ := filepath.Glob("testdata/*.lang")
+	if len(programs) == 0 {
+		t.Fatal("no programs to compile")
+	}
+
+	for _, path := range programs {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens, lexErrors := lexer.TokenizeFile(path, string(source))
+			program, parseErrors := parser.Parse(tokens)
+			if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+				t.Fatalf("syntax errors: %v", errors)
+			}
+			fn, diagnostics := Compile(program)
+			if len(diagnostics) > 0 {
+				t.Fatalf("compile errors: %v", diagnostics)
+			}
+
+			var out bytes.Buffer
+			Disassemble(&out, fn)
+
+			golden := strings.TrimSuffix(path, ".lang") + ".disasm"
+			if *update {
+				if err := os.WriteFile(golden, out.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != string(want) {
+				t.Errorf("disassembly:\n%s\nwant:\n%s", out.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+)
+
+// Disassemble prints the bytecode of fn, followed by the functions it creates, one instruction per line:
+//
+//	0012  3:5  get_local 1 (count)
+//
+// - The columns are the offset, the source line:column and the instruction
+//
+// - Operands are annotated with the constant, variable or jump target they refer to
+func Disassemble(w io.Writer, fn *Function) {
+	fmt.Fprintf(w, "== %s ==\n", fn.Name)
+
+	var nested []*Function
+	for offset := 0; offset < len(fn.Code); {
+		op := Opcode(fn.Code[offset])
+		span := fn.SpanAt(offset)
+		fmt.Fprintf(w, "%04d  %d:%d\t%s\n", offset, span.Start.Line, span.Start.Column, fn.instruction(offset))
+		offset += op.Width()
+	}
+
+	for _, constant := range fn.Constants {
+		if f, ok := constant.(*Function); ok {
+			nested = append(nested, f)
+		}
+	}
+	for _, f := range nested {
+		fmt.Fprintln(w)
+		Disassemble(w, f)
+	}
+}
+
+// Formats the instruction at offset with its annotated operand
+func (fn *Function) instruction(offset int) string {
+	op := Opcode(fn.Code[offset])
+	if op.Width() == 1 {
+		return op.String()
+	}
+
+	var arg int
+	if op.Width() == 2 {
+		arg = int(fn.Code[offset+1])
+	} else {
+		arg = ReadUint16(fn.Code[offset+1:])
+	}
+
+	var note string
+	switch op {
+	case OpConstant, OpGetGlobal, OpSetGlobal, OpGetMember, OpSetMember, OpInitField, OpImport, OpExport:
+		note = describeConstant(fn.Constants[arg])
+	case OpClosure:
+		note = "fn " + fn.Constants[arg].(*Function).Name
+	case OpClass:
+		note = "class " + fn.Constants[arg].(*Class).Name
+	case OpDefineLocal, OpGetLocal, OpSetLocal:
+		note = fn.LocalName(arg, offset)
+	case OpGetUpvalue, OpSetUpvalue:
+		note = fn.Upvalues[arg].Name
+	case OpJump, OpJumpIfFalse, OpJumpIfTrue, OpIterNext:
+		note = fmt.Sprintf("→ %04d", offset+op.Width()+arg)
+	case OpLoop:
+		note = fmt.Sprintf("→ %04d", offset+op.Width()-arg)
+	}
+
+	if note == "" {
+		return fmt.Sprintf("%s %d", op, arg)
+	}
+	return fmt.Sprintf("%s %d (%s)", op, arg, note)
+}
+
+// Formats a number or string constant the way it appears in source
+func describeConstant(constant any) string {
+	switch c := constant.(type) {
+	case string:
+		return fmt.Sprintf("%q", c)
+	default:
+		return fmt.Sprint(c)
+	}
+}
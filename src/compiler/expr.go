@@ -0,0 +1,225 @@
+package compiler
+
+import (
+	"math"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Opcodes of the binary operators that evaluate both operands
+var binary_ops = map[lexer.TokenKind]Opcode{
+	lexer.PLUS:           OpAdd,
+	lexer.DASH:           OpSubtract,
+	lexer.STAR:           OpMultiply,
+	lexer.SLASH:          OpDivide,
+	lexer.PERCENT:        OpModulo,
+	lexer.EQUALS:         OpEqual,
+	lexer.NOT_EQUALS:     OpNotEqual,
+	lexer.LESS:           OpLess,
+	lexer.LESS_EQUALS:    OpLessEqual,
+	lexer.GREATER:        OpGreater,
+	lexer.GREATER_EQUALS: OpGreaterEqual,
+}
+
+// Compiles an expression, which pushes exactly one value
+func (c *compiler) expr(expr ast.Expr) {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		c.emit(OpConstant, n.Span(), c.constant(n.Value))
+	case ast.StringExpr:
+		c.emit(OpConstant, n.Span(), c.constant(n.Value))
+	case ast.TemplateExpr:
+		c.template(n)
+	case ast.SymbolExpr:
+		c.getVariable(n.Value, n.Span())
+	case ast.PrefixExpr:
+		c.expr(n.RightExpr)
+		switch n.Operator.Kind {
+		case lexer.DASH:
+			c.emit(OpNegate, n.RightExpr.Span())
+		case lexer.NOT:
+			c.emit(OpNot, n.RightExpr.Span())
+		case lexer.TYPEOF:
+			c.emit(OpTypeof, n.RightExpr.Span())
+		default:
+			c.errorf(CodeUnsupported, n.Operator.Span, "unsupported prefix operator %s", n.Operator.Value)
+		}
+	case ast.BinaryExpr:
+		c.binary(n)
+	case ast.AssignmentExpr:
+		c.assignment(n)
+	case ast.FunctionExpr:
+		c.function("<anonymous>", n.Parameters, n.Body, false, n.Span())
+	case ast.CallExpr:
+		c.expr(n.Method)
+		c.arguments(n.Arguments, n.Span())
+		c.emit(OpCall, n.Span(), len(n.Arguments))
+	case ast.MemberExpr:
+		c.expr(n.Member)
+		c.emit(OpGetMember, n.Span(), c.constant(n.Property))
+	case ast.ComputedExpr:
+		c.expr(n.Member)
+		c.expr(n.Property)
+		c.emit(OpGetIndex, n.Span())
+	case ast.NewExpr:
+		c.expr(n.Instantiation.Method)
+		c.emit(OpNew, n.Instantiation.Method.Span())
+		c.arguments(n.Instantiation.Arguments, n.Span())
+		c.emit(OpConstruct, n.Span(), len(n.Instantiation.Arguments))
+	case ast.ArrayLiteral:
+		for _, element := range n.Contents {
+			c.expr(element)
+		}
+		c.emit(OpArray, n.Span(), len(n.Contents))
+	case ast.ObjectLiteral:
+		c.object(n)
+	case ast.RangeExpr:
+		c.errorf(CodeInvalidRange, n.Span(), "ranges can only be used in foreach loops")
+		c.emit(OpNull, n.Span())
+	case ast.BadExpr:
+		c.errorf(CodeSyntaxError, n.Span(), "cannot compile an expression with syntax errors")
+		c.emit(OpNull, n.Span())
+	default:
+		c.errorf(CodeUnsupported, expr.Span(), "unsupported expression %T", expr)
+		c.emit(OpNull, expr.Span())
+	}
+}
+
+// Pushes the arguments of a call, which fit in the one byte operand of OpCall
+func (c *compiler) arguments(arguments []ast.Expr, span lexer.Span) {
+	if len(arguments) > math.MaxUint8 {
+		c.errorf(CodeLimitExceeded, span, "calls take at most %d arguments", math.MaxUint8)
+	}
+	for _, argument := range arguments {
+		c.expr(argument)
+	}
+}
+
+// Pushes the parts of a template, leaving out the empty strings between interpolations
+func (c *compiler) template(n ast.TemplateExpr) {
+	parts := 0
+	text := func(s string) {
+		if s != "" {
+			c.emit(OpConstant, n.Span(), c.constant(s))
+			parts++
+		}
+	}
+
+	for i, expr := range n.Expressions {
+		text(n.Strings[i])
+		c.expr(expr)
+		parts++
+	}
+	text(n.Strings[len(n.Strings)-1])
+
+	switch {
+	case parts == 0:
+		c.emit(OpConstant, n.Span(), c.constant(""))
+	case len(n.Expressions) > 0:
+		c.emit(OpTemplate, n.Span(), parts)
+	}
+}
+
+// Logical operators short-circuit and always produce a boolean; the others evaluate both sides
+//
+//	a && b:  a; jump_if_false F; b; jump_if_false F; true; jump E; F: false; E:
+func (c *compiler) binary(n ast.BinaryExpr) {
+	switch n.Operator.Kind {
+	case lexer.AND, lexer.OR:
+		test, result := OpJumpIfFalse, OpTrue
+		if n.Operator.Kind == lexer.OR {
+			test, result = OpJumpIfTrue, OpFalse
+		}
+
+		c.expr(n.Left)
+		left := c.emitJump(test, n.Left.Span())
+		c.expr(n.Right)
+		right := c.emitJump(test, n.Right.Span())
+		c.emit(result, n.Span())
+		end := c.emitJump(OpJump, n.Span())
+
+		c.patchJump(left)
+		c.patchJump(right)
+		if result == OpTrue {
+			c.emit(OpFalse, n.Span())
+		} else {
+			c.emit(OpTrue, n.Span())
+		}
+		c.patchJump(end)
+		return
+	}
+
+	op, exists := binary_ops[n.Operator.Kind]
+	if !exists {
+		c.errorf(CodeUnsupported, n.Operator.Span, "unsupported binary operator %s", n.Operator.Value)
+	}
+
+	c.expr(n.Left)
+	c.expr(n.Right)
+	c.emit(op, n.Span())
+}
+
+// Compiles `target = value`, `target += value` and `target -= value`.
+// The object and index of the target are evaluated once, before the value; compound
+// assignments read the target from copies of them and apply the operator before storing.
+func (c *compiler) assignment(n ast.AssignmentExpr) {
+	// Pushes the value to store, for compound assignments reading the current one with read first
+	value := func(read func()) {
+		var op Opcode
+		switch n.Operator.Kind {
+		case lexer.PLUS_EQUALS:
+			op = OpAdd
+		case lexer.MINUS_EQUALS:
+			op = OpSubtract
+		default:
+			c.expr(n.Value)
+			return
+		}
+
+		read()
+		c.expr(n.Value)
+		c.emit(op, n.Span())
+	}
+
+	switch target := n.Assigne.(type) {
+	case ast.SymbolExpr:
+		value(func() { c.getVariable(target.Value, target.Span()) })
+		c.setVariable(target.Value, n.Span())
+	case ast.MemberExpr:
+		c.expr(target.Member)
+		value(func() {
+			c.emit(OpDup, target.Span())
+			c.emit(OpGetMember, target.Span(), c.constant(target.Property))
+		})
+		c.emit(OpSetMember, target.Span(), c.constant(target.Property))
+	case ast.ComputedExpr:
+		c.expr(target.Member)
+		c.expr(target.Property)
+		value(func() {
+			c.emit(OpDup2, target.Span())
+			c.emit(OpGetIndex, target.Span())
+		})
+		c.emit(OpSetIndex, target.Span())
+	default:
+		c.errorf(CodeInvalidTarget, n.Assigne.Span(), "invalid assignment target")
+	}
+}
+
+// Builds an object property by property, in source order
+func (c *compiler) object(n ast.ObjectLiteral) {
+	c.emit(OpObject, n.Span())
+
+	for _, property := range n.Properties {
+		span := property.Span()
+		if property.ComputedKey != nil {
+			c.expr(property.ComputedKey)
+			span = property.ComputedKey.Span()
+		} else {
+			c.emit(OpConstant, property.Span(), c.constant(property.Key))
+		}
+
+		c.expr(property.Value)
+		c.emit(OpInitProperty, span)
+	}
+}
@@ -0,0 +1,219 @@
+package compiler
+
+import (
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/loader"
+)
+
+// Compiles a statement, which leaves the stack as it found it
+func (c *compiler) stmt(stmt ast.Stmt) {
+	switch n := stmt.(type) {
+	case ast.ExpressionStmt:
+		c.expr(n.Expression)
+		c.emit(OpPop, n.Span())
+	case ast.BlockStmt:
+		c.block(n)
+	case ast.VarDeclStmt:
+		c.varDecl(n)
+	case ast.FunctionDeclStmt:
+		c.function(n.Name, n.Parameters, n.Body, false, n.Span())
+		c.define(n.Name, n.Span())
+	case ast.ClassDeclStmt:
+		c.classDecl(n)
+	case ast.ReturnStmt:
+		if c.fs.enclosing == nil {
+			c.errorf(CodeInvalidReturn, n.Span(), "return outside of function")
+		}
+		if n.Value != nil {
+			c.expr(n.Value)
+		} else {
+			c.emit(OpNull, n.Span())
+		}
+		c.emit(OpReturn, n.Span())
+	case ast.IfStmt:
+		c.ifStmt(n)
+	case ast.WhileStmt:
+		start := len(c.fs.fn.Code)
+		c.expr(n.Condition)
+		exit := c.emitJump(OpJumpIfFalse, n.Condition.Span())
+		c.block(n.Body)
+		c.emitLoop(start, n.Span())
+		c.patchJump(exit)
+	case ast.ForStmt:
+		c.forStmt(n)
+	case ast.ForeachStmt:
+		c.foreachStmt(n)
+	case ast.ImportStmt:
+		c.importStmt(n)
+	case ast.BadStmt:
+		c.errorf(CodeSyntaxError, n.Span(), "cannot compile a statement with syntax errors")
+	default:
+		c.errorf(CodeUnsupported, stmt.Span(), "unsupported statement %T", stmt)
+	}
+}
+
+// Compiles a block in a scope of its own
+func (c *compiler) block(block ast.BlockStmt) {
+	c.beginScope()
+	c.reserve(block.Body)
+	for _, stmt := range block.Body {
+		c.stmt(stmt)
+	}
+	c.endScope(block.Span())
+}
+
+// `let x: T = value;`, where a missing value is the zero value of T
+func (c *compiler) varDecl(n ast.VarDeclStmt) {
+	if n.AssignedValue != nil {
+		c.expr(n.AssignedValue)
+	} else {
+		c.zeroValue(n.ExplicitType, n.Span())
+	}
+	c.define(n.VariableName, n.Span())
+}
+
+// Pushes the value of a variable declared with type t and no value
+func (c *compiler) zeroValue(t ast.Type, span lexer.Span) {
+	switch t := t.(type) {
+	case ast.SymbolType:
+		switch t.Name {
+		case "number":
+			c.emit(OpConstant, span, c.constant(0.0))
+			return
+		case "string":
+			c.emit(OpConstant, span, c.constant(""))
+			return
+		case "boolean":
+			c.emit(OpFalse, span)
+			return
+		}
+	case ast.ArrayType:
+		c.emit(OpArray, span, 0)
+		return
+	}
+	c.emit(OpNull, span)
+}
+
+// Pushes the method closures, then the field initializer, and turns them into a class.
+// The initializer is a method setting every field on the new instance; it runs in the scope
+// of the declaration, where `this` is not the instance.
+func (c *compiler) classDecl(n ast.ClassDeclStmt) {
+	class := &Class{Name: n.Name}
+
+	for _, method := range n.Methods {
+		c.function(n.Name+"."+method.Name, method.Parameters, method.Body, true, method.Span())
+		class.Methods = append(class.Methods, method.Name)
+	}
+
+	if len(n.Fields) > 0 {
+		c.begin(n.Name+".<fields>", true)
+		c.fs.locals[0].name = ""
+		c.emit(OpGetLocal, n.Span(), 0)
+		for _, field := range n.Fields {
+			if field.AssignedValue != nil {
+				c.expr(field.AssignedValue)
+			} else {
+				c.zeroValue(field.ExplicitType, field.Span())
+			}
+			c.emit(OpInitField, field.Span(), c.constant(field.VariableName))
+			class.Fields = append(class.Fields, field.VariableName)
+		}
+		c.emit(OpReturn, n.Span())
+		c.emit(OpClosure, n.Span(), c.constant(c.end()))
+	}
+
+	c.emit(OpClass, n.Span(), c.constant(class))
+	c.define(n.Name, n.Span())
+}
+
+func (c *compiler) ifStmt(n ast.IfStmt) {
+	c.expr(n.Condition)
+	otherwise := c.emitJump(OpJumpIfFalse, n.Condition.Span())
+	c.block(n.Consequent)
+
+	if n.Alternate == nil {
+		c.patchJump(otherwise)
+		return
+	}
+
+	end := c.emitJump(OpJump, n.Span())
+	c.patchJump(otherwise)
+	c.stmt(n.Alternate)
+	c.patchJump(end)
+}
+
+// `for (init; condition; post) { ... }`, where init is scoped to the loop
+func (c *compiler) forStmt(n ast.ForStmt) {
+	c.beginScope()
+	if n.Init != nil {
+		c.reserve([]ast.Stmt{n.Init})
+		c.stmt(n.Init)
+	}
+
+	start := len(c.fs.fn.Code)
+	exit := -1
+	if n.Condition != nil {
+		c.expr(n.Condition)
+		exit = c.emitJump(OpJumpIfFalse, n.Condition.Span())
+	}
+
+	c.block(n.Body)
+	if n.Post != nil {
+		c.expr(n.Post)
+		c.emit(OpPop, n.Post.Span())
+	}
+	c.emitLoop(start, n.Span())
+
+	if exit >= 0 {
+		c.patchJump(exit)
+	}
+	c.endScope(n.Span())
+}
+
+// `foreach value in iterable { ... }`. The iterator sits in a hidden slot, and every
+// iteration gets a scope of its own holding the value and the body's declarations.
+func (c *compiler) foreachStmt(n ast.ForeachStmt) {
+	c.beginScope()
+	if r, ok := n.Iterable.(ast.RangeExpr); ok {
+		c.expr(r.Lower)
+		c.expr(r.Upper)
+		c.emit(OpRange, r.Span())
+	} else {
+		c.expr(n.Iterable)
+		c.emit(OpIterator, n.Iterable.Span())
+	}
+	c.addLocal("", true, n.Span())
+
+	start := len(c.fs.fn.Code)
+	exit := c.emitJump(OpIterNext, n.Span())
+
+	c.beginScope()
+	c.addLocal(n.Value, false, n.Span())
+	c.reserve(n.Body.Body)
+	for _, stmt := range n.Body.Body {
+		c.stmt(stmt)
+	}
+	c.endScope(n.Body.Span())
+
+	c.emitLoop(start, n.Span())
+	c.patchJump(exit)
+	c.endScope(n.Span())
+}
+
+// `import alias from "path";` binds the module, `import { a, b } from "path";` its exports
+func (c *compiler) importStmt(n ast.ImportStmt) {
+	path := c.constant(loader.Resolve(n.Span().File, n.From))
+
+	if n.Alias != "" {
+		c.emit(OpImport, n.Span(), path)
+		c.define(n.Alias, n.Span())
+		return
+	}
+
+	for _, name := range n.Names {
+		c.emit(OpImport, n.Span(), path)
+		c.emit(OpGetMember, n.Span(), c.constant(name))
+		c.define(name, n.Span())
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines of context shown around each change
+const diffContext = 3
+
+// A line of a diff: ' ' kept, '-' removed, '+' added
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// Returns a unified diff turning before into after, or "" when they are equal.
+// Lines are matched with Myers' algorithm, in time proportional to the size of the files times the
+// number of changed lines and in space proportional to the size of the files.
+func unifiedDiff(name string, before []byte, after []byte) string {
+	a, b := splitLines(string(before)), splitLines(string(after))
+	lines := diffLines(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+
+	changed := false
+	for start := 0; ; {
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		changed = true
+
+		// changes separated by few enough unchanged lines share a hunk
+		last := first
+		for i := first + 1; i < len(lines) && i-last-1 <= 2*diffContext; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+
+		from, to := max(first-diffContext, start), min(last+1+diffContext, len(lines))
+		writeHunk(&out, lines, from, to)
+		start = to
+	}
+
+	if !changed {
+		return ""
+	}
+	return out.String()
+}
+
+// Writes lines[from:to] as a hunk with its @@ header
+func writeHunk(out *strings.Builder, lines []diffLine, from int, to int) {
+	oldStart, newStart := 1, 1
+	for _, line := range lines[:from] {
+		if line.op != '+' {
+			oldStart++
+		}
+		if line.op != '-' {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, line := range lines[from:to] {
+		if line.op != '+' {
+			oldCount++
+		}
+		if line.op != '-' {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, line := range lines[from:to] {
+		out.WriteByte(line.op)
+		out.WriteString(line.text)
+		out.WriteByte('\n')
+	}
+}
+
+// Marks a last line without a line break. Lines never contain "\n", so a marked line differs
+// from the same line followed by a line break, and prints as diff(1) shows it.
+const noNewline = "\n\\ No newline at end of file"
+
+// Splits text into lines without their line breaks, marking a last line that has none
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// Lines of a and b in order, marked as kept, removed or added
+func diffLines(a []string, b []string) []diffLine {
+	return appendDiff(make([]diffLine, 0, len(a)+len(b)), a, b)
+}
+
+// Appends the shortest diff of a and b to lines, found with Myers' algorithm in linear space:
+// the middle of an edit script splits a and b in two, and each half is diffed on its own
+func appendDiff(lines []diffLine, a []string, b []string) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{op: ' ', text: text})
+	}
+
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if x, y, found := middleSnake(middleA, middleB); found {
+		lines = appendDiff(lines, middleA[:x], middleB[:y])
+		lines = appendDiff(lines, middleA[x:], middleB[y:])
+	} else {
+		for _, text := range middleA {
+			lines = append(lines, diffLine{op: '-', text: text})
+		}
+		for _, text := range middleB {
+			lines = append(lines, diffLine{op: '+', text: text})
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{op: ' ', text: text})
+	}
+	return lines
+}
+
+// Finds where a shortest edit script of a and b crosses its middle, searching from both ends
+// at once. Returns the point (x, y) splitting a[:x], b[:y] from a[x:], b[y:], or false when
+// a and b have no line in common and the script is removing a then adding b.
+//
+// - forward[k] is the furthest x reached on diagonal k = x - y from the start, backward[k] the
+// furthest reached from the end, counted from the end
+func middleSnake(a []string, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward, backward := make([]int, 2*maxD+1), make([]int, 2*maxD+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	// with an odd delta the paths meet while extending forward, with an even one backward
+	delta := n - m
+	odd := delta%2 != 0
+
+	// diagonals that ran off the edit graph are skipped from then on
+	forwardStart, forwardEnd, backwardStart, backwardEnd := 0, 0, 0, 0
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + forwardStart; k <= d-forwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[i] = x
+
+			switch {
+			case x > n:
+				forwardEnd += 2
+			case y > m:
+				forwardStart += 2
+			case odd:
+				if j := offset + delta - k; j >= 0 && j < len(backward) && backward[j] != -1 && x >= n-backward[j] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + backwardStart; k <= d-backwardEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && backward[i-1] < backward[i+1]) {
+				x = backward[i+1]
+			} else {
+				x = backward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			backward[i] = x
+
+			switch {
+			case x > n:
+				backwardEnd += 2
+			case y > m:
+				backwardStart += 2
+			case !odd:
+				if j := offset + delta - k; j >= 0 && j < len(forward) && forward[j] != -1 {
+					forwardX := forward[j]
+					if forwardX >= n-x {
+						return forwardX, forwardX - (j - offset), true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Length of the longest common subsequence of a and b, the quadratic way
+func longestCommon(a []string, b []string) int {
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+	return common[0][0]
+}
+
+// Diffs turn a into b with as few removed and added lines as possible
+func TestDiffLines(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 5000; i++ {
+		a, b := random(), random()
+		var before, after []string
+		changes := 0
+		for _, line := range diffLines(a, b) {
+			if line.op != '+' {
+				before = append(before, line.text)
+			}
+			if line.op != '-' {
+				after = append(after, line.text)
+			}
+			if line.op != ' ' {
+				changes++
+			}
+		}
+
+		if strings.Join(before, "") != strings.Join(a, "") || strings.Join(after, "") != strings.Join(b, "") {
+			t.Fatalf("diff of %q and %q turns %q into %q", a, b, before, after)
+		}
+		if want := len(a) + len(b) - 2*longestCommon(a, b); changes != want {
+			t.Fatalf("diff of %q and %q has %d changed lines, want %d", a, b, changes, want)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	after := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	want := `--- f.lang
++++ f.lang
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if got := unifiedDiff("f.lang", []byte(before), []byte(after)); got != want {
+		t.Errorf("unifiedDiff:\n%s\nwant:\n%s", got, want)
+	}
+	if got := unifiedDiff("f.lang", []byte(before), []byte(before)); got != "" {
+		t.Errorf("unifiedDiff of equal files = %q, want \"\"", got)
+	}
+
+	// adding or removing the line break at the end of a file is a change, shown like diff(1) does
+	tests := []struct {
+		before string
+		after  string
+		want   string
+	}{
+		{"a", "a\n", "--- f.lang\n+++ f.lang\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+		{"a\nb\n", "a\nb", "--- f.lang\n+++ f.lang\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"a\nb", "A\nb", "--- f.lang\n+++ f.lang\n@@ -1,2 +1,2 @@\n-a\n+A\n b\n\\ No newline at end of file\n"},
+		{"a", "a", ""},
+	}
+
+	for _, test := range tests {
+		if got := unifiedDiff("f.lang", []byte(test.before), []byte(test.after)); got != test.want {
+			t.Errorf("unifiedDiff(%q, %q):\n%s\nwant:\n%s", test.before, test.after, got, test.want)
+		}
+	}
+}
+
+// A few edits to a large file are diffed without a table of every pair of lines
+func TestDiffLinesLargeFile(t *testing.T) {
+	a := make([]string, 200000)
+	for i := range a {
+		a[i] = fmt.Sprintf("line %d", i)
+	}
+	b := append([]string(nil), a...)
+	b[1000], b[100000] = "changed", "changed"
+	b = append(b[:150000], b[150010:]...)
+
+	changes := 0
+	for _, line := range diffLines(a, b) {
+		if line.op != ' ' {
+			changes++
+		}
+	}
+	if changes != 14 {
+		t.Errorf("diff has %d changed lines, want 14", changes)
+	}
+}
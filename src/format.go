@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/thutasann/go-parser/src/printer"
+)
+
+// `fmt [-w] [-d] [files...]` prints the files in canonical form, reading standard input when no file is given
+//
+// - -w rewrites the files that are not formatted yet
+//
+// - -d prints a diff for the files that are not formatted yet
+//
+// - Files with syntax errors are reported and left alone, and the exit status is 1
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result back to the source file instead of stdout")
+	diff := flags.Bool("d", false, "display diffs instead of the formatted source")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		if *write {
+			fmt.Fprintln(os.Stderr, "fmt: cannot use -w with standard input")
+			return 2
+		}
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "fmt:", err)
+			return 1
+		}
+		return formatSource(stdinName, source, false, *diff)
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "fmt:", err)
+			status = 1
+			continue
+		}
+		status = max(status, formatSource(path, source, *write, *diff))
+	}
+	return status
+}
+
+// Formats one file and writes, diffs or prints the result
+func formatSource(path string, source []byte, write bool, diff bool) int {
+	formatted, diagnostics := printer.Format(path, source)
+
+	r := newReporter(os.Stderr)
+	r.addSource(path, source)
+	r.diagnostics(diagnostics)
+	if formatted == nil {
+		return 1
+	}
+
+	if diff {
+		fmt.Print(unifiedDiff(path, source, formatted))
+	}
+
+	if write {
+		if bytes.Equal(source, formatted) {
+			return 0
+		}
+		if err := os.WriteFile(path, formatted, 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "fmt:", err)
+			return 1
+		}
+	} else if !diff {
+		os.Stdout.Write(formatted)
+	}
+	return 0
+}
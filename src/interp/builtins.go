@@ -0,0 +1,59 @@
+package interp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Installs the global constants and builtin functions
+func (in *Interpreter) defineGlobals() {
+	in.globals.declare("true", true, true)
+	in.globals.declare("false", false, true)
+	in.globals.declare("null", nil, true)
+
+	builtins := []*Builtin{
+		{Name: "println", Fn: builtinPrintln},
+		{Name: "print", Fn: builtinPrint},
+		{Name: "len", Fn: builtinLen},
+	}
+	for _, builtin := range builtins {
+		in.globals.declare(builtin.Name, builtin, true)
+	}
+}
+
+// println(args...) prints the arguments separated by spaces, followed by a newline
+func builtinPrintln(in *Interpreter, args []Value) (Value, error) {
+	_, err := fmt.Fprintln(in.Stdout, joinValues(args))
+	return nil, err
+}
+
+// print(args...) prints the arguments separated by spaces
+func builtinPrint(in *Interpreter, args []Value) (Value, error) {
+	_, err := fmt.Fprint(in.Stdout, joinValues(args))
+	return nil, err
+}
+
+// len(value) returns the number of characters in a string or elements in an array
+func builtinLen(in *Interpreter, args []Value) (Value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("len expects 1 argument but received %d", len(args))
+	}
+
+	switch v := args[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), nil
+	case *Array:
+		return float64(len(v.Elements)), nil
+	default:
+		return nil, fmt.Errorf("len is not defined for %s", typeName(v))
+	}
+}
+
+func joinValues(args []Value) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = stringify(arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,40 @@
+package interp
+
+// A single variable in a scope
+type binding struct {
+	value    Value
+	constant bool
+}
+
+// Environment is a lexical scope: a set of variables plus the enclosing scope
+type Environment struct {
+	parent *Environment
+	vars   map[string]*binding
+}
+
+// Creates a scope nested inside parent (nil for the global scope)
+func newEnvironment(parent *Environment) *Environment {
+	return &Environment{
+		parent: parent,
+		vars:   map[string]*binding{},
+	}
+}
+
+// Declares a variable in this scope. Returns false if the name is already declared here.
+func (env *Environment) declare(name string, value Value, constant bool) bool {
+	if _, exists := env.vars[name]; exists {
+		return false
+	}
+	env.vars[name] = &binding{value: value, constant: constant}
+	return true
+}
+
+// Finds the binding for name in this scope or the closest enclosing one
+func (env *Environment) lookup(name string) (*binding, bool) {
+	for scope := env; scope != nil; scope = scope.parent {
+		if b, exists := scope.vars[name]; exists {
+			return b, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,26 @@
+package interp
+
+import (
+	"fmt"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// RuntimeError is an error raised while executing a program, located at the node that caused it
+type RuntimeError struct {
+	Message string
+	Span    lexer.Span
+}
+
+// Error formats the error as file:line:column: runtime error: message
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s: runtime error: %s", e.Span, e.Message)
+}
+
+// Aborts execution with a runtime error. Run recovers it and returns it as an error.
+func throw(span lexer.Span, format string, args ...any) {
+	panic(&RuntimeError{
+		Message: fmt.Sprintf(format, args...),
+		Span:    span,
+	})
+}
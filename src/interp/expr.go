@@ -0,0 +1,432 @@
+package interp
+
+import (
+	"math"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Evaluates an expression in env
+func (in *Interpreter) eval(expr ast.Expr, env *Environment) Value {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		return n.Value
+	case ast.StringExpr:
+		return n.Value
+	case ast.TemplateExpr:
+		var text strings.Builder
+		for i, expr := range n.Expressions {
+			text.WriteString(n.Strings[i])
+			text.WriteString(stringify(in.eval(expr, env)))
+		}
+		text.WriteString(n.Strings[len(n.Strings)-1])
+		return text.String()
+	case ast.SymbolExpr:
+		b, exists := env.lookup(n.Value)
+		if !exists {
+			throw(n.Span(), "%s is not defined", n.Value)
+		}
+		return b.value
+	case ast.PrefixExpr:
+		return in.evalPrefix(n, env)
+	case ast.BinaryExpr:
+		return in.evalBinary(n, env)
+	case ast.AssignmentExpr:
+		return in.evalAssignment(n, env)
+	case ast.FunctionExpr:
+		return &Function{Name: "<anonymous>", Parameters: n.Parameters, Body: n.Body, Closure: env}
+	case ast.CallExpr:
+		callee := in.eval(n.Method, env)
+		return in.call(callee, in.evalArguments(n.Arguments, env), n.Span())
+	case ast.MemberExpr:
+		return in.member(in.eval(n.Member, env), n.Property, n.Span())
+	case ast.ComputedExpr:
+		return in.index(in.eval(n.Member, env), in.eval(n.Property, env), n.Span())
+	case ast.NewExpr:
+		return in.evalNew(n, env)
+	case ast.ArrayLiteral:
+		return &Array{Elements: in.evalArguments(n.Contents, env)}
+	case ast.ObjectLiteral:
+		return in.evalObject(n, env)
+	case ast.RangeExpr:
+		throw(n.Span(), "ranges can only be used in foreach loops")
+	case ast.BadExpr:
+		throw(n.Span(), "cannot evaluate an expression with syntax errors")
+	default:
+		throw(expr.Span(), "unsupported expression %T", expr)
+	}
+
+	return nil
+}
+
+func (in *Interpreter) evalArguments(arguments []ast.Expr, env *Environment) []Value {
+	values := make([]Value, len(arguments))
+	for i, argument := range arguments {
+		values[i] = in.eval(argument, env)
+	}
+	return values
+}
+
+// Evaluates a condition, which must be a boolean
+func (in *Interpreter) condition(expr ast.Expr, env *Environment) bool {
+	value := in.eval(expr, env)
+	b, ok := value.(bool)
+	if !ok {
+		throw(expr.Span(), "condition must be a boolean but is %s", typeName(value))
+	}
+	return b
+}
+
+// Evaluates an expression that must be a number
+func (in *Interpreter) number(expr ast.Expr, env *Environment) float64 {
+	value := in.eval(expr, env)
+	n, ok := value.(float64)
+	if !ok {
+		throw(expr.Span(), "expected number but found %s", typeName(value))
+	}
+	return n
+}
+
+func (in *Interpreter) evalPrefix(n ast.PrefixExpr, env *Environment) Value {
+	switch n.Operator.Kind {
+	case lexer.DASH:
+		return -in.number(n.RightExpr, env)
+	case lexer.NOT:
+		return !in.condition(n.RightExpr, env)
+	case lexer.TYPEOF:
+		return typeOf(in.eval(n.RightExpr, env))
+	default:
+		throw(n.Operator.Span, "unsupported prefix operator %s", n.Operator.Value)
+		return nil
+	}
+}
+
+func (in *Interpreter) evalBinary(n ast.BinaryExpr, env *Environment) Value {
+	// Logical operators short-circuit, so the right side is evaluated lazily
+	switch n.Operator.Kind {
+	case lexer.AND:
+		return in.condition(n.Left, env) && in.condition(n.Right, env)
+	case lexer.OR:
+		return in.condition(n.Left, env) || in.condition(n.Right, env)
+	}
+
+	left := in.eval(n.Left, env)
+	right := in.eval(n.Right, env)
+	return binary(n.Operator, left, right, n.Span())
+}
+
+// Applies an arithmetic, comparison or concatenation operator to two values
+func binary(operator lexer.Token, left Value, right Value, span lexer.Span) Value {
+	switch operator.Kind {
+	case lexer.EQUALS:
+		return equals(left, right)
+	case lexer.NOT_EQUALS:
+		return !equals(left, right)
+	}
+
+	// String concatenation: a string on either side of + joins both sides as text
+	if operator.Kind == lexer.PLUS {
+		_, leftString := left.(string)
+		_, rightString := right.(string)
+		if leftString || rightString {
+			return stringify(left) + stringify(right)
+		}
+	}
+
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			switch operator.Kind {
+			case lexer.LESS:
+				return l < r
+			case lexer.LESS_EQUALS:
+				return l <= r
+			case lexer.GREATER:
+				return l > r
+			case lexer.GREATER_EQUALS:
+				return l >= r
+			}
+		}
+	}
+
+	l, leftNumber := left.(float64)
+	r, rightNumber := right.(float64)
+	if !leftNumber || !rightNumber {
+		throw(span, "cannot apply %s to %s and %s", operator.Value, typeName(left), typeName(right))
+	}
+
+	switch operator.Kind {
+	case lexer.PLUS:
+		return l + r
+	case lexer.DASH:
+		return l - r
+	case lexer.STAR:
+		return l * r
+	case lexer.SLASH:
+		if r == 0 {
+			throw(span, "division by zero")
+		}
+		return l / r
+	case lexer.PERCENT:
+		if r == 0 {
+			throw(span, "division by zero")
+		}
+		return math.Mod(l, r)
+	case lexer.LESS:
+		return l < r
+	case lexer.LESS_EQUALS:
+		return l <= r
+	case lexer.GREATER:
+		return l > r
+	case lexer.GREATER_EQUALS:
+		return l >= r
+	default:
+		throw(operator.Span, "unsupported binary operator %s", operator.Value)
+		return nil
+	}
+}
+
+// Evaluates `target = value`, `target += value` and `target -= value`
+//
+// - The target's object is evaluated once, before the value, and `+=` and `-=` read and write the same place
+func (in *Interpreter) evalAssignment(n ast.AssignmentExpr, env *Environment) Value {
+	switch target := n.Assigne.(type) {
+	case ast.SymbolExpr:
+		b, exists := env.lookup(target.Value)
+		if !exists {
+			throw(target.Span(), "%s is not defined", target.Value)
+		}
+		value := in.assignedValue(n, env, func() Value { return b.value })
+		if b.constant {
+			throw(n.Span(), "cannot assign to constant %s", target.Value)
+		}
+		b.value = value
+		return value
+	case ast.MemberExpr:
+		object := in.eval(target.Member, env)
+		value := in.assignedValue(n, env, func() Value { return in.member(object, target.Property, target.Span()) })
+		switch o := object.(type) {
+		case *Instance:
+			if _, exists := o.Fields[target.Property]; !exists {
+				throw(target.Span(), "%s has no field %s", o.Class.Name, target.Property)
+			}
+			o.Fields[target.Property] = value
+		case *Object:
+			o.set(target.Property, value)
+		default:
+			throw(target.Span(), "cannot assign to a member of %s", typeName(object))
+		}
+		return value
+	case ast.ComputedExpr:
+		object := in.eval(target.Member, env)
+		property := in.eval(target.Property, env)
+		value := in.assignedValue(n, env, func() Value { return in.index(object, property, target.Span()) })
+		in.setIndex(object, property, value, target.Span())
+		return value
+	default:
+		throw(n.Assigne.Span(), "invalid assignment target")
+		return nil
+	}
+}
+
+// Value stored by an assignment: its right-hand side, or for `+=` and `-=` the target's current value combined with it
+func (in *Interpreter) assignedValue(n ast.AssignmentExpr, env *Environment, current func() Value) Value {
+	switch n.Operator.Kind {
+	case lexer.PLUS_EQUALS:
+		left := current()
+		return binary(lexer.NewTokenAt(lexer.PLUS, "+", n.Operator.Span), left, in.eval(n.Value, env), n.Span())
+	case lexer.MINUS_EQUALS:
+		left := current()
+		return binary(lexer.NewTokenAt(lexer.DASH, "-", n.Operator.Span), left, in.eval(n.Value, env), n.Span())
+	default:
+		return in.eval(n.Value, env)
+	}
+}
+
+// Calls a function, builtin or method with already evaluated arguments
+func (in *Interpreter) call(callee Value, args []Value, span lexer.Span) Value {
+	switch fn := callee.(type) {
+	case *Builtin:
+		result, err := fn.Fn(in, args)
+		if err != nil {
+			throw(span, "%s", err)
+		}
+		return result
+	case *Function:
+		if len(args) != len(fn.Parameters) {
+			throw(span, "%s expects %d arguments but received %d", fn.Name, len(fn.Parameters), len(args))
+		}
+		if in.callDepth >= maxCallDepth {
+			throw(span, "maximum call depth of %d exceeded", maxCallDepth)
+		}
+
+		scope := newEnvironment(fn.Closure)
+		for i, parameter := range fn.Parameters {
+			scope.declare(parameter.Name, args[i], false)
+		}
+
+		in.callDepth++
+		result := in.execBlock(fn.Body, scope)
+		in.callDepth--
+		return result.value
+	case *Class:
+		throw(span, "class %s must be instantiated with new", fn.Name)
+	default:
+		throw(span, "%s is not callable", typeName(callee))
+	}
+
+	return nil
+}
+
+// Reads `object.property`; methods come back bound to their instance
+func (in *Interpreter) member(object Value, property string, span lexer.Span) Value {
+	switch o := object.(type) {
+	case *Instance:
+		if value, exists := o.Fields[property]; exists {
+			return value
+		}
+		if method, exists := o.Class.Methods[property]; exists {
+			return bind(method, o)
+		}
+		throw(span, "%s has no member %s", o.Class.Name, property)
+	case *Module:
+		if value, exists := o.Exports[property]; exists {
+			return value
+		}
+		throw(span, "module %s does not export %s", o.Path, property)
+	case *Object:
+		if value, exists := o.Fields[property]; exists {
+			return value
+		}
+		throw(span, "object has no member %s", property)
+	case *Array:
+		switch property {
+		case "length":
+			return float64(len(o.Elements))
+		case "push":
+			return &Builtin{Name: "push", Fn: func(in *Interpreter, args []Value) (Value, error) {
+				o.Elements = append(o.Elements, args...)
+				return float64(len(o.Elements)), nil
+			}}
+		}
+		throw(span, "array has no member %s", property)
+	default:
+		throw(span, "%s has no member %s", typeName(object), property)
+	}
+
+	return nil
+}
+
+// Reads `object[property]`
+func (in *Interpreter) index(object Value, property Value, span lexer.Span) Value {
+	switch o := object.(type) {
+	case string:
+		i, ok := property.(float64)
+		runes := []rune(o)
+		if !ok || i != math.Trunc(i) || i < 0 || int(i) >= len(runes) {
+			throw(span, "string index %s out of range", stringify(property))
+		}
+		return string(runes[int(i)])
+	case *Array:
+		return o.Elements[arrayIndex(o, property, span)]
+	case *Object:
+		key, ok := property.(string)
+		if !ok {
+			throw(span, "object keys must be strings but found %s", typeName(property))
+		}
+		value, exists := o.Fields[key]
+		if !exists {
+			throw(span, "object has no member %s", key)
+		}
+		return value
+	default:
+		throw(span, "cannot index %s", typeName(object))
+		return nil
+	}
+}
+
+// Writes `object[property] = value`
+func (in *Interpreter) setIndex(object Value, property Value, value Value, span lexer.Span) {
+	switch o := object.(type) {
+	case *Array:
+		o.Elements[arrayIndex(o, property, span)] = value
+	case *Object:
+		key, ok := property.(string)
+		if !ok {
+			throw(span, "object keys must be strings but found %s", typeName(property))
+		}
+		o.set(key, value)
+	default:
+		throw(span, "cannot assign to an index of %s", typeName(object))
+	}
+}
+
+// Validates an array index and converts it to int
+func arrayIndex(array *Array, property Value, span lexer.Span) int {
+	i, ok := property.(float64)
+	if !ok || i != math.Trunc(i) || i < 0 || int(i) >= len(array.Elements) {
+		throw(span, "array index %s out of range [0, %d)", stringify(property), len(array.Elements))
+	}
+	return int(i)
+}
+
+// Evaluates an object literal; computed keys must evaluate to strings
+func (in *Interpreter) evalObject(n ast.ObjectLiteral, env *Environment) Value {
+	object := &Object{Fields: map[string]Value{}}
+
+	for _, property := range n.Properties {
+		key := property.Key
+		if property.ComputedKey != nil {
+			computed := in.eval(property.ComputedKey, env)
+			k, ok := computed.(string)
+			if !ok {
+				throw(property.ComputedKey.Span(), "object keys must be strings but found %s", typeName(computed))
+			}
+			key = k
+		}
+		object.set(key, in.eval(property.Value, env))
+	}
+
+	return object
+}
+
+// Returns a copy of method whose scope has `this` bound to instance
+func bind(method *Function, instance *Instance) *Function {
+	scope := newEnvironment(method.Closure)
+	scope.declare("this", instance, true)
+
+	bound := *method
+	bound.Closure = scope
+	return &bound
+}
+
+// Evaluates `new Class(args...)`: initializes the fields, then runs the constructor method if there is one
+func (in *Interpreter) evalNew(n ast.NewExpr, env *Environment) Value {
+	callee := in.eval(n.Instantiation.Method, env)
+	class, ok := callee.(*Class)
+	if !ok {
+		throw(n.Instantiation.Method.Span(), "%s is not a class", typeName(callee))
+	}
+
+	instance := &Instance{
+		Class:  class,
+		Fields: map[string]Value{},
+	}
+	for _, field := range class.Fields {
+		if field.AssignedValue != nil {
+			instance.Fields[field.VariableName] = in.eval(field.AssignedValue, class.Closure)
+		} else {
+			instance.Fields[field.VariableName] = zeroValue(field.ExplicitType)
+		}
+	}
+
+	args := in.evalArguments(n.Instantiation.Arguments, env)
+	if constructor, exists := class.Methods["constructor"]; exists {
+		in.call(bind(constructor, instance), args, n.Span())
+	} else if len(args) > 0 {
+		throw(n.Span(), "class %s has no constructor but received %d arguments", class.Name, len(args))
+	}
+
+	return instance
+}
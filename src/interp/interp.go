@@ -0,0 +1,118 @@
+package interp
+
+import (
+	"io"
+	"os"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/loader"
+)
+
+// Deepest allowed call nesting before a script is stopped with a runtime error
+const maxCallDepth = 10000
+
+// Interpreter is a tree-walking evaluator for parsed programs
+//
+// - Stdout receives everything printed by the script
+//
+// - globals holds builtins shared by every module
+//
+// - modules holds the exports of every module run by RunGraph, keyed by file path
+type Interpreter struct {
+	Stdout    io.Writer
+	globals   *Environment
+	modules   map[string]*Module
+	callDepth int
+}
+
+// New creates an interpreter that prints to stdout (os.Stdout if nil)
+func New(stdout io.Writer) *Interpreter {
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	in := &Interpreter{
+		Stdout:  stdout,
+		globals: newEnvironment(nil),
+	}
+	in.defineGlobals()
+	return in
+}
+
+// Run executes a single program in a fresh top-level scope.
+// A failure is returned as a *RuntimeError pointing at the offending node.
+func (in *Interpreter) Run(program ast.BlockStmt) error {
+	_, err := in.runModule(program)
+	return err
+}
+
+// RunGraph executes every module of a loaded program, imported modules first,
+// so import statements can bind the exports of modules that already ran
+func (in *Interpreter) RunGraph(graph *loader.Graph) error {
+	in.modules = map[string]*Module{}
+
+	for _, module := range graph.Order {
+		env, err := in.runModule(module.Program)
+		if err != nil {
+			return err
+		}
+		in.modules[module.Path] = &Module{
+			Path:    module.Path,
+			Exports: exports(module.Program, env),
+		}
+	}
+
+	return nil
+}
+
+// Executes the top-level statements of a program and returns its scope
+func (in *Interpreter) runModule(program ast.BlockStmt) (env *Environment, err error) {
+	env = newEnvironment(in.globals)
+
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeError, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r)
+			}
+			in.callDepth = 0
+			err = runtimeError
+		}
+	}()
+
+	for _, stmt := range program.Body {
+		in.exec(stmt, env)
+	}
+
+	return env, nil
+}
+
+// Collects the values of the exported top-level declarations of a module
+func exports(program ast.BlockStmt, env *Environment) map[string]Value {
+	exported := map[string]Value{}
+
+	for _, stmt := range program.Body {
+		var name string
+
+		switch decl := stmt.(type) {
+		case ast.VarDeclStmt:
+			if decl.IsExported {
+				name = decl.VariableName
+			}
+		case ast.FunctionDeclStmt:
+			if decl.IsExported {
+				name = decl.Name
+			}
+		case ast.ClassDeclStmt:
+			if decl.IsExported {
+				name = decl.Name
+			}
+		}
+
+		if b, exists := env.vars[name]; name != "" && exists {
+			exported[name] = b.value
+		}
+	}
+
+	return exported
+}
@@ -0,0 +1,229 @@
+package interp
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Parses and runs source. Returns what it printed and its runtime error.
+func run(t *testing.T, source string) (string, error) {
+	t.Helper()
+	tokens, lexErrors := lexer.TokenizeFile("test.lang", source)
+	program, parseErrors := parser.Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+		t.Fatalf("syntax errors in %q: %v", source, errors)
+	}
+
+	var out bytes.Buffer
+	err := New(&out).Run(program)
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "arithmetic",
+			source: "println(1 + 2 * 3, (1 + 2) * 3, 7 % 4, 10 / 4, -2 - -3);",
+			want:   "7 9 3 2.5 1\n",
+		},
+		{
+			name:   "comparison",
+			source: "println(1 < 2, 2 <= 1, \"a\" < \"b\", 1 == 1, \"1\" == 1, null != 0);",
+			want:   "true false true true false true\n",
+		},
+		{
+			name:   "logical operators short-circuit",
+			source: "fn loud(): boolean { println(\"called\"); return true; }\nprintln(false && loud(), true || loud(), !false);",
+			want:   "false true true\n",
+		},
+		{
+			name:   "string concatenation",
+			source: "let name = \"world\";\nprintln(\"hello \" + name + \"!\", 1 + \"2\", \"n=\" + 2.5, len(name));",
+			want:   "hello world! 12 n=2.5 5\n",
+		},
+		{
+			name:   "compound assignment",
+			source: "let n = 10;\nn += 5;\nn -= 3;\nlet s = \"a\";\ns += \"b\";\nprintln(n, s, n += 1);",
+			want:   "12 ab 13\n",
+		},
+		{
+			name:   "blocks shadow and restore",
+			source: "let x = 1;\nif true {\n  let x = 2;\n  x += 1;\n  println(x);\n}\nfor let x = 5; x < 6; x += 1 { println(x); }\nprintln(x);",
+			want:   "3\n5\n1\n",
+		},
+		{
+			name:   "closures see the scope they were created in",
+			source: "fn counter() {\n  let count = 0;\n  return fn () { count += 1; return count; };\n}\nlet a = counter();\nlet b = counter();\na();\nprintln(a(), b());",
+			want:   "2 1\n",
+		},
+		{
+			name:   "functions assign to enclosing variables",
+			source: "let total = 0;\nfn add(n: number) { total = total + n; }\nadd(2);\nadd(3);\nprintln(total);",
+			want:   "5\n",
+		},
+		{
+			name:   "recursion",
+			source: "fn fib(n: number): number {\n  if n < 2 { return n; }\n  return fib(n - 1) + fib(n - 2);\n}\nprintln(fib(15));",
+			want:   "610\n",
+		},
+		{
+			name:   "control flow",
+			source: "let s = \"\";\nfor let i = 0; i < 3; i += 1 { s += i; }\nlet j = 0;\nwhile j < 2 { j += 1; }\nforeach k in 3..5 { s += k; }\nforeach c in \"xy\" { s += c; }\nprintln(s, j);",
+			want:   "01234xy 2\n",
+		},
+		{
+			name:   "classes",
+			source: "class Point {\n  let x: number;\n  let y = 0;\n  fn constructor(x: number) { this.x = x; }\n  fn move(d: number) { this.x += d; this.y -= d; }\n}\nlet p = new Point(1);\np.move(2);\nprintln(p, p.x);",
+			want:   "Point { x: 3, y: -2 } 3\n",
+		},
+		{
+			name:   "zero values",
+			source: "let n: number;\nlet s: string;\nlet b: boolean;\nlet c: Point;\nprintln(n, s + \"|\", b, c);",
+			want:   "0 | false null\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := run(t, test.source)
+			if err != nil {
+				t.Fatalf("runtime error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("printed %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// A compound assignment evaluates its target's object and index once, before the value
+func TestCompoundAssignmentEvaluatesTargetOnce(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name: "member",
+			source: `class Box { let v = 10; }
+let boxes = 0;
+let first = new Box();
+let second = new Box();
+fn next(): Box {
+  boxes += 1;
+  if boxes == 1 { return first; }
+  return second;
+}
+fn five(): number { println("value after target", boxes); return 5; }
+next().v += five();
+next().v -= 1;
+println(first.v, second.v, boxes);`,
+			want: "value after target 1\n15 9 2\n",
+		},
+		{
+			name: "index",
+			source: `let n = 0;
+fn next(): number { n = n + 1; return n - 1; }
+let arr = [10, 20, 30];
+arr[next()] += 5;
+println(arr, n);`,
+			want: "[15, 20, 30] 1\n",
+		},
+		{
+			name: "object key",
+			source: `let calls = 0;
+fn key(): string { calls += 1; return "count"; }
+let counts = { count: 1 };
+counts[key()] += 2;
+counts[key()] -= 1;
+println(counts, calls);`,
+			want: "{ count: 2 } 2\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := run(t, test.source)
+			if err != nil {
+				t.Fatalf("runtime error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("printed %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // message of the error
+		at     string // source text the error points at
+	}{
+		{"const limit = 1;\nlimit = 2;", "cannot assign to constant limit", "limit = 2"},
+		{"const limit = 1;\nlimit += 2;", "cannot assign to constant limit", "limit += 2"},
+		{"fn f() {\n  const c = 1;\n  c -= 1;\n}\nf();", "cannot assign to constant c", "c -= 1"},
+		{"println(y);", "y is not defined", "y"},
+		{"if true { let y = 1; }\ny = 2;", "y is not defined", "y"},
+		{"let x = 1;\nlet x = 2;", "x is already declared in this scope", "let x = 2;"},
+		{"fn f(a: number) {}\nf(1, 2);", "f expects 1 arguments but received 2", "f(1, 2)"},
+		{"let s = \"a\" - 1;", "cannot apply - to string and number", "\"a\" - 1"},
+		{"let z = 1 / 0;", "division by zero", "1 / 0"},
+		{"let b = -\"a\";", "expected number but found string", "\"a\""},
+		{"if 1 { }", "condition must be a boolean but is number", "1"},
+		{"let n = 1;\nn();", "number is not callable", "n()"},
+		{"class C { let v = 1; }\nlet c = new C();\nc.w = 2;", "C has no field w", "c.w"},
+		{"class C { let v = 1; }\nC();", "class C must be instantiated with new", "C()"},
+		{"fn down(n: number): number { return down(n + 1); }\ndown(0);", "maximum call depth of 10000 exceeded", "down(n + 1)"},
+		{"return 1;", "return outside of function", "return 1;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			_, err := run(t, test.source)
+			var runtimeError *RuntimeError
+			if !errors.As(err, &runtimeError) {
+				t.Fatalf("error = %v, want a runtime error", err)
+			}
+			if runtimeError.Message != test.want {
+				t.Errorf("message %q, want %q", runtimeError.Message, test.want)
+			}
+			span := runtimeError.Span
+			if at := test.source[span.Start.Offset:span.End.Offset]; at != test.at {
+				t.Errorf("error points at %q, want %q", at, test.at)
+			}
+			if !strings.HasPrefix(err.Error(), "test.lang:") {
+				t.Errorf("error %q does not start with the file name", err)
+			}
+		})
+	}
+}
+
+// Output printed before a runtime error is kept, and the interpreter can run again afterwards
+func TestRunAfterError(t *testing.T) {
+	tokens, _ := lexer.Tokenize("fn down(n: number) { down(n + 1); }\nprintln(\"before\");\ndown(0);")
+	failing, _ := parser.Parse(tokens)
+	tokens, _ = lexer.Tokenize("fn depth(n: number): number { if n == 0 { return 0; } return 1 + depth(n - 1); }\nprintln(depth(9000));")
+	deep, _ := parser.Parse(tokens)
+
+	var out bytes.Buffer
+	in := New(&out)
+	if err := in.Run(failing); err == nil {
+		t.Fatal("expected the call depth to be exceeded")
+	}
+	if err := in.Run(deep); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if want := "before\n9000\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,192 @@
+package interp
+
+import (
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/loader"
+)
+
+// Result of executing a statement: whether a return statement ran, and its value
+type completion struct {
+	returned bool
+	value    Value
+}
+
+// Executes a single statement in env
+func (in *Interpreter) exec(stmt ast.Stmt, env *Environment) completion {
+	switch n := stmt.(type) {
+	case ast.ExpressionStmt:
+		in.eval(n.Expression, env)
+	case ast.BlockStmt:
+		return in.execBlock(n, newEnvironment(env))
+	case ast.VarDeclStmt:
+		in.execVarDecl(n, env)
+	case ast.FunctionDeclStmt:
+		fn := &Function{Name: n.Name, Parameters: n.Parameters, Body: n.Body, Closure: env}
+		in.declare(env, n.Name, fn, true, n.Span())
+	case ast.ClassDeclStmt:
+		in.execClassDecl(n, env)
+	case ast.ReturnStmt:
+		if in.callDepth == 0 {
+			throw(n.Span(), "return outside of function")
+		}
+		var value Value
+		if n.Value != nil {
+			value = in.eval(n.Value, env)
+		}
+		return completion{returned: true, value: value}
+	case ast.IfStmt:
+		if in.condition(n.Condition, env) {
+			return in.execBlock(n.Consequent, newEnvironment(env))
+		} else if n.Alternate != nil {
+			return in.exec(n.Alternate, env)
+		}
+	case ast.WhileStmt:
+		for in.condition(n.Condition, env) {
+			if c := in.execBlock(n.Body, newEnvironment(env)); c.returned {
+				return c
+			}
+		}
+	case ast.ForStmt:
+		return in.execFor(n, newEnvironment(env))
+	case ast.ForeachStmt:
+		return in.execForeach(n, env)
+	case ast.ImportStmt:
+		in.execImport(n, env)
+	case ast.BadStmt:
+		throw(n.Span(), "cannot execute a statement with syntax errors")
+	default:
+		throw(stmt.Span(), "unsupported statement %T", stmt)
+	}
+
+	return completion{}
+}
+
+// Executes the statements of a block in env, stopping at the first return
+func (in *Interpreter) execBlock(block ast.BlockStmt, env *Environment) completion {
+	for _, stmt := range block.Body {
+		if c := in.exec(stmt, env); c.returned {
+			return c
+		}
+	}
+	return completion{}
+}
+
+// Declares a variable, reporting a redeclaration in the same scope
+func (in *Interpreter) declare(env *Environment, name string, value Value, constant bool, span lexer.Span) {
+	if !env.declare(name, value, constant) {
+		throw(span, "%s is already declared in this scope", name)
+	}
+}
+
+func (in *Interpreter) execVarDecl(n ast.VarDeclStmt, env *Environment) {
+	var value Value
+	if n.AssignedValue != nil {
+		value = in.eval(n.AssignedValue, env)
+	} else {
+		value = zeroValue(n.ExplicitType)
+	}
+
+	in.declare(env, n.VariableName, value, n.IsConstant, n.Span())
+}
+
+func (in *Interpreter) execClassDecl(n ast.ClassDeclStmt, env *Environment) {
+	class := &Class{
+		Name:    n.Name,
+		Fields:  n.Fields,
+		Methods: map[string]*Function{},
+		Closure: env,
+	}
+
+	for _, method := range n.Methods {
+		class.Methods[method.Name] = &Function{
+			Name:       n.Name + "." + method.Name,
+			Parameters: method.Parameters,
+			Body:       method.Body,
+			Closure:    env,
+		}
+	}
+
+	in.declare(env, n.Name, class, true, n.Span())
+}
+
+func (in *Interpreter) execFor(n ast.ForStmt, env *Environment) completion {
+	if n.Init != nil {
+		in.exec(n.Init, env)
+	}
+
+	for n.Condition == nil || in.condition(n.Condition, env) {
+		if c := in.execBlock(n.Body, newEnvironment(env)); c.returned {
+			return c
+		}
+		if n.Post != nil {
+			in.eval(n.Post, env)
+		}
+	}
+
+	return completion{}
+}
+
+// Runs the body once per element; ranges are iterated without building a list
+func (in *Interpreter) execForeach(n ast.ForeachStmt, env *Environment) completion {
+	each := func(value Value) completion {
+		scope := newEnvironment(env)
+		scope.declare(n.Value, value, false)
+		return in.execBlock(n.Body, scope)
+	}
+
+	if r, ok := n.Iterable.(ast.RangeExpr); ok {
+		lower := in.number(r.Lower, env)
+		upper := in.number(r.Upper, env)
+		for i := lower; i < upper; i++ {
+			if c := each(i); c.returned {
+				return c
+			}
+		}
+		return completion{}
+	}
+
+	switch iterable := in.eval(n.Iterable, env).(type) {
+	case *Array:
+		for _, element := range iterable.Elements {
+			if c := each(element); c.returned {
+				return c
+			}
+		}
+	case string:
+		for _, ch := range iterable {
+			if c := each(string(ch)); c.returned {
+				return c
+			}
+		}
+	default:
+		throw(n.Iterable.Span(), "cannot iterate over %s", typeName(iterable))
+	}
+
+	return completion{}
+}
+
+// Binds the exports of an already executed module
+func (in *Interpreter) execImport(n ast.ImportStmt, env *Environment) {
+	if in.modules == nil {
+		throw(n.Span(), "imports are only supported when running a program loaded with its modules")
+	}
+
+	module, exists := in.modules[loader.Resolve(n.Span().File, n.From)]
+	if !exists {
+		throw(n.Span(), "module %q was not loaded", n.From)
+	}
+
+	if n.Alias != "" {
+		in.declare(env, n.Alias, module, true, n.Span())
+		return
+	}
+
+	for _, name := range n.Names {
+		value, exported := module.Exports[name]
+		if !exported {
+			throw(n.Span(), "module %q does not export %s", n.From, name)
+		}
+		in.declare(env, name, value, true, n.Span())
+	}
+}
@@ -0,0 +1,186 @@
+package interp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+)
+
+// Value is any runtime value.
+//
+// - number → float64, string → string, boolean → bool, null → nil
+//
+// - functions, classes, instances and modules use the pointer types below
+type Value any
+
+// User defined function or method, closing over the scope it was declared in
+type Function struct {
+	Name       string
+	Parameters []ast.Parameter
+	Body       ast.BlockStmt
+	Closure    *Environment
+}
+
+// Go function exposed to scripts, e.g. println
+type Builtin struct {
+	Name string
+	Fn   func(in *Interpreter, args []Value) (Value, error)
+}
+
+// Class declared with `class Name { ... }`
+type Class struct {
+	Name    string
+	Fields  []ast.VarDeclStmt
+	Methods map[string]*Function
+	Closure *Environment
+}
+
+// Object created with `new Class(...)`
+type Instance struct {
+	Class  *Class
+	Fields map[string]Value
+}
+
+// Array created with `[a, b, c]`
+type Array struct {
+	Elements []Value
+}
+
+// Object created with `{ key: value }`; Keys keeps the insertion order for printing
+type Object struct {
+	Keys   []string
+	Fields map[string]Value
+}
+
+// Sets a field, remembering the order in which keys were first added
+func (o *Object) set(key string, value Value) {
+	if _, exists := o.Fields[key]; !exists {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Fields[key] = value
+}
+
+// Module imported with `import alias from "path";`
+type Module struct {
+	Path    string
+	Exports map[string]Value
+}
+
+// Returns the name of the runtime type of a value, used in error messages
+func typeName(value Value) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case *Function, *Builtin:
+		return "function"
+	case *Class:
+		return "class"
+	case *Instance:
+		return v.Class.Name
+	case *Module:
+		return "module"
+	case *Array:
+		return "array"
+	case *Object:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// Returns the result of typeof for a value, which matches JavaScript's so compiled programs print the same
+func typeOf(value Value) string {
+	switch value.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case *Function, *Builtin, *Class:
+		return "function"
+	default:
+		return "object"
+	}
+}
+
+// Formats a value the way println shows it
+func stringify(value Value) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case *Function:
+		return "fn " + v.Name
+	case *Builtin:
+		return "fn " + v.Name
+	case *Class:
+		return "class " + v.Name
+	case *Instance:
+		fields := make([]string, 0, len(v.Class.Fields))
+		for _, field := range v.Class.Fields {
+			fields = append(fields, field.VariableName+": "+stringify(v.Fields[field.VariableName]))
+		}
+		return v.Class.Name + " { " + strings.Join(fields, ", ") + " }"
+	case *Module:
+		return "module " + v.Path
+	case *Array:
+		elements := make([]string, len(v.Elements))
+		for i, element := range v.Elements {
+			elements[i] = stringify(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Object:
+		fields := make([]string, len(v.Keys))
+		for i, key := range v.Keys {
+			fields[i] = key + ": " + stringify(v.Fields[key])
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	default:
+		return "unknown"
+	}
+}
+
+// Integral numbers print without a fraction, everything else in the shortest exact form
+func formatNumber(n float64) string {
+	if n == math.Trunc(n) && math.Abs(n) < 1e21 {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+// Value equality used by == and !=: primitives by value, everything else by identity
+func equals(left Value, right Value) bool {
+	return left == right
+}
+
+// Zero value for a declared type, used by `let x: T;`
+func zeroValue(t ast.Type) Value {
+	if symbol, ok := t.(ast.SymbolType); ok {
+		switch symbol.Name {
+		case "number":
+			return 0.0
+		case "string":
+			return ""
+		case "boolean":
+			return false
+		}
+	}
+	if _, ok := t.(ast.ArrayType); ok {
+		return &Array{}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package lexer
+
+import "fmt"
+
+// Severity tells how serious a diagnostic is
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+// String returns the lowercase name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("severity(%d)", s)
+	}
+}
+
+// DiagnosticCode is a stable identifier for a kind of diagnostic, e.g. "L0001"
+type DiagnosticCode string
+
+// Lexer diagnostic codes
+const (
+	CodeUnrecognizedCharacter DiagnosticCode = "L0001"
+	CodeReadError             DiagnosticCode = "L0002"
+	CodeInvalidEscape         DiagnosticCode = "L0003"
+	CodeUnterminatedString    DiagnosticCode = "L0004"
+	CodeInvalidNumber         DiagnosticCode = "L0005"
+	CodeUnterminatedComment   DiagnosticCode = "L0006"
+)
+
+// Diagnostic is a problem found in the source, reported instead of panicking
+//
+// - Expected lists the token kinds that would have been accepted, if the problem is an unexpected token
+//
+// - Found is the kind of the offending token and is only meaningful when Expected is not empty
+type Diagnostic struct {
+	Code     DiagnosticCode
+	Severity Severity
+	Message  string
+	Span     Span
+	Expected []TokenKind
+	Found    TokenKind
+}
+
+// Errorf creates an error diagnostic with a formatted message
+func Errorf(code DiagnosticCode, span Span, format string, args ...any) Diagnostic {
+	return Diagnostic{
+		Code:     code,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+		Span:     span,
+	}
+}
+
+// Error formats the diagnostic as file:line:column: severity[code]: message
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s[%s]: %s", d.Span, d.Severity, d.Code, d.Message)
+}
+
+// HasErrors reports whether any of the diagnostics is an error
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -1,172 +1,630 @@
 package lexer
 
 import (
-	"fmt"
-	"regexp"
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
 )
 
-// Regex handler function type
-type regexHandler func(lex *lexer, regex *regexp.Regexp)
-
-// Regex pattern struct
-type regexPattern struct {
-	regex   *regexp.Regexp
-	handler regexHandler
-}
-
-// Lexer struct
+// Scanner reads source from an io.Reader and produces tokens one at a time
 // Example:
 //
-// - createLexer("12 + 34")
-// - It goes through each pattern and tries to match the start of the string.
-//   - Matches 12 as a number → calls numberHandler → adds NUMBER token.
-//   - Matches whitespace → calls skipHandler → skips.
-//   - Matches + → calls defaultHandler(PLUS, "+") → adds PLUS token.
-//   - Matches 34 → again NUMBER.
-type lexer struct {
-	patterns []regexPattern
-	Tokens   []Token
-	source   string
-	pos      int
+// - NewScanner("", strings.NewReader("12 + 34"))
+// - Each call to Next looks at the byte under the cursor and picks the scanning function for it.
+//   - Sees 1 → scanNumber consumes 12 → returns NUMBER token.
+//   - Sees a space → skipWhitespace skips it.
+//   - Sees + → scanOperator picks the longest operator, + → returns PLUS token.
+//   - Sees 3 → again NUMBER, then EOF forever after.
+//
+// Only the bytes of the token being scanned are buffered, and every byte is looked at a
+// constant number of times, so scanning is linear in the size of the source.
+type Scanner struct {
+	Diagnostics []Diagnostic // problems found so far, unrecognized characters are skipped
+	syntax      *Syntax      // keywords and operators to recognize
+	reader      *bufio.Reader
+	file        string
+	window      []byte    // bytes read from reader but not consumed yet
+	chunk       []byte    // read buffer reused by fill
+	eof         bool      // reader is exhausted
+	queue       []Token   // ring buffer of scanned tokens not returned by Next yet
+	head        int       // index in queue of the next token
+	queued      int       // number of tokens in queue
+	templates   []int     // for each enclosing ${ interpolation, the number of { still open inside it
+	comments    []Comment // comments scanned since the last token
+	pos         int
+	line        int
+	column      int
 }
 
 // Tokenize the source string
-func Tokenize(source string) []Token {
-	lex := createLexer(source)
+//
+// - Unrecognized characters are skipped and reported as diagnostics, so the tokens are always usable
+func Tokenize(source string) ([]Token, []Diagnostic) {
+	return TokenizeFile("", source)
+}
 
-	// 10 + [5]
-	// Iterate while we sill have tokens
-	for !lex.at_eof() {
-		matched := false
+// TokenizeFile tokenizes the source string, recording file in every token span
+func TokenizeFile(file string, source string) ([]Token, []Diagnostic) {
+	return base_syntax.Tokenize(file, source)
+}
 
-		for _, pattern := range lex.patterns {
-			loc := pattern.regex.FindStringIndex(lex.remainder())
+// NewScanner creates a scanner reading source from r, recording file in every token span
+func NewScanner(file string, r io.Reader) *Scanner {
+	return &Scanner{
+		syntax: base_syntax,
+		reader: bufio.NewReader(r),
+		chunk:  make([]byte, 4096),
+		file:   file,
+		line:   1,
+		column: 1,
+	}
+}
 
-			if loc != nil && loc[0] == 0 {
-				pattern.handler(lex, pattern.regex)
-				matched = true
-				break
-			}
+// Next returns the next token and moves past it. At the end of the input it returns EOF, on every call.
+func (lex *Scanner) Next() Token {
+	token := lex.PeekN(0)
+	if token.Kind != EOF {
+		lex.head = (lex.head + 1) % len(lex.queue)
+		lex.queued--
+	}
+	return token
+}
+
+// Peek returns the next token without moving past it
+func (lex *Scanner) Peek() Token {
+	return lex.PeekN(0)
+}
+
+// PeekN returns the token n positions after the next one (PeekN(0) == Peek()) without moving
+func (lex *Scanner) PeekN(n int) Token {
+	for lex.queued <= n {
+		if last := lex.queuedToken(lex.queued - 1); lex.queued > 0 && last.Kind == EOF {
+			return last
+		}
+		lex.scan()
+	}
+	return lex.queuedToken(n)
+}
+
+// Returns the nth queued token
+func (lex *Scanner) queuedToken(n int) Token {
+	if n < 0 {
+		return Token{}
+	}
+	return lex.queue[(lex.head+n)%len(lex.queue)]
+}
+
+// Scans until at least one more token (possibly EOF) is queued
+func (lex *Scanner) scan() {
+	for queued := lex.queued; lex.queued == queued; {
+		if lex.at_eof() {
+			lex.push(lex.token(EOF, "EOF", 0))
+			return
+		}
+
+		ch := lex.peekByte(0)
+
+		switch {
+		case ch == 'r' && (lex.peekByte(1) == '"' || lex.peekByte(1) == '\''):
+			lex.scanRawString()
+		case isLetter(ch):
+			lex.scanSymbol()
+		case isDigit(ch):
+			lex.scanNumber()
+		case ch == '"' || ch == '\'':
+			lex.scanString()
+		case ch == '`':
+			lex.scanTemplate(TEMPLATE, TEMPLATE_HEAD)
+		case ch == '}' && len(lex.templates) > 0 && lex.templates[len(lex.templates)-1] == 0:
+			lex.templates = lex.templates[:len(lex.templates)-1]
+			lex.scanTemplate(TEMPLATE_TAIL, TEMPLATE_MIDDLE)
+		case ch == '/' && lex.peekByte(1) == '/':
+			lex.scanLineComment()
+		case ch == '/' && lex.peekByte(1) == '*':
+			lex.scanBlockComment()
+		case isWhitespace(ch):
+			lex.skipWhitespace()
+		default:
+			lex.scanOperator()
 		}
+	}
+}
+
+// Makes sure the window holds more than n bytes, unless the reader runs out first
+func (lex *Scanner) fill(n int) {
+	for len(lex.window) <= n && !lex.eof {
+		read, err := lex.reader.Read(lex.chunk)
+		lex.window = append(lex.window, lex.chunk[:read]...)
 
-		// You could extend this to print the location and other things
-		if !matched {
-			panic(fmt.Sprintf("Lexer::Error -> unrecognized token near %s\n", lex.remainder()))
+		if err == io.EOF {
+			lex.eof = true
+		} else if err != nil {
+			lex.eof = true
+			lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeReadError, lex.span(0), "cannot read source: %v", err))
 		}
 	}
+}
+
+// Reports whether the source has a byte n positions after the cursor
+func (lex *Scanner) has(n int) bool {
+	lex.fill(n)
+	return n < len(lex.window)
+}
+
+// Returns the byte n positions after the cursor, or 0 past the end of the source
+func (lex *Scanner) peekByte(n int) byte {
+	if lex.has(n) {
+		return lex.window[n]
+	}
+	return 0
+}
 
-	lex.push(NewToken(EOF, "EOF"))
-	return lex.Tokens
+// Returns the next n bytes of source, which must already be in the window
+func (lex *Scanner) text(n int) string {
+	return string(lex.window[:n])
 }
 
 // Advance the position by n characters
-// Finds a number match in the string, creates a token, and advances the position accordingly.
-func (lex *lexer) advanceN(n int) {
-	lex.pos += n
+// Consumes the bytes from the window and keeps line and column up to date.
+func (lex *Scanner) advanceN(n int) {
+	end := lex.positionAfter(n)
+	lex.pos, lex.line, lex.column = end.Offset, end.Line, end.Column
+	lex.window = lex.window[n:]
+}
+
+// Current position of the scanner in the source
+func (lex *Scanner) position() Position {
+	return Position{
+		Offset: lex.pos,
+		Line:   lex.line,
+		Column: lex.column,
+	}
+}
+
+// Position reached after consuming the next n bytes of source
+func (lex *Scanner) positionAfter(n int) Position {
+	end := lex.position()
+	for _, ch := range lex.window[:n] {
+		if ch == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	end.Offset += n
+	return end
+}
+
+// Span starting at the current position and covering the next n bytes of source
+func (lex *Scanner) span(n int) Span {
+	return lex.spanBetween(0, n)
+}
+
+// Span covering the bytes from start to end, counted from the cursor
+func (lex *Scanner) spanBetween(start int, end int) Span {
+	return Span{
+		File:  lex.file,
+		Start: lex.positionAfter(start),
+		End:   lex.positionAfter(end),
+	}
+}
+
+// Creates a token starting at the current position and spanning the next n bytes of source
+func (lex *Scanner) token(kind TokenKind, value string, n int) Token {
+	return NewTokenAt(kind, value, lex.span(n))
+}
+
+// Queue a token for Next, handing it the comments scanned since the previous token
+// The ring buffer only grows when the parser looks further ahead than before, so scanning
+// doesn't allocate per token.
+func (lex *Scanner) push(token Token) {
+	token.Comments, lex.comments = lex.comments, nil
+	if lex.queued == len(lex.queue) {
+		grown := make([]Token, 2*len(lex.queue)+4)
+		for i := 0; i < lex.queued; i++ {
+			grown[i] = lex.queuedToken(i)
+		}
+		lex.queue, lex.head = grown, 0
+	}
+	lex.queue[(lex.head+lex.queued)%len(lex.queue)] = token
+	lex.queued++
+}
+
+// Check if the whole source has been consumed
+func (lex *Scanner) at_eof() bool {
+	return !lex.has(0)
+}
+
+// Pushes a token of n bytes and moves past it
+func (lex *Scanner) emit(kind TokenKind, value string, n int) {
+	lex.push(lex.token(kind, value, n))
+	lex.advanceN(n)
+}
+
+// Reports the character under the cursor as unrecognized and skips it
+func (lex *Scanner) unrecognized() {
+	lex.fill(utf8.UTFMax - 1)
+	ch, size := utf8.DecodeRune(lex.window)
+	lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeUnrecognizedCharacter, lex.span(size), "unrecognized character %q", ch))
+	lex.advanceN(size)
+}
+
+// Whitespace: [ \t\n\f\r]+ skips over the run without generating a token.
+func (lex *Scanner) skipWhitespace() {
+	for isWhitespace(lex.peekByte(0)) {
+		lex.advanceN(1)
+	}
+}
+
+// Line comment: // up to (not including) the end of the line
+func (lex *Scanner) scanLineComment() {
+	n := 2
+	for lex.has(n) && lex.window[n] != '\n' {
+		n++
+	}
+	lex.comment(n)
+}
+
+// Block comment: /* up to and including the next */, may span lines and does not nest
+func (lex *Scanner) scanBlockComment() {
+	n := 2
+	for lex.has(n) && !(lex.window[n] == '*' && lex.peekByte(n+1) == '/') {
+		n++
+	}
+
+	if !lex.has(n) {
+		lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeUnterminatedComment, lex.span(2), "unterminated block comment"))
+		lex.comment(n)
+		return
+	}
+	lex.comment(n + 2)
+}
+
+// Keeps the next n bytes as a comment for the next token and moves past them
+func (lex *Scanner) comment(n int) {
+	lex.comments = append(lex.comments, Comment{Text: lex.text(n), Span: lex.span(n)})
+	lex.advanceN(n)
+}
+
+// Number: the token value is the literal as written, conversion is left to the parser
+//
+// - Decimal: [0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?
+//
+// - Hex, octal and binary integers: 0x1F, 0o17, 0b101
+//
+// - Underscores may separate digits anywhere in the literal: 1_000_000, 0xFF_FF
+//
+// The fraction is only taken when a digit follows the dot, so 1..5 is NUMBER DOT_DOT NUMBER.
+func (lex *Scanner) scanNumber() {
+	if lex.peekByte(0) == '0' {
+		if base, name := numberBase(lex.peekByte(1)); base != 0 {
+			lex.scanPrefixedNumber(base, name)
+			return
+		}
+	}
+
+	n := lex.countDigits(0)
+	if lex.peekByte(n) == '.' && isDigit(lex.peekByte(n+1)) {
+		n += 1 + lex.countDigits(n+1)
+	}
+
+	if e := lex.peekByte(n); e == 'e' || e == 'E' {
+		sign := 1
+		if s := lex.peekByte(n + 1); s == '+' || s == '-' {
+			sign = 2
+		}
+		if isDigit(lex.peekByte(n + sign)) {
+			n += sign + lex.countDigits(n+sign)
+		}
+	}
+
+	lex.checkSeparators(n, isDigit)
+	lex.emit(NUMBER, lex.text(n), n)
 }
 
-// Push a token to the tokens slice
-func (lex *lexer) push(token Token) {
-	lex.Tokens = append(lex.Tokens, token)
+// Integer with a 0x, 0o or 0b prefix. All hex digits are taken so a stray digit like
+// the 2 in 0b102 is reported instead of starting a new token.
+func (lex *Scanner) scanPrefixedNumber(base int, name string) {
+	n, digits := 2, 0
+	for ch := lex.peekByte(n); isHexDigit(ch) || ch == '_'; ch = lex.peekByte(n) {
+		if ch != '_' {
+			if int(hexValue(ch)) >= base {
+				lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidNumber, lex.spanBetween(n, n+1), "invalid digit %q in %s literal", ch, name))
+			}
+			digits++
+		}
+		n++
+	}
+
+	if digits == 0 {
+		lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidNumber, lex.span(n), "%s literal has no digits", name))
+	}
+
+	lex.checkSeparators(n, isHexDigit)
+	lex.emit(NUMBER, lex.text(n), n)
 }
 
-// Check if the current position is at the end of the source string
-func (lex *lexer) at_eof() bool {
-	return lex.pos >= len(lex.source)
+// Base and name of the number literal prefix 0<ch>, or 0 if ch doesn't start one
+func numberBase(ch byte) (int, string) {
+	switch ch {
+	case 'x', 'X':
+		return 16, "hexadecimal"
+	case 'o', 'O':
+		return 8, "octal"
+	case 'b', 'B':
+		return 2, "binary"
+	default:
+		return 0, ""
+	}
 }
 
-// Get the remainder of the source string
-func (lex *lexer) remainder() string {
-	return lex.source[lex.pos:]
+// Number of consecutive digits and underscores starting n bytes after the cursor
+func (lex *Scanner) countDigits(n int) int {
+	count := 0
+	for isDigit(lex.peekByte(n+count)) || lex.peekByte(n+count) == '_' {
+		count++
+	}
+	return count
 }
 
-// Default handler for regex patterns
-func defaultHandler(kind TokenKind, value string) regexHandler {
-	return func(lex *lexer, regex *regexp.Regexp) {
-		lex.advanceN(len(value))
-		lex.push(NewToken(kind, value))
+// Reports underscores in the next n bytes that don't sit between two digits
+func (lex *Scanner) checkSeparators(n int, digit func(byte) bool) {
+	for i := 0; i < n; i++ {
+		if lex.window[i] != '_' {
+			continue
+		}
+		if i == 0 || i+1 == n || !digit(lex.window[i-1]) || !digit(lex.window[i+1]) {
+			lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidNumber, lex.spanBetween(i, i+1), "_ must separate digits"))
+		}
 	}
 }
 
-// Skip handler for regex patterns
-// Used for whitespace: skips over the matched part without generating a token.
-func skipHandler(lex *lexer, regex *regexp.Regexp) {
-	match := regex.FindStringIndex(lex.remainder())
-	lex.advanceN(match[1])
+// String: "..." or '...' with escapes, may span lines. The token value is the decoded contents.
+func (lex *Scanner) scanString() {
+	value, n, _ := lex.scanQuoted(1, lex.peekByte(0), false, false)
+	lex.emit(STRING, value, n)
+}
+
+// Raw string: r"..." or r'...' where backslashes are kept as written, so it cannot contain its own quote
+func (lex *Scanner) scanRawString() {
+	value, n, _ := lex.scanQuoted(2, lex.peekByte(1), true, false)
+	lex.emit(STRING, value, n)
+}
+
+// Template string: `...` with ${expr} interpolations.
+// The cursor is on the opening ` or on the } closing an interpolation. The text up to the
+// closing ` is emitted as done, text up to the next ${ is emitted as open and scanning goes
+// back to normal tokens until the matching } (tracked in lex.templates).
+func (lex *Scanner) scanTemplate(done TokenKind, open TokenKind) {
+	value, n, end := lex.scanQuoted(1, '`', false, true)
+	if end == quoteInterpolation {
+		lex.emit(open, value, n)
+		lex.templates = append(lex.templates, 0)
+		return
+	}
+	lex.emit(done, value, n)
 }
 
-// Number handler for regex patterns
-func numberHandler(lex *lexer, regex *regexp.Regexp) {
-	match := regex.FindString(lex.remainder())
-	lex.push(NewToken(NUMBER, match))
-	lex.advanceN(len(match))
+// How a quoted literal ended
+type quoteEnd int
+
+const (
+	quoteClosed quoteEnd = iota
+	quoteInterpolation
+	quoteUnterminated
+)
+
+// Reads a quoted literal whose contents start `start` bytes after the cursor and end at closing.
+// Returns the decoded contents and the length of the literal, delimiters included.
+//
+// - Escapes are decoded unless raw, invalid ones are reported and kept as written
+//
+// - template literals also stop after ${
+//
+// - An unterminated literal is reported and runs to the end of the source
+func (lex *Scanner) scanQuoted(start int, closing byte, raw bool, template bool) (string, int, quoteEnd) {
+	var value strings.Builder
+	n := start
+
+	for {
+		if !lex.has(n) {
+			lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeUnterminatedString, lex.span(start), "unterminated string literal"))
+			return value.String(), n, quoteUnterminated
+		}
+
+		switch ch := lex.window[n]; {
+		case ch == closing:
+			return value.String(), n + 1, quoteClosed
+		case template && ch == '$' && lex.peekByte(n+1) == '{':
+			return value.String(), n + 2, quoteInterpolation
+		case ch == '\\' && !raw:
+			n += lex.scanEscape(n, &value)
+		default:
+			value.WriteByte(ch)
+			n++
+		}
+	}
 }
 
-// String handler for regex patterns
-func stringHandler(lex *lexer, regex *regexp.Regexp) {
-	match := regex.FindStringIndex(lex.remainder())
-	stringLiteral := lex.remainder()[match[0]+1 : match[1]-1]
-	lex.push(NewToken(STRING, stringLiteral))
-	lex.advanceN(len(stringLiteral) + 2)
+// simple_escapes maps the character after a backslash to the character it stands for
+var simple_escapes = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'\'': '\'',
+	'`':  '`',
+	'$':  '$',
 }
 
-// Symbol handler for regex patterns
-func symbolHandler(lex *lexer, regex *regexp.Regexp) {
-	value := regex.FindString(lex.remainder())
+// Decodes the escape sequence n bytes after the cursor into value and returns its length
+//
+// - \n \t \r \0 \\ \" \' \` \$
+//
+// - \u{X} with 1 to 6 hex digits naming a unicode code point
+func (lex *Scanner) scanEscape(n int, value *strings.Builder) int {
+	if !lex.has(n + 1) {
+		return 1 // the literal is unterminated, which is reported by the caller
+	}
+
+	if decoded, exists := simple_escapes[lex.window[n+1]]; exists {
+		value.WriteByte(decoded)
+		return 2
+	}
+
+	if lex.window[n+1] == 'u' {
+		return lex.scanUnicodeEscape(n, value)
+	}
 
-	if kind, exists := reserved_lu[value]; exists {
-		lex.push(NewToken(kind, value))
+	lex.fill(n + utf8.UTFMax)
+	ch, size := utf8.DecodeRune(lex.window[n+1:])
+	lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidEscape, lex.spanBetween(n, n+1+size), "invalid escape sequence \\%c", ch))
+	value.WriteRune(ch)
+	return 1 + size
+}
+
+// Decodes \u{X} n bytes after the cursor into value and returns its length
+func (lex *Scanner) scanUnicodeEscape(n int, value *strings.Builder) int {
+	length := 2
+	if lex.peekByte(n+length) != '{' {
+		lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidEscape, lex.spanBetween(n, n+length), "invalid unicode escape, expected \\u{...}"))
+		return length
+	}
+	length++
+
+	code, digits := rune(0), 0
+	for isHexDigit(lex.peekByte(n + length)) {
+		if digits < 7 {
+			code = code*16 + rune(hexValue(lex.peekByte(n+length)))
+		}
+		digits++
+		length++
+	}
+
+	if lex.peekByte(n+length) != '}' {
+		lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidEscape, lex.spanBetween(n, n+length), "unterminated unicode escape, expected }"))
+		return length
+	}
+	length++
+
+	if digits == 0 || digits > 6 || !utf8.ValidRune(code) {
+		lex.Diagnostics = append(lex.Diagnostics, Errorf(CodeInvalidEscape, lex.spanBetween(n, n+length), "invalid unicode code point %s", lex.window[n+3:n+length-1]))
+		return length
+	}
+
+	value.WriteRune(code)
+	return length
+}
+
+// Symbol: [a-zA-Z_][a-zA-Z0-9_]* is a keyword if reserved, an identifier otherwise
+func (lex *Scanner) scanSymbol() {
+	n := 1
+	for isLetter(lex.peekByte(n)) || isDigit(lex.peekByte(n)) {
+		n++
+	}
+
+	value := lex.text(n)
+	if kind, exists := lex.syntax.keywords[value]; exists {
+		lex.emit(kind, value, n)
 	} else {
-		lex.push(NewToken(IDENTIFIER, value))
-	}
-
-	lex.advanceN(len(value))
-}
-
-// Create a new lexer
-func createLexer(source string) *lexer {
-	return &lexer{
-		pos:    0,
-		source: source,
-		Tokens: make([]Token, 0),
-		patterns: []regexPattern{
-			{regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`), symbolHandler},
-			{regexp.MustCompile(`[0-9]+(\.[0-9]+)?`), numberHandler},
-			{regexp.MustCompile(`"[^"]*"`), stringHandler},
-			{regexp.MustCompile(`\/\/.*`), skipHandler},
-			{regexp.MustCompile(`\s+`), skipHandler},
-			{regexp.MustCompile(`\s+`), skipHandler},
-			{regexp.MustCompile(`\[`), defaultHandler(OPEN_BRACKET, "[")},
-			{regexp.MustCompile(`\]`), defaultHandler(CLOSE_BRACKET, "]")},
-			{regexp.MustCompile(`\{`), defaultHandler(OPEN_CURLY, "{")},
-			{regexp.MustCompile(`\}`), defaultHandler(CLOSE_CURLY, "}")},
-			{regexp.MustCompile(`\(`), defaultHandler(OPEN_PAREN, "(")},
-			{regexp.MustCompile(`\)`), defaultHandler(CLOSE_PAREN, ")")},
-			{regexp.MustCompile(`==`), defaultHandler(EQUALS, "==")},
-			{regexp.MustCompile(`!=`), defaultHandler(NOT_EQUALS, "!=")},
-			{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT, "=")},
-			{regexp.MustCompile(`!`), defaultHandler(NOT, "!")},
-			{regexp.MustCompile(`<=`), defaultHandler(LESS_EQUALS, "<=")},
-			{regexp.MustCompile(`<`), defaultHandler(LESS, "<")},
-			{regexp.MustCompile(`>=`), defaultHandler(GREATER_EQUALS, ">=")},
-			{regexp.MustCompile(`>`), defaultHandler(GREATER, ">")},
-			{regexp.MustCompile(`\|\|`), defaultHandler(OR, "||")},
-			{regexp.MustCompile(`&&`), defaultHandler(AND, "&&")},
-			{regexp.MustCompile(`\.\.`), defaultHandler(DOT_DOT, "..")},
-			{regexp.MustCompile(`\.`), defaultHandler(DOT, ".")},
-			{regexp.MustCompile(`;`), defaultHandler(SEMI_COLON, ";")},
-			{regexp.MustCompile(`:`), defaultHandler(COLON, ":")},
-			{regexp.MustCompile(`\?`), defaultHandler(QUESTION, "?")},
-			{regexp.MustCompile(`,`), defaultHandler(COMMA, ",")},
-			{regexp.MustCompile(`\+\+`), defaultHandler(PLUS_PLUS, "++")},
-			{regexp.MustCompile(`--`), defaultHandler(MINUS_MINUS, "--")},
-			{regexp.MustCompile(`\+=`), defaultHandler(PLUS_EQUALS, "+=")},
-			{regexp.MustCompile(`-=`), defaultHandler(MINUS_EQUALS, "-=")},
-			{regexp.MustCompile(`\+`), defaultHandler(PLUS, "+")},
-			{regexp.MustCompile(`-`), defaultHandler(DASH, "-")},
-			{regexp.MustCompile(`/`), defaultHandler(SLASH, "/")},
-			{regexp.MustCompile(`\*`), defaultHandler(STAR, "*")},
-			{regexp.MustCompile(`%`), defaultHandler(PERCENT, "%")},
-		},
+		lex.emit(IDENTIFIER, value, n)
+	}
+}
+
+// Operators and punctuation, preferring the longest form (== over =)
+func (lex *Scanner) scanOperator() {
+	for n := lex.syntax.longest; n > 0; n-- {
+		if !lex.has(n - 1) {
+			continue
+		}
+		if kind, exists := lex.syntax.operators[lex.text(n)]; exists {
+			lex.countBraces(kind)
+			lex.emit(kind, lex.text(n), n)
+			return
+		}
 	}
+
+	lex.unrecognized()
+}
+
+// Keeps track of the curly braces opened inside a template interpolation,
+// so that only the } matching the ${ resumes the template.
+func (lex *Scanner) countBraces(kind TokenKind) {
+	if len(lex.templates) == 0 {
+		return
+	}
+
+	switch kind {
+	case OPEN_CURLY:
+		lex.templates[len(lex.templates)-1]++
+	case CLOSE_CURLY:
+		lex.templates[len(lex.templates)-1]--
+	}
+}
+
+// operators_lu maps operator and punctuation spellings to their TokenKind
+var operators_lu = map[string]TokenKind{
+	"[":  OPEN_BRACKET,
+	"]":  CLOSE_BRACKET,
+	"{":  OPEN_CURLY,
+	"}":  CLOSE_CURLY,
+	"(":  OPEN_PAREN,
+	")":  CLOSE_PAREN,
+	"==": EQUALS,
+	"!=": NOT_EQUALS,
+	"=":  ASSIGNMENT,
+	"!":  NOT,
+	"<=": LESS_EQUALS,
+	"<":  LESS,
+	">=": GREATER_EQUALS,
+	">":  GREATER,
+	"||": OR,
+	"&&": AND,
+	"..": DOT_DOT,
+	".":  DOT,
+	";":  SEMI_COLON,
+	":":  COLON,
+	"?":  QUESTION,
+	",":  COMMA,
+	"++": PLUS_PLUS,
+	"--": MINUS_MINUS,
+	"+=": PLUS_EQUALS,
+	"-=": MINUS_EQUALS,
+	"+":  PLUS,
+	"-":  DASH,
+	"/":  SLASH,
+	"*":  STAR,
+	"%":  PERCENT,
+}
+
+func isLetter(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexDigit(ch byte) bool {
+	return isDigit(ch) || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
+
+// Value of a hex digit
+func hexValue(ch byte) byte {
+	switch {
+	case isDigit(ch):
+		return ch - '0'
+	case ch >= 'a' && ch <= 'f':
+		return ch - 'a' + 10
+	default:
+		return ch - 'A' + 10
+	}
+}
+
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\f' || ch == '\r'
 }
@@ -0,0 +1,395 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+)
+
+// The regex lexer the scanner replaced, kept as the reference the scanner must agree with on
+// the language it understood: no escapes, raw or template strings, block comments or number
+// prefixes, exponents and separators.
+type regexLexer struct {
+	patterns    []regexPattern
+	tokens      []Token
+	diagnostics []Diagnostic
+	file        string
+	source      string
+	pos         int
+	line        int
+	column      int
+}
+
+type regexPattern struct {
+	regex   *regexp.Regexp
+	handler func(lex *regexLexer, regex *regexp.Regexp)
+}
+
+func regexTokenize(file string, source string) ([]Token, []Diagnostic) {
+	lex := &regexLexer{file: file, source: source, line: 1, column: 1, patterns: regex_patterns}
+
+	for lex.pos < len(lex.source) {
+		matched := false
+		for _, pattern := range lex.patterns {
+			if loc := pattern.regex.FindStringIndex(lex.source[lex.pos:]); loc != nil && loc[0] == 0 {
+				pattern.handler(lex, pattern.regex)
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			ch, size := utf8.DecodeRuneInString(lex.source[lex.pos:])
+			lex.diagnostics = append(lex.diagnostics, Errorf(CodeUnrecognizedCharacter, lex.span(size), "unrecognized character %q", ch))
+			lex.advanceN(size)
+		}
+	}
+
+	lex.tokens = append(lex.tokens, NewTokenAt(EOF, "EOF", lex.span(0)))
+	return lex.tokens, lex.diagnostics
+}
+
+func (lex *regexLexer) positionAfter(n int) Position {
+	end := Position{Offset: lex.pos, Line: lex.line, Column: lex.column}
+	for _, ch := range []byte(lex.source[lex.pos : lex.pos+n]) {
+		if ch == '\n' {
+			end.Line++
+			end.Column = 1
+		} else {
+			end.Column++
+		}
+	}
+	end.Offset += n
+	return end
+}
+
+func (lex *regexLexer) span(n int) Span {
+	return Span{File: lex.file, Start: Position{Offset: lex.pos, Line: lex.line, Column: lex.column}, End: lex.positionAfter(n)}
+}
+
+func (lex *regexLexer) advanceN(n int) {
+	end := lex.positionAfter(n)
+	lex.pos, lex.line, lex.column = end.Offset, end.Line, end.Column
+}
+
+// Pushes a token of kind for the next n bytes, with value as its value
+func (lex *regexLexer) emit(kind TokenKind, value string, n int) {
+	lex.tokens = append(lex.tokens, NewTokenAt(kind, value, lex.span(n)))
+	lex.advanceN(n)
+}
+
+var regex_patterns = func() []regexPattern {
+	patterns := []regexPattern{
+		{regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`), func(lex *regexLexer, regex *regexp.Regexp) {
+			value := regex.FindString(lex.source[lex.pos:])
+			kind, exists := reserved_lu[value]
+			if !exists {
+				kind = IDENTIFIER
+			}
+			lex.emit(kind, value, len(value))
+		}},
+		{regexp.MustCompile(`[0-9]+(\.[0-9]+)?`), func(lex *regexLexer, regex *regexp.Regexp) {
+			value := regex.FindString(lex.source[lex.pos:])
+			lex.emit(NUMBER, value, len(value))
+		}},
+		{regexp.MustCompile(`"[^"]*"`), func(lex *regexLexer, regex *regexp.Regexp) {
+			value := regex.FindString(lex.source[lex.pos:])
+			lex.emit(STRING, value[1:len(value)-1], len(value))
+		}},
+		{regexp.MustCompile(`\/\/.*`), func(lex *regexLexer, regex *regexp.Regexp) {
+			lex.advanceN(len(regex.FindString(lex.source[lex.pos:])))
+		}},
+		{regexp.MustCompile(`\s+`), func(lex *regexLexer, regex *regexp.Regexp) {
+			lex.advanceN(len(regex.FindString(lex.source[lex.pos:])))
+		}},
+	}
+
+	// in the order the regex lexer tried them, longer operators first
+	for _, symbol := range []string{
+		"[", "]", "{", "}", "(", ")", "==", "!=", "=", "!", "<=", "<", ">=", ">", "||", "&&",
+		"..", ".", ";", ":", "?", ",", "++", "--", "+=", "-=", "+", "-", "/", "*", "%",
+	} {
+		symbol, kind := symbol, operators_lu[symbol]
+		patterns = append(patterns, regexPattern{regexp.MustCompile(regexp.QuoteMeta(symbol)), func(lex *regexLexer, regex *regexp.Regexp) {
+			lex.emit(kind, symbol, len(symbol))
+		}})
+	}
+	return patterns
+}()
+
+// Fragments of the language, including characters neither lexer accepts
+var equivalence_fragments = []string{
+	"let", "const", "fn", "if", "else", "while", "foreach", "in", "return", "class", "new",
+	"x", "foo_bar", "_tmp", "a1", "Name",
+	"0", "7", "42", "3.14", "10.0",
+	`""`, `"hello"`, `"a b"`, `"héllo"`,
+	"// comment\n", "//\n",
+	"[", "]", "{", "}", "(", ")", "==", "!=", "=", "!", "<=", "<", ">=", ">", "||", "&&",
+	"..", ".", ";", ":", "?", ",", "++", "--", "+=", "-=", "+", "-", "/", "*", "%",
+	"@", "#", "é",
+}
+
+// Random source built from fragments. Fragments are separated by whitespace wherever
+// gluing them would form a token only the scanner knows, such as 0x1F, 1e5 or /* */.
+func randomSource(rng *rand.Rand) string {
+	var source strings.Builder
+	previous := ""
+
+	for i := rng.Intn(40); i >= 0; i-- {
+		fragment := equivalence_fragments[rng.Intn(len(equivalence_fragments))]
+		if previous != "" && (rng.Intn(3) == 0 || !gluable(previous, fragment)) {
+			source.WriteString([]string{" ", "\n", "\t", "  ", "\r\n"}[rng.Intn(5)])
+		}
+		source.WriteString(fragment)
+		previous = fragment
+	}
+	return source.String()
+}
+
+// Reports whether next can follow previous without whitespace and both lexers still agree
+func gluable(previous string, next string) bool {
+	last, first := previous[len(previous)-1], next[0]
+	word := func(ch byte) bool { return isLetter(ch) || isDigit(ch) || ch >= 0x80 }
+	switch {
+	case word(last) && word(first):
+		return false
+	case last == '/' && (first == '/' || first == '*'):
+		return false
+	}
+	return true
+}
+
+func describe(tokens []Token, diagnostics []Diagnostic) string {
+	var out strings.Builder
+	for _, token := range tokens {
+		fmt.Fprintf(&out, "%s %q %v-%v\n", TokenKindString(token.Kind), token.Value, token.Span.Start, token.Span.End)
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(&out, "%s %s %v\n", diagnostic.Code, diagnostic.Message, diagnostic.Span)
+	}
+	return out.String()
+}
+
+// The scanner produces the same tokens, spans and diagnostics as the regex lexer it replaced
+func TestTokenizeMatchesRegexLexer(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sources := []string{
+		"",
+		"let x = 10;\nconst y: number = x + 3.5;",
+		"fn add(a: number, b: number) { return a+b; } // sum\n",
+		"foreach i in 0..10 { println(\"i\", i); }",
+		"if a<=b&&c!=d||!e { x+=1; y-=2; z++; w--; }",
+		"let é = 1; # @",
+	}
+	for i := 0; i < 5000; i++ {
+		sources = append(sources, randomSource(rng))
+	}
+
+	for _, source := range sources {
+		want := describe(regexTokenize("test.lang", source))
+		got := describe(TokenizeFile("test.lang", source))
+		if got != want {
+			t.Fatalf("tokens of %q differ\nscanner:\n%s\nregex lexer:\n%s", source, got, want)
+		}
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // kind and decoded value of every token before EOF
+		errors string // code and message of every diagnostic
+	}{
+		{`"a\tb\n\\ \"q\" \u{1F600}"`, "string \"a\\tb\\n\\\\ \\\"q\\\" 😀\"", ""},
+		{`'it\'s' "it's"`, `string "it's" string "it's"`, ""},
+		{`r"\d+\n" r'a\b'`, `string "\\d+\\n" string "a\\b"`, ""},
+		{"`${a}b${c}` `\\${}`", `template_head "" identifier "a" template_middle "b" identifier "c" template_tail "" template "${}"`, ""},
+		{"`x${ {} }y`", `template_head "x" open_curly "{" close_curly "}" template_tail "y"`, ""},
+		{`"bad \q"`, `string "bad q"`, `L0003 invalid escape sequence \q`},
+		{`"\u{110000} \u41 \u{41"`, `string " 41 "`, "L0003 invalid unicode code point 110000\nL0003 invalid unicode escape, expected \\u{...}\nL0003 unterminated unicode escape, expected }"},
+		{`"open`, `string "open"`, "L0004 unterminated string literal"},
+		{"`open ${x}", `template_head "open " identifier "x" template_tail ""`, "L0004 unterminated string literal"},
+	}
+
+	for _, test := range tests {
+		tokens, diagnostics := Tokenize(test.source)
+		var got, errors []string
+		for _, token := range tokens[:len(tokens)-1] {
+			got = append(got, fmt.Sprintf("%s %q", TokenKindString(token.Kind), token.Value))
+		}
+		for _, diagnostic := range diagnostics {
+			errors = append(errors, string(diagnostic.Code)+" "+diagnostic.Message)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("tokens of %s\n%s\nwant\n%s", test.source, strings.Join(got, " "), test.want)
+		}
+		if strings.Join(errors, "\n") != test.errors {
+			t.Errorf("diagnostics of %s\n%s\nwant\n%s", test.source, strings.Join(errors, "\n"), test.errors)
+		}
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // value of every token before EOF
+		errors string // code, message and column of every diagnostic
+	}{
+		{"1_000 0x1F 0o17 0B11 1.5e-3 2E+8 7e", "1_000 0x1F 0o17 0B11 1.5e-3 2E+8 7 e", ""},
+		{"1..5 1.x", "1 .. 5 1 . x", ""},
+		{"0b102", "0b102", "L0005 invalid digit '2' in binary literal 5"},
+		{"0o78", "0o78", "L0005 invalid digit '8' in octal literal 4"},
+		{"0x", "0x", "L0005 hexadecimal literal has no digits 1"},
+		{"1__0 0x_F 2_", "1__0 0x_F 2_", "L0005 _ must separate digits 2\nL0005 _ must separate digits 3\nL0005 _ must separate digits 8\nL0005 _ must separate digits 12"},
+	}
+
+	for _, test := range tests {
+		tokens, diagnostics := Tokenize(test.source)
+		var got, errors []string
+		for _, token := range tokens[:len(tokens)-1] {
+			got = append(got, token.Value)
+		}
+		for _, diagnostic := range diagnostics {
+			errors = append(errors, fmt.Sprintf("%s %s %d", diagnostic.Code, diagnostic.Message, diagnostic.Span.Start.Column))
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("tokens of %s: %s, want %s", test.source, strings.Join(got, " "), test.want)
+		}
+		if strings.Join(errors, "\n") != test.errors {
+			t.Errorf("diagnostics of %s\n%s\nwant\n%s", test.source, strings.Join(errors, "\n"), test.errors)
+		}
+	}
+}
+
+// Comments are kept on the token after them, and the EOF token holds the ones at the end
+func TestComments(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // every token with a comment, followed by its comments
+		errors string
+	}{
+		{"let x = 1; // one\n// two\nlet", `let: "// one" "// two"`, ""},
+		{"a /* inline */ + /* multi\nline */ b", `plus: "/* inline */" identifier: "/* multi\nline */"`, ""},
+		{"/* a */ /* b */ c // end", `identifier: "/* a */" "/* b */" eof: "// end"`, ""},
+		{"/* /* not nested */ */", `star: "/* /* not nested */"`, ""},
+		{"x /* open", `eof: "/* open"`, "L0006 unterminated block comment"},
+	}
+
+	for _, test := range tests {
+		tokens, diagnostics := Tokenize(test.source)
+		var got, errors []string
+		for _, token := range tokens {
+			if len(token.Comments) == 0 {
+				continue
+			}
+			got = append(got, TokenKindString(token.Kind)+":")
+			for _, comment := range token.Comments {
+				got = append(got, fmt.Sprintf("%q", comment.Text))
+				if span := comment.Span; test.source[span.Start.Offset:span.End.Offset] != comment.Text {
+					t.Errorf("comment %q spans %q", comment.Text, test.source[span.Start.Offset:span.End.Offset])
+				}
+			}
+		}
+		for _, diagnostic := range diagnostics {
+			errors = append(errors, string(diagnostic.Code)+" "+diagnostic.Message)
+		}
+		if strings.Join(got, " ") != test.want {
+			t.Errorf("comments of %q\n%s\nwant\n%s", test.source, strings.Join(got, " "), test.want)
+		}
+		if strings.Join(errors, "\n") != test.errors {
+			t.Errorf("diagnostics of %q\n%s\nwant\n%s", test.source, strings.Join(errors, "\n"), test.errors)
+		}
+	}
+}
+
+// Lookahead never consumes tokens, however far ahead it looks or however little the reader returns at once
+func TestScannerLookahead(t *testing.T) {
+	source := "let total = a + b * (c - 1); println(total);"
+	want, _ := Tokenize(source)
+	scanner := NewScanner("", iotest.OneByteReader(strings.NewReader(source)))
+
+	for i := 0; i < len(want); i++ {
+		for n := len(want) - i - 1; n >= 0; n-- {
+			if got := scanner.PeekN(n); !reflect.DeepEqual(got, want[i+n]) {
+				t.Fatalf("PeekN(%d) after %d tokens = %v, want %v", n, i, got, want[i+n])
+			}
+		}
+		if got := scanner.Peek(); !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("Peek() after %d tokens = %v, want %v", i, got, want[i])
+		}
+		if got := scanner.Next(); !reflect.DeepEqual(got, want[i]) {
+			t.Fatalf("Next() after %d tokens = %v, want %v", i, got, want[i])
+		}
+	}
+
+	// past the end, every call returns EOF
+	for n := 0; n < 3; n++ {
+		if scanner.PeekN(n).Kind != EOF || scanner.Next().Kind != EOF {
+			t.Fatalf("expected EOF after the last token")
+		}
+	}
+}
+
+// A failing reader ends the tokens with a diagnostic instead of an error return
+func TestScannerReadError(t *testing.T) {
+	reader := io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(errors.New("disk on fire")))
+	scanner := NewScanner("test.lang", reader)
+
+	var kinds []string
+	for token := scanner.Next(); ; token = scanner.Next() {
+		kinds = append(kinds, TokenKindString(token.Kind))
+		if token.Kind == EOF {
+			break
+		}
+	}
+	if got := strings.Join(kinds, " "); got != "let identifier eof" {
+		t.Errorf("tokens %s, want let identifier eof", got)
+	}
+	if len(scanner.Diagnostics) != 1 || scanner.Diagnostics[0].Code != CodeReadError || !strings.Contains(scanner.Diagnostics[0].Message, "disk on fire") {
+		t.Errorf("diagnostics %v, want one read error", scanner.Diagnostics)
+	}
+}
+
+// Source of the given size made of the examples, repeated
+func benchmarkSource(b *testing.B, size int) string {
+	files, err := filepath.Glob("../../examples/*.lang")
+	if err != nil || len(files) == 0 {
+		b.Fatalf("no examples to build the input from: %v", err)
+	}
+
+	var examples strings.Builder
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		examples.Write(content)
+		examples.WriteString("\n")
+	}
+
+	source := strings.Repeat(examples.String(), size/examples.Len()+1)
+	return source[:strings.LastIndexByte(source[:size], '\n')+1]
+}
+
+// MB/s stays the same from 1 to 16 MB, as tokenizing is linear in the size of the input
+func BenchmarkTokenize(b *testing.B) {
+	for _, megabytes := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("%dMB", megabytes), func(b *testing.B) {
+			source := benchmarkSource(b, megabytes<<20)
+			b.SetBytes(int64(len(source)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Tokenize(source)
+			}
+		})
+	}
+}
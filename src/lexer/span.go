@@ -0,0 +1,35 @@
+package lexer
+
+import "fmt"
+
+// Position is a single point in the source text
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number in bytes, starting at 1
+}
+
+// Span is the half-open source range [Start, End) covered by a token or AST node
+type Span struct {
+	File  string
+	Start Position
+	End   Position
+}
+
+// String formats the span as file:line:column
+func (s Span) String() string {
+	file := s.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d:%d", file, s.Start.Line, s.Start.Column)
+}
+
+// To returns a span starting at s and ending where end ends
+func (s Span) To(end Span) Span {
+	return Span{
+		File:  s.File,
+		Start: s.Start,
+		End:   end.End,
+	}
+}
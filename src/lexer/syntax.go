@@ -0,0 +1,83 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+)
+
+// Syntax is the set of keywords and operators a Scanner recognizes.
+// Languages built on top of this one start from NewSyntax and add their own words and symbols.
+//
+// - A Syntax must not be changed while scanners created from it are running
+type Syntax struct {
+	keywords  map[string]TokenKind
+	operators map[string]TokenKind
+	longest   int // length of the longest operator, the most bytes scanOperator looks at
+}
+
+// Keywords and operators of the language, used by NewScanner and Tokenize
+var base_syntax = NewSyntax()
+
+// NewSyntax returns a copy of the keywords and operators of the language
+func NewSyntax() *Syntax {
+	s := &Syntax{
+		keywords:  make(map[string]TokenKind, len(reserved_lu)),
+		operators: make(map[string]TokenKind, len(operators_lu)),
+	}
+
+	for word, kind := range reserved_lu {
+		s.AddKeyword(word, kind)
+	}
+	for symbol, kind := range operators_lu {
+		s.AddOperator(symbol, kind)
+	}
+	return s
+}
+
+// AddKeyword makes word scan as a token of the given kind instead of an identifier
+func (s *Syntax) AddKeyword(word string, kind TokenKind) {
+	s.keywords[word] = kind
+}
+
+// AddOperator makes symbol scan as a token of the given kind.
+// The longest operator matching the source wins, so "|>" can be added next to "||".
+//
+// - symbol must start with a character that doesn't begin anything else (a letter, digit, quote or `//`)
+func (s *Syntax) AddOperator(symbol string, kind TokenKind) {
+	s.operators[symbol] = kind
+	s.longest = max(s.longest, len(symbol))
+}
+
+// Keyword returns the kind of the keyword word, if it is one
+func (s *Syntax) Keyword(word string) (TokenKind, bool) {
+	kind, exists := s.keywords[word]
+	return kind, exists
+}
+
+// Operator returns the kind of the operator symbol, if it is one
+func (s *Syntax) Operator(symbol string) (TokenKind, bool) {
+	kind, exists := s.operators[symbol]
+	return kind, exists
+}
+
+// NewScanner creates a scanner for this syntax reading source from r, recording file in every token span
+func (s *Syntax) NewScanner(file string, r io.Reader) *Scanner {
+	scanner := NewScanner(file, r)
+	scanner.syntax = s
+	return scanner
+}
+
+// Tokenize tokenizes the source string with this syntax, recording file in every token span
+func (s *Syntax) Tokenize(file string, source string) ([]Token, []Diagnostic) {
+	scanner := s.NewScanner(file, strings.NewReader(source))
+	tokens := make([]Token, 0)
+
+	for {
+		token := scanner.Next()
+		tokens = append(tokens, token)
+
+		if token.Kind == EOF {
+			return tokens, scanner.Diagnostics
+		}
+	}
+}
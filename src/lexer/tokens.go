@@ -1,6 +1,10 @@
 package lexer
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
 // TokenKind represents the type of token using integer constants.
 type TokenKind int
@@ -14,6 +18,12 @@ const (
 	STRING
 	IDENTIFIER
 
+	// Template strings, split around their ${...} interpolations
+	TEMPLATE        // `text` without interpolations
+	TEMPLATE_HEAD   // `text${
+	TEMPLATE_MIDDLE // }text${
+	TEMPLATE_TAIL   // }text`
+
 	// Brackets and parentheses
 	OPEN_BRACKET  // [
 	CLOSE_BRACKET // ]
@@ -73,6 +83,9 @@ const (
 	EXPORT
 	TYPEOF
 	IN
+	RETURN
+
+	token_kind_count // number of token kinds, keep last
 )
 
 // reserved_lu maps keywords (like "let", "if") to their corresponding TokenKind.
@@ -92,18 +105,48 @@ var reserved_lu map[string]TokenKind = map[string]TokenKind{
 	"export":  EXPORT,
 	"typeof":  TYPEOF,
 	"in":      IN,
+	"return":  RETURN,
+}
+
+// IsKeyword reports whether name is reserved and cannot be used as an identifier
+func IsKeyword(name string) bool {
+	_, exists := reserved_lu[name]
+	return exists
 }
 
 // Token is a struct that represents a token
 type Token struct {
-	Kind  TokenKind
-	Value string
+	Kind     TokenKind
+	Value    string
+	Span     Span      // where the token appears in the source
+	Comments []Comment // comments between the previous token and this one, EOF holds the trailing ones
+}
+
+// Comment is a `// line` or `/* block */` comment, kept as trivia on the token that follows it
+type Comment struct {
+	Text string // the comment as written, markers included
+	Span Span
+}
+
+// IsBlock reports whether the comment is a /* block */ comment
+func (c Comment) IsBlock() bool {
+	return strings.HasPrefix(c.Text, "/*")
 }
 
 // NewToken creates a new token
 func NewToken(kind TokenKind, value string) Token {
 	return Token{
-		kind, value,
+		Kind:  kind,
+		Value: value,
+	}
+}
+
+// NewTokenAt creates a new token covering the given source span
+func NewTokenAt(kind TokenKind, value string, span Span) Token {
+	return Token{
+		Kind:  kind,
+		Value: value,
+		Span:  span,
 	}
 }
 
@@ -126,6 +169,78 @@ func (token Token) isOneOfMany(expectedTokens ...TokenKind) bool {
 	return false
 }
 
+// kinds_lu maps the names returned by TokenKindString back to token kinds
+var kinds_lu = func() map[string]TokenKind {
+	kinds := make(map[string]TokenKind, token_kind_count)
+	for kind := EOF; kind < token_kind_count; kind++ {
+		kinds[TokenKindString(kind)] = kind
+	}
+	return kinds
+}()
+
+// Token kinds added by NewTokenKind, numbered from token_kind_count
+var extension_kinds struct {
+	sync.RWMutex
+	names []string
+	kinds map[string]TokenKind
+}
+
+// Prefix of the names of extension kinds that are also the name of one of the language's kinds
+const extension_prefix = "extension:"
+
+// NewTokenKind allocates a token kind that is not part of the language, for the keywords and
+// operators of an extension. name is what TokenKindString returns for it, e.g. "unless".
+//
+// - Registering a name twice returns the same kind
+//
+// - Extension kinds have names of their own: one named like a kind of the language, e.g. "and",
+// is a different kind, and TokenKindString tells them apart by returning "extension:and"
+func NewTokenKind(name string) TokenKind {
+	extension_kinds.Lock()
+	defer extension_kinds.Unlock()
+
+	if kind, exists := extension_kinds.kinds[name]; exists {
+		return kind
+	}
+	if extension_kinds.kinds == nil {
+		extension_kinds.kinds = make(map[string]TokenKind)
+	}
+
+	kind := token_kind_count + TokenKind(len(extension_kinds.names))
+	if _, builtin := kinds_lu[name]; builtin {
+		extension_kinds.names = append(extension_kinds.names, extension_prefix+name)
+	} else {
+		extension_kinds.names = append(extension_kinds.names, name)
+	}
+	extension_kinds.kinds[name] = kind
+	return kind
+}
+
+// ParseTokenKind returns the token kind named name, the inverse of TokenKindString
+func ParseTokenKind(name string) (TokenKind, bool) {
+	if extension, prefixed := strings.CutPrefix(name, extension_prefix); prefixed {
+		name = extension
+	} else if kind, exists := kinds_lu[name]; exists {
+		return kind, true
+	}
+
+	extension_kinds.RLock()
+	defer extension_kinds.RUnlock()
+	kind, exists := extension_kinds.kinds[name]
+	return kind, exists
+}
+
+// Name of a kind allocated by NewTokenKind
+func extensionKindString(kind TokenKind) (string, bool) {
+	extension_kinds.RLock()
+	defer extension_kinds.RUnlock()
+
+	if i := int(kind - token_kind_count); i >= 0 && i < len(extension_kinds.names) {
+		return extension_kinds.names[i], true
+	}
+	return "", false
+}
+
 // TokenKindString returns the string representation of the token kind
 func TokenKindString(kind TokenKind) string {
 	switch kind {
@@ -137,6 +252,14 @@ func TokenKindString(kind TokenKind) string {
 		return "string"
 	case IDENTIFIER:
 		return "identifier"
+	case TEMPLATE:
+		return "template"
+	case TEMPLATE_HEAD:
+		return "template_head"
+	case TEMPLATE_MIDDLE:
+		return "template_middle"
+	case TEMPLATE_TAIL:
+		return "template_tail"
 	case OPEN_BRACKET:
 		return "open_bracket"
 	case CLOSE_BRACKET:
@@ -225,9 +348,16 @@ func TokenKindString(kind TokenKind) string {
 		return "while"
 	case EXPORT:
 		return "export"
+	case TYPEOF:
+		return "typeof"
 	case IN:
 		return "in"
+	case RETURN:
+		return "return"
 	default:
+		if name, exists := extensionKindString(kind); exists {
+			return name
+		}
 		return fmt.Sprintf("unknown(%d)", kind)
 	}
 }
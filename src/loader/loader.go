@@ -0,0 +1,153 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Extension added to import paths that don't have one
+const Extension = ".lang"
+
+// Loader diagnostic codes
+const (
+	CodeModuleNotFound lexer.DiagnosticCode = "M0001" // imported file could not be read
+	CodeImportCycle    lexer.DiagnosticCode = "M0002" // modules import each other
+)
+
+// Module is a parsed source file together with the modules it imports
+type Module struct {
+	Path    string // cleaned path of the source file
+	Program ast.BlockStmt
+	Imports []*Module // in the order of the import statements
+}
+
+// Graph of every module reachable from the entry module
+//
+// - Modules maps a cleaned file path to its module
+//
+// - Order lists every module after all the modules it imports
+type Graph struct {
+	Entry   *Module
+	Modules map[string]*Module
+	Order   []*Module
+}
+
+// Holds the loading state while walking the import graph
+// visiting: modules on the current import chain, used to detect cycles
+// read: returns the source of the file at a path, os.ReadFile outside of tests
+type loader struct {
+	graph       *Graph
+	visiting    []string
+	diagnostics []lexer.Diagnostic
+	read        func(path string) ([]byte, error)
+}
+
+// Load parses the entry file and, recursively, every file it imports
+//
+// - Import paths are resolved relative to the importing file
+//
+// - Missing files and import cycles are reported as diagnostics; the graph holds everything that could be loaded
+func Load(entry string) (*Graph, []lexer.Diagnostic) {
+	return loadWith(entry, os.ReadFile)
+}
+
+// LoadSource is like Load but takes the entry module's source instead of reading it.
+// Imports are still read from disk, relative to the directory of entry.
+func LoadSource(entry string, source string) (*Graph, []lexer.Diagnostic) {
+	path := filepath.Clean(entry)
+	return loadWith(path, func(file string) ([]byte, error) {
+		if file == path {
+			return []byte(source), nil
+		}
+		return os.ReadFile(file)
+	})
+}
+
+func loadWith(entry string, read func(path string) ([]byte, error)) (*Graph, []lexer.Diagnostic) {
+	l := &loader{
+		graph: &Graph{
+			Modules: map[string]*Module{},
+		},
+		read: read,
+	}
+
+	path := filepath.Clean(entry)
+	source, err := l.read(path)
+	if err != nil {
+		l.diagnostics = append(l.diagnostics, lexer.Errorf(CodeModuleNotFound, lexer.Span{File: path}, "cannot read module: %v", err))
+		return l.graph, l.diagnostics
+	}
+
+	l.graph.Entry = l.load(path, string(source))
+	return l.graph, l.diagnostics
+}
+
+// Resolve returns the file an import path refers to, relative to the importing file
+func Resolve(importer string, importPath string) string {
+	path := importPath
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(importer), path)
+	}
+	if filepath.Ext(path) == "" {
+		path += Extension
+	}
+	return filepath.Clean(path)
+}
+
+// Parses a module and loads its imports depth first
+func (l *loader) load(path string, source string) *Module {
+	tokens, lexErrors := lexer.TokenizeFile(path, source)
+	program, parseErrors := parser.Parse(tokens)
+	l.diagnostics = append(l.diagnostics, lexErrors...)
+	l.diagnostics = append(l.diagnostics, parseErrors...)
+
+	module := &Module{
+		Path:    path,
+		Program: program,
+	}
+	l.graph.Modules[path] = module
+	l.visiting = append(l.visiting, path)
+
+	for _, stmt := range program.Body {
+		importStmt, ok := stmt.(ast.ImportStmt)
+		if !ok {
+			continue
+		}
+
+		if imported := l.loadImport(importStmt, Resolve(path, importStmt.From)); imported != nil {
+			module.Imports = append(module.Imports, imported)
+		}
+	}
+
+	l.visiting = l.visiting[:len(l.visiting)-1]
+	l.graph.Order = append(l.graph.Order, module)
+	return module
+}
+
+// Loads the module imported by stmt, unless it is already loaded or would close a cycle
+func (l *loader) loadImport(stmt ast.ImportStmt, path string) *Module {
+	for i, visiting := range l.visiting {
+		if visiting == path {
+			cycle := append(append([]string{}, l.visiting[i:]...), path)
+			l.diagnostics = append(l.diagnostics, lexer.Errorf(CodeImportCycle, stmt.Span(), "import cycle: %s", strings.Join(cycle, " -> ")))
+			return nil
+		}
+	}
+
+	if module, exists := l.graph.Modules[path]; exists {
+		return module
+	}
+
+	source, err := l.read(path)
+	if err != nil {
+		l.diagnostics = append(l.diagnostics, lexer.Errorf(CodeModuleNotFound, stmt.Span(), "cannot import %q: %v", stmt.From, err))
+		return nil
+	}
+
+	return l.load(path, string(source))
+}
@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Loads entry from an in-memory file system mapping paths to sources
+func loadSources(entry string, sources map[string]string) (*Graph, []lexer.Diagnostic) {
+	return loadWith(entry, func(path string) ([]byte, error) {
+		source, exists := sources[filepath.ToSlash(path)]
+		if !exists {
+			return nil, fs.ErrNotExist
+		}
+		return []byte(source), nil
+	})
+}
+
+func modulePaths(modules []*Module) []string {
+	var paths []string
+	for _, module := range modules {
+		paths = append(paths, filepath.ToSlash(module.Path))
+	}
+	return paths
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		importer   string
+		importPath string
+		want       string
+	}{
+		{"main.lang", "./lib", "lib.lang"},
+		{"app/main.lang", "./lib/math", "app/lib/math.lang"},
+		{"app/lib/math.lang", "../util.lang", "app/util.lang"},
+		{"app/main.lang", "../../shared/strings", "../shared/strings.lang"},
+		{"app/main.lang", "/usr/lib/io", "/usr/lib/io.lang"},
+		{"app/main.lang", "./data.txt", "app/data.txt"},
+	}
+
+	for _, test := range tests {
+		if got := filepath.ToSlash(Resolve(filepath.FromSlash(test.importer), test.importPath)); got != test.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", test.importer, test.importPath, got, test.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	graph, diagnostics := loadSources("app/main.lang", map[string]string{
+		"app/main.lang":           "import { add } from \"./lib/math\";\nimport util from \"./util\";\nprintln(add(1, 2));",
+		"app/lib/math.lang":       "import { log } from \"../util.lang\";\nimport { sum } from \"./nested/sum\";\nexport fn add(a: number, b: number): number { return sum(a, b); }",
+		"app/lib/nested/sum.lang": "export fn sum(a: number, b: number): number { return a + b; }",
+		"app/util.lang":           "export fn log(message: string) { println(message); }",
+	})
+	if len(diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", diagnostics)
+	}
+
+	// every module comes after the modules it imports, and each is loaded once
+	if got, want := modulePaths(graph.Order), []string{"app/util.lang", "app/lib/nested/sum.lang", "app/lib/math.lang", "app/main.lang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order %q, want %q", got, want)
+	}
+	if len(graph.Modules) != 4 {
+		t.Errorf("%d modules, want 4", len(graph.Modules))
+	}
+
+	main := graph.Entry
+	if main != graph.Modules[filepath.FromSlash("app/main.lang")] {
+		t.Fatalf("entry %v is not the module app/main.lang", main)
+	}
+	if got, want := modulePaths(main.Imports), []string{"app/lib/math.lang", "app/util.lang"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("main imports %q, want %q", got, want)
+	}
+	if main.Imports[1] != main.Imports[0].Imports[0] {
+		t.Errorf("app/util.lang was loaded twice")
+	}
+	if len(main.Program.Body) != 3 {
+		t.Errorf("main has %d statements, want 3", len(main.Program.Body))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		want    []string // code and message of every diagnostic
+		loaded  []string // modules in the graph's order
+	}{
+		{
+			name:    "missing entry",
+			sources: map[string]string{},
+			want:    []string{"M0001 cannot read module: file does not exist"},
+		},
+		{
+			name: "missing import",
+			sources: map[string]string{
+				"main.lang": "import { a } from \"./a\";\nimport { b } from \"./lib/b\";",
+				"a.lang":    "export let a = 1;",
+			},
+			want:   []string{"M0001 cannot import \"./lib/b\": file does not exist"},
+			loaded: []string{"a.lang", "main.lang"},
+		},
+		{
+			name: "cycle",
+			sources: map[string]string{
+				"main.lang": "import { a } from \"./a\";",
+				"a.lang":    "import { b } from \"./b\";\nexport let a = 1;",
+				"b.lang":    "import { a } from \"./a\";\nexport let b = 2;",
+			},
+			want:   []string{"M0002 import cycle: a.lang -> b.lang -> a.lang"},
+			loaded: []string{"b.lang", "a.lang", "main.lang"},
+		},
+		{
+			name: "cycle through the entry",
+			sources: map[string]string{
+				"main.lang":  "import { a } from \"./dir/a\";",
+				"dir/a.lang": "import { b } from \"../main\";",
+			},
+			want:   []string{"M0002 import cycle: main.lang -> dir/a.lang -> main.lang"},
+			loaded: []string{"dir/a.lang", "main.lang"},
+		},
+		{
+			name: "self import",
+			sources: map[string]string{
+				"main.lang": "import main from \"./main.lang\";",
+			},
+			want:   []string{"M0002 import cycle: main.lang -> main.lang"},
+			loaded: []string{"main.lang"},
+		},
+		{
+			// an import that is no longer on the chain is not a cycle
+			name: "diamond",
+			sources: map[string]string{
+				"main.lang": "import { a } from \"./a\";\nimport { b } from \"./b\";",
+				"a.lang":    "import { c } from \"./c\";",
+				"b.lang":    "import { c } from \"./c\";",
+				"c.lang":    "export let c = 1;",
+			},
+			loaded: []string{"c.lang", "a.lang", "b.lang", "main.lang"},
+		},
+		{
+			name: "syntax error in an import",
+			sources: map[string]string{
+				"main.lang": "import { a } from \"./a\";",
+				"a.lang":    "export let a = ;",
+			},
+			want:   []string{"P0002 Expected expression but received semi_colon instead"},
+			loaded: []string{"a.lang", "main.lang"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graph, diagnostics := loadSources("main.lang", test.sources)
+
+			var got []string
+			for _, diagnostic := range diagnostics {
+				got = append(got, string(diagnostic.Code)+" "+filepath.ToSlash(diagnostic.Message))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+			if got := modulePaths(graph.Order); !reflect.DeepEqual(got, test.loaded) {
+				t.Errorf("loaded %q, want %q", got, test.loaded)
+			}
+		})
+	}
+}
+
+// Diagnostics about an import point at the import statement in the importing file
+func TestLoadErrorSpans(t *testing.T) {
+	_, diagnostics := loadSources("main.lang", map[string]string{
+		"main.lang": "let x = 1;\nimport { a } from \"./a\";",
+		"a.lang":    "import { b } from \"./b\";\nimport { x } from \"./main\";",
+	})
+	if len(diagnostics) != 2 {
+		t.Fatalf("diagnostics: %v", diagnostics)
+	}
+
+	missing, cycle := diagnostics[0].Span, diagnostics[1].Span
+	if missing.File != "a.lang" || missing.Start.Line != 1 || missing.Start.Column != 1 || missing.End.Offset != len(`import { b } from "./b";`) {
+		t.Errorf("missing module reported at %+v, want the first line of a.lang", missing)
+	}
+	if cycle.File != "a.lang" || cycle.Start.Line != 2 || cycle.Start.Column != 1 {
+		t.Errorf("cycle reported at %+v, want line 2 of a.lang", cycle)
+	}
+}
+
+// The entry's source is given, its imports come from disk
+func TestLoadSource(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lib.lang"), []byte("export let x = 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	graph, diagnostics := LoadSource(filepath.Join(dir, "main.lang"), "import { x } from \"./lib\";\nprintln(x);")
+	if len(diagnostics) > 0 {
+		t.Fatalf("diagnostics: %v", diagnostics)
+	}
+	if len(graph.Order) != 2 || graph.Order[0].Path != filepath.Join(dir, "lib.lang") || graph.Entry != graph.Order[1] {
+		t.Errorf("loaded %q, want lib.lang then main.lang", modulePaths(graph.Order))
+	}
+	if len(graph.Entry.Program.Body) != 2 {
+		t.Errorf("main has %d statements, want 2", len(graph.Entry.Program.Body))
+	}
+}
@@ -1,17 +1,55 @@
 package main
 
 import (
+	"fmt"
 	"os"
-
-	"github.com/sanity-io/litter"
-	"github.com/thutasann/go-parser/src/lexer"
-	"github.com/thutasann/go-parser/src/parser"
 )
 
+const usage = `usage: lang <command> [arguments]
+
+commands:
+  tokens [-json] [file]   print the tokens of a file
+  ast [-json] [file]      print the syntax tree of a file
+  check [file]            report syntax and type errors in a program and its imports
+  run [-vm] [file]        run a program, on the bytecode virtual machine with -vm
+  disasm [file]           print the bytecode of a program and its imports
+  js [-o file] [-map] [file]
+                          translate a module to JavaScript, with a source map
+  go [-o file] [file]     translate a program to a Go main package
+  fmt [-w] [-d] [files]   print files in canonical form
+
+A missing file or - reads standard input.
+
+exit status: 0 on success, 1 if the program has errors, 2 on bad usage
+`
+
+// Subcommands, each taking the arguments after its name and returning the exit status
+var commands = map[string]func(args []string) int{
+	"tokens": runTokens,
+	"ast":    runAst,
+	"check":  runCheck,
+	"run":    runRun,
+	"disasm": runDisasm,
+	"js":     runJS,
+	"go":     runGo,
+	"fmt":    runFmt,
+}
+
 func main() {
-	bytes, _ := os.ReadFile("./examples/04.lang")
-	tokens := lexer.Tokenize(string(bytes))
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-	ast := parser.Parse(tokens)
-	litter.Dump(ast)
+	switch name := os.Args[1]; name {
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		command, exists := commands[name]
+		if !exists {
+			fmt.Fprintf(os.Stderr, "lang: unknown command %q\n\n%s", name, usage)
+			os.Exit(2)
+		}
+		os.Exit(command(os.Args[2:]))
+	}
 }
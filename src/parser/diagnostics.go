@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Parser diagnostic codes
+const (
+	CodeUnexpectedToken    lexer.DiagnosticCode = "P0001" // a specific token kind was expected
+	CodeExpectedExpression lexer.DiagnosticCode = "P0002" // no nud handler for the current token
+	CodeExpectedOperator   lexer.DiagnosticCode = "P0003" // no led handler for the current token
+	CodeExpectedType       lexer.DiagnosticCode = "P0004" // no type nud handler for the current token
+	CodeMissingInitializer lexer.DiagnosticCode = "P0005" // var declaration without value or type
+	CodeConstWithoutValue  lexer.DiagnosticCode = "P0006" // const declaration without value
+	CodeInvalidClassMember lexer.DiagnosticCode = "P0007" // class body statement that is not a field or method
+	CodeNumberOutOfRange   lexer.DiagnosticCode = "P0008" // number literal too large for its type
+	CodeInexactNumber      lexer.DiagnosticCode = "P0009" // warning: integer literal that a float64 cannot hold exactly
+)
+
+// Panic value used to unwind the parser after an error has been reported.
+// It never escapes the package: parse_stmt recovers it and synchronizes.
+type bailout struct{}
+
+// Records a diagnostic without interrupting parsing.
+// A second error at the same position is almost always a follow-on error and is dropped.
+func (p *parser) report(diagnostic lexer.Diagnostic) {
+	if n := len(p.diagnostics); n > 0 && p.diagnostics[n-1].Span.Start == diagnostic.Span.Start {
+		return
+	}
+	p.diagnostics = append(p.diagnostics, diagnostic)
+}
+
+// Records an error diagnostic and unwinds to the nearest recovery point
+func (p *parser) fail(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	p.report(lexer.Errorf(code, span, format, args...))
+	panic(bailout{})
+}
+
+// Records an "unexpected token" error for the current token
+func (p *parser) reportUnexpected(code lexer.DiagnosticCode, message string, expected ...lexer.TokenKind) {
+	token := p.currentToken()
+	diagnostic := lexer.Errorf(code, token.Span, "%s", message)
+	diagnostic.Expected = expected
+	diagnostic.Found = token.Kind
+	p.report(diagnostic)
+}
+
+// Records an "unexpected token" error for the current token and unwinds
+func (p *parser) failUnexpected(code lexer.DiagnosticCode, message string, expected ...lexer.TokenKind) {
+	p.reportUnexpected(code, message, expected...)
+	panic(bailout{})
+}
+
+// Human readable description of a token for diagnostics
+func describeToken(token lexer.Token) string {
+	if token.Kind == lexer.IDENTIFIER || token.Kind == lexer.NUMBER || token.Kind == lexer.STRING {
+		return fmt.Sprintf("%s (%s)", lexer.TokenKindString(token.Kind), token.Value)
+	}
+	return lexer.TokenKindString(token.Kind)
+}
@@ -1,8 +1,10 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/thutasann/go-parser/src/ast"
 	"github.com/thutasann/go-parser/src/lexer"
@@ -10,22 +12,22 @@ import (
 
 func parse_expr(p *parser, bp binding_power) ast.Expr {
 	tokenKind := p.currentTokenKind()
-	nud_fn, exists := nud_lu[tokenKind]
+	nud_fn, exists := p.grammar.nud_lu[tokenKind]
 
 	if !exists {
-		panic(fmt.Sprintf("NUD HANDLER EXPECTED FOR TOKEN %s\n", lexer.TokenKindString(tokenKind)))
+		return parse_bad_expr(p)
 	}
 
 	left := nud_fn(p)
-	for bp_lu[p.currentTokenKind()] > bp {
+	for p.grammar.bp_lu[p.currentTokenKind()] > bp {
 		tokenKind = p.currentTokenKind()
-		led_fn, exists := led_lu[tokenKind]
+		led_fn, exists := p.grammar.led_lu[tokenKind]
 
 		if !exists {
-			panic(fmt.Sprintf("LED HANDLER EXPECTED FOR TOKEN %s\n", lexer.TokenKindString(tokenKind)))
+			p.failUnexpected(CodeExpectedOperator, fmt.Sprintf("Expected operator but received %s instead", describeToken(p.currentToken())))
 		}
 
-		left = led_fn(p, left, bp_lu[p.currentTokenKind()])
+		left = led_fn(p, left, p.grammar.bp_lu[p.currentTokenKind()])
 
 	}
 
@@ -35,20 +37,21 @@ func parse_expr(p *parser, bp binding_power) ast.Expr {
 func parse_primary_expr(p *parser) ast.Expr {
 	switch p.currentTokenKind() {
 	case lexer.NUMBER:
-		number, _ := strconv.ParseFloat(p.advance().Value, 64)
-		return ast.NumberExpr{
-			Value: number,
-		}
+		return parse_number_expr(p, p.advance())
 	case lexer.STRING:
+		token := p.advance()
 		return ast.StringExpr{
-			Value: p.advance().Value,
+			Value: token.Value,
+			Loc:   token.Span,
 		}
 	case lexer.IDENTIFIER:
+		token := p.advance()
 		return ast.SymbolExpr{
-			Value: p.advance().Value,
+			Value: token.Value,
+			Loc:   token.Span,
 		}
 	default:
-		panic(fmt.Sprintf("Cannot create primary_expression from %s\n", lexer.TokenKindString(p.currentTokenKind())))
+		return parse_bad_expr(p)
 	}
 }
 
@@ -60,16 +63,18 @@ func parse_binary_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 		Left:     left,
 		Operator: operatorToken,
 		Right:    right,
+		Loc:      left.Span().To(right.Span()),
 	}
 }
 
 func parse_prefix_expr(p *parser) ast.Expr {
 	operatorToken := p.advance()
-	rhs := parse_expr(p, default_bp)
+	rhs := parse_expr(p, unary)
 
 	return ast.PrefixExpr{
 		Operator:  operatorToken,
 		RightExpr: rhs,
+		Loc:       operatorToken.Span.To(rhs.Span()),
 	}
 }
 
@@ -88,5 +93,240 @@ func parse_assignment_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr
 		Operator: operatorToken,
 		Value:    rhs,
 		Assigne:  left,
+		Loc:      left.Span().To(rhs.Span()),
+	}
+}
+
+// Anonymous function expression `fn (a: T): R { ... }`
+func parse_fn_expr(p *parser) ast.Expr {
+	start := p.expect(lexer.FN)
+	parameters, returnType, body := parse_fn_params_and_body(p)
+
+	return ast.FunctionExpr{
+		Parameters: parameters,
+		ReturnType: returnType,
+		Body:       body,
+		Loc:        p.spanFrom(start),
+	}
+}
+
+// Call expression `callee(a, b)`
+func parse_call_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // eat the open paren
+	arguments := make([]ast.Expr, 0)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_PAREN {
+		arguments = append(arguments, parse_expr(p, default_bp))
+
+		if p.currentTokenKind() != lexer.CLOSE_PAREN {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	end := p.expect(lexer.CLOSE_PAREN)
+
+	return ast.CallExpr{
+		Method:    left,
+		Arguments: arguments,
+		Loc:       left.Span().To(end.Span),
+	}
+}
+
+// Member access `object.property`
+func parse_member_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // eat the dot
+	property := p.expectError(lexer.IDENTIFIER, "Expected property name after dot")
+
+	return ast.MemberExpr{
+		Member:   left,
+		Property: property.Value,
+		Loc:      left.Span().To(property.Span),
+	}
+}
+
+// Computed member access `object[property]`
+func parse_computed_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // eat the open bracket
+	property := parse_expr(p, default_bp)
+	end := p.expect(lexer.CLOSE_BRACKET)
+
+	return ast.ComputedExpr{
+		Member:   left,
+		Property: property,
+		Loc:      left.Span().To(end.Span),
+	}
+}
+
+// Instantiation `new Class(arguments...)`
+func parse_new_expr(p *parser) ast.Expr {
+	start := p.advance()
+	class := parse_expr(p, call) // stops in front of the argument list
+
+	if p.currentTokenKind() != lexer.OPEN_PAREN {
+		p.failUnexpected(CodeUnexpectedToken, fmt.Sprintf("Expected argument list in new expression but received %s instead", describeToken(p.currentToken())), lexer.OPEN_PAREN)
+	}
+
+	instantiation := parse_call_expr(p, class, call).(ast.CallExpr)
+
+	return ast.NewExpr{
+		Instantiation: instantiation,
+		Loc:           p.spanFrom(start),
+	}
+}
+
+// Converts a NUMBER token into a number literal
+//
+// - Integers must fit in 64 bits, and get a warning past 2^53 where float64 starts rounding them
+//
+// - Other literals must not overflow a float64
+//
+// - Malformed literals were already reported by the lexer and are left as 0
+func parse_number_expr(p *parser, token lexer.Token) ast.NumberExpr {
+	text := strings.ReplaceAll(token.Value, "_", "")
+	prefixed := len(text) > 1 && text[0] == '0' && strings.ContainsRune("xXoObB", rune(text[1]))
+	integer := prefixed || !strings.ContainsAny(text, ".eE")
+
+	var number float64
+	if integer {
+		base := 10
+		if prefixed {
+			base, text = 0, "0"+strings.ToLower(text[1:2])+text[2:]
+		}
+
+		value, err := strconv.ParseUint(text, base, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			p.report(lexer.Errorf(CodeNumberOutOfRange, token.Span, "integer literal %s overflows 64 bits", token.Value))
+		} else if value > 1<<53 {
+			warning := lexer.Errorf(CodeInexactNumber, token.Span, "integer literal %s cannot be represented exactly and will be rounded", token.Value)
+			warning.Severity = lexer.SeverityWarning
+			p.report(warning)
+		}
+		number = float64(value)
+	} else {
+		value, err := strconv.ParseFloat(text, 64)
+		if errors.Is(err, strconv.ErrRange) {
+			p.report(lexer.Errorf(CodeNumberOutOfRange, token.Span, "number literal %s overflows float64", token.Value))
+		} else if err == nil {
+			number = value
+		}
+	}
+
+	return ast.NumberExpr{
+		Value:   number,
+		Integer: integer,
+		Raw:     token.Value,
+		Loc:     token.Span,
 	}
 }
+
+// Template string `text ${expr} text`
+//
+// - The lexer splits it into TEMPLATE_HEAD, then expression tokens and TEMPLATE_MIDDLE pieces, then TEMPLATE_TAIL
+//
+// - A template without interpolations is a single TEMPLATE token
+func parse_template_expr(p *parser) ast.Expr {
+	start := p.advance()
+	strings := []string{start.Value}
+	expressions := make([]ast.Expr, 0)
+
+	for start.Kind == lexer.TEMPLATE_HEAD {
+		expressions = append(expressions, parse_expr(p, default_bp))
+
+		if p.currentTokenKind() != lexer.TEMPLATE_MIDDLE {
+			strings = append(strings, p.expectError(lexer.TEMPLATE_TAIL, fmt.Sprintf("Expected } to close template interpolation but received %s instead", describeToken(p.currentToken()))).Value)
+			break
+		}
+		strings = append(strings, p.advance().Value)
+	}
+
+	return ast.TemplateExpr{
+		Strings:     strings,
+		Expressions: expressions,
+		Loc:         p.spanFrom(start),
+	}
+}
+
+// Range `lower..upper`
+func parse_range_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
+	p.advance() // eat the dot dot
+	upper := parse_expr(p, bp)
+
+	return ast.RangeExpr{
+		Lower: left,
+		Upper: upper,
+		Loc:   left.Span().To(upper.Span()),
+	}
+}
+
+// Array literal `[a, b, c]`, a trailing comma is allowed
+func parse_array_literal_expr(p *parser) ast.Expr {
+	start := p.expect(lexer.OPEN_BRACKET)
+	contents := make([]ast.Expr, 0)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_BRACKET {
+		contents = append(contents, parse_expr(p, default_bp))
+
+		if p.currentTokenKind() != lexer.CLOSE_BRACKET {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_BRACKET)
+
+	return ast.ArrayLiteral{
+		Contents: contents,
+		Loc:      p.spanFrom(start),
+	}
+}
+
+// Object literal `{ key: value, "quoted": value, [computed]: value, shorthand }`, a trailing comma is allowed
+//
+// - Only reached in expression position: a `{` that starts a statement is a block
+func parse_object_literal_expr(p *parser) ast.Expr {
+	start := p.expect(lexer.OPEN_CURLY)
+	properties := make([]ast.ObjectProperty, 0)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		properties = append(properties, parse_object_property(p))
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.ObjectLiteral{
+		Properties: properties,
+		Loc:        p.spanFrom(start),
+	}
+}
+
+// Parses a single `key: value` entry of an object literal
+func parse_object_property(p *parser) ast.ObjectProperty {
+	start := p.currentToken()
+	property := ast.ObjectProperty{}
+
+	switch start.Kind {
+	case lexer.OPEN_BRACKET:
+		p.advance()
+		property.ComputedKey = parse_expr(p, default_bp)
+		p.expect(lexer.CLOSE_BRACKET)
+	case lexer.IDENTIFIER, lexer.STRING:
+		property.Key = p.advance().Value
+	default:
+		p.failUnexpected(CodeUnexpectedToken, fmt.Sprintf("Expected property key but received %s instead", describeToken(start)), lexer.IDENTIFIER, lexer.STRING, lexer.OPEN_BRACKET)
+	}
+
+	// `{ name }` is shorthand for `{ name: name }`
+	if start.Kind == lexer.IDENTIFIER && p.currentTokenKind() != lexer.COLON {
+		property.Shorthand = true
+		property.Value = ast.SymbolExpr{Value: start.Value, Loc: start.Span}
+	} else {
+		p.expect(lexer.COLON)
+		property.Value = parse_expr(p, default_bp)
+	}
+
+	property.Loc = p.spanFrom(start)
+	return property
+}
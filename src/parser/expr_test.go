@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+func TestCallsAndMembers(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "println(1, x + 2);",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [1, BinaryExpr{Left: x, Operator: +, Right: 2}]}}]`,
+			spans:  []string{"println(1, x + 2);", "println(1, x + 2)", "println", "1", "x + 2", "x", "2"},
+		},
+		{
+			source: "fs.readDir(this.directoryPath);",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: MemberExpr{Member: fs, Property: "readDir"}, Arguments: [MemberExpr{Member: this, Property: "directoryPath"}]}}]`,
+			spans:  []string{"fs.readDir(this.directoryPath);", "fs.readDir(this.directoryPath)", "fs.readDir", "fs", "this.directoryPath", "this"},
+		},
+		{
+			source: "a.b(c)[d + 1].e;",
+			want:   `[ExpressionStmt{Expression: MemberExpr{Member: ComputedExpr{Member: CallExpr{Method: MemberExpr{Member: a, Property: "b"}, Arguments: [c]}, Property: BinaryExpr{Left: d, Operator: +, Right: 1}}, Property: "e"}}]`,
+			spans:  []string{"a.b(c)[d + 1].e;", "a.b(c)[d + 1].e", "a.b(c)[d + 1]", "a.b(c)", "a.b", "a", "c", "d + 1", "d", "1"},
+		},
+		{
+			source: "f()(1)();",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: CallExpr{Method: CallExpr{Method: f}, Arguments: [1]}}}]`,
+			spans:  []string{"f()(1)();", "f()(1)()", "f()(1)", "f()", "f", "1"},
+		},
+		{
+			source: "items[0] * -obj.size;",
+			want:   `[ExpressionStmt{Expression: BinaryExpr{Left: ComputedExpr{Member: items, Property: 0}, Operator: *, Right: PrefixExpr{Operator: -, RightExpr: MemberExpr{Member: obj, Property: "size"}}}}]`,
+			spans:  []string{"items[0] * -obj.size;", "items[0] * -obj.size", "items[0]", "items", "0", "-obj.size", "obj.size", "obj"},
+		},
+		{
+			source: "counts[key] += 1;",
+			want:   `[ExpressionStmt{Expression: AssignmentExpr{Assigne: ComputedExpr{Member: counts, Property: key}, Operator: +=, Value: 1}}]`,
+			spans:  []string{"counts[key] += 1;", "counts[key] += 1", "counts[key]", "counts", "key", "1"},
+		},
+		{
+			source: "typeof obj.f() == \"number\";",
+			want:   `[ExpressionStmt{Expression: BinaryExpr{Left: PrefixExpr{Operator: typeof, RightExpr: CallExpr{Method: MemberExpr{Member: obj, Property: "f"}}}, Operator: ==, Right: "number"}}]`,
+			spans:  []string{"typeof obj.f() == \"number\";", "typeof obj.f() == \"number\"", "typeof obj.f()", "obj.f()", "obj.f", "obj", "\"number\""},
+		},
+	})
+}
+
+func TestArrayAndObjectLiterals(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "let recentFiles: []string = [];",
+			want:   `[VarDeclStmt{VariableName: "recentFiles", AssignedValue: ArrayLiteral{}, ExplicitType: ArrayType{Underlying: string}}]`,
+			spans:  []string{"let recentFiles: []string = [];", "[]", "[]string", "string"},
+		},
+		{
+			source: "let matrix = [[1, 2], [3,],];",
+			want:   `[VarDeclStmt{VariableName: "matrix", AssignedValue: ArrayLiteral{Contents: [ArrayLiteral{Contents: [1, 2]}, ArrayLiteral{Contents: [3]}]}}]`,
+			spans:  []string{"let matrix = [[1, 2], [3,],];", "[[1, 2], [3,],]", "[1, 2]", "1", "2", "[3,]", "3"},
+		},
+		{
+			source: "let o = { name: \"x\", size, [key + 1]: [], nested: { a: 1 }, };",
+			want:   `[VarDeclStmt{VariableName: "o", AssignedValue: ObjectLiteral{Properties: [ObjectProperty{Key: "name", Value: "x"}, ObjectProperty{Key: "size", Value: size, Shorthand: true}, ObjectProperty{ComputedKey: BinaryExpr{Left: key, Operator: +, Right: 1}, Value: ArrayLiteral{}}, ObjectProperty{Key: "nested", Value: ObjectLiteral{Properties: [ObjectProperty{Key: "a", Value: 1}]}}]}}]`,
+			spans:  []string{"let o = { name: \"x\", size, [key + 1]: [], nested: { a: 1 }, };", "{ name: \"x\", size, [key + 1]: [], nested: { a: 1 }, }", "name: \"x\"", "\"x\"", "size", "size", "[key + 1]: []", "key + 1", "key", "1", "[]", "nested: { a: 1 }", "{ a: 1 }", "a: 1", "1"},
+		},
+		{
+			source: "let empty = {};",
+			want:   `[VarDeclStmt{VariableName: "empty", AssignedValue: ObjectLiteral{}}]`,
+			spans:  []string{"let empty = {};", "{}"},
+		},
+		{
+			source: "println({ a: 1 }.a, [1, 2][0]);",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [MemberExpr{Member: ObjectLiteral{Properties: [ObjectProperty{Key: "a", Value: 1}]}, Property: "a"}, ComputedExpr{Member: ArrayLiteral{Contents: [1, 2]}, Property: 0}]}}]`,
+			spans:  []string{"println({ a: 1 }.a, [1, 2][0]);", "println({ a: 1 }.a, [1, 2][0])", "println", "{ a: 1 }.a", "{ a: 1 }", "a: 1", "1", "[1, 2][0]", "[1, 2]", "1", "2", "0"},
+		},
+		{
+			source: "{ let x = { a: 1 }; }",
+			want:   `[BlockStmt{Body: [VarDeclStmt{VariableName: "x", AssignedValue: ObjectLiteral{Properties: [ObjectProperty{Key: "a", Value: 1}]}}]}]`,
+			spans:  []string{"{ let x = { a: 1 }; }", "let x = { a: 1 };", "{ a: 1 }", "a: 1", "1"},
+		},
+		{
+			source: "if ok { println([x]); }",
+			want:   `[IfStmt{Condition: ok, Consequent: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [ArrayLiteral{Contents: [x]}]}}]}}]`,
+			spans:  []string{"if ok { println([x]); }", "ok", "{ println([x]); }", "println([x]);", "println([x])", "println", "[x]", "x"},
+		},
+	})
+}
+
+func TestStrings(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: `let s = "tab\tline\nquote\" \u{48}\u{e9}";`,
+			want:   `[VarDeclStmt{VariableName: "s", AssignedValue: "tab\tline\nquote\" Hé"}]`,
+			spans:  []string{`let s = "tab\tline\nquote\" \u{48}\u{e9}";`, `"tab\tline\nquote\" \u{48}\u{e9}"`},
+		},
+		{
+			source: `let q = 'it\'s "fine"';`,
+			want:   `[VarDeclStmt{VariableName: "q", AssignedValue: "it's \"fine\""}]`,
+			spans:  []string{`let q = 'it\'s "fine"';`, `'it\'s "fine"'`},
+		},
+		{
+			source: `let raw = r"C:\path\n" + r'\d+';`,
+			want:   `[VarDeclStmt{VariableName: "raw", AssignedValue: BinaryExpr{Left: "C:\\path\\n", Operator: +, Right: "\\d+"}}]`,
+			spans:  []string{`let raw = r"C:\path\n" + r'\d+';`, `r"C:\path\n" + r'\d+'`, `r"C:\path\n"`, `r'\d+'`},
+		},
+		{
+			source: "let t = `plain\\`text`;",
+			want:   "[VarDeclStmt{VariableName: \"t\", AssignedValue: TemplateExpr{Strings: [\"plain`text\"]}}]",
+			spans:  []string{"let t = `plain\\`text`;", "`plain\\`text`"},
+		},
+		{
+			source: "let t = `a ${x} b ${y + 1} c`;",
+			want:   `[VarDeclStmt{VariableName: "t", AssignedValue: TemplateExpr{Strings: ["a ", " b ", " c"], Expressions: [x, BinaryExpr{Left: y, Operator: +, Right: 1}]}}]`,
+			spans:  []string{"let t = `a ${x} b ${y + 1} c`;", "`a ${x} b ${y + 1} c`", "x", "y + 1", "y", "1"},
+		},
+		{
+			source: "let e = `${x}`;",
+			want:   `[VarDeclStmt{VariableName: "e", AssignedValue: TemplateExpr{Strings: ["", ""], Expressions: [x]}}]`,
+			spans:  []string{"let e = `${x}`;", "`${x}`", "x"},
+		},
+		{
+			source: "let n = `outer ${ `inner ${ { a: 1 }.a }` } done`;",
+			want:   `[VarDeclStmt{VariableName: "n", AssignedValue: TemplateExpr{Strings: ["outer ", " done"], Expressions: [TemplateExpr{Strings: ["inner ", ""], Expressions: [MemberExpr{Member: ObjectLiteral{Properties: [ObjectProperty{Key: "a", Value: 1}]}, Property: "a"}]}]}}]`,
+			spans:  []string{"let n = `outer ${ `inner ${ { a: 1 }.a }` } done`;", "`outer ${ `inner ${ { a: 1 }.a }` } done`", "`inner ${ { a: 1 }.a }`", "{ a: 1 }.a", "{ a: 1 }", "a: 1", "1"},
+		},
+		{
+			source: "println(`${a}${b}`.length);",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [MemberExpr{Member: TemplateExpr{Strings: ["", "", ""], Expressions: [a, b]}, Property: "length"}]}}]`,
+			spans:  []string{"println(`${a}${b}`.length);", "println(`${a}${b}`.length)", "println", "`${a}${b}`.length", "`${a}${b}`", "a", "b"},
+		},
+	})
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		source  string
+		value   float64
+		integer bool
+		errors  string // code and message of every diagnostic
+	}{
+		{source: "42", value: 42, integer: true},
+		{source: "3.25", value: 3.25},
+		{source: "1_000_000", value: 1e6, integer: true},
+		{source: "0xFF_FF", value: 65535, integer: true},
+		{source: "0XfF", value: 255, integer: true},
+		{source: "0o17", value: 15, integer: true},
+		{source: "0b1010", value: 10, integer: true},
+		{source: "1e3", value: 1000},
+		{source: "2.5E-2", value: 0.025},
+		{source: "1_0.0_1e+1_0", value: 10.01e10},
+		{source: "9007199254740992", value: 1 << 53, integer: true},
+		{source: "9007199254740993", value: 1 << 53, integer: true, errors: "P0009 integer literal 9007199254740993 cannot be represented exactly and will be rounded"},
+		{source: "0xFFFF_FFFF_FFFF_FFFF", value: 1 << 64, integer: true, errors: "P0009 integer literal 0xFFFF_FFFF_FFFF_FFFF cannot be represented exactly and will be rounded"},
+		{source: "18446744073709551616", value: 1 << 64, integer: true, errors: "P0008 integer literal 18446744073709551616 overflows 64 bits"},
+		{source: "1e400", value: 0, errors: "P0008 number literal 1e400 overflows float64"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			tokens, lexErrors := lexer.Tokenize(test.source + ";")
+			program, diagnostics := Parse(tokens)
+			var errors []string
+			for _, diagnostic := range append(lexErrors, diagnostics...) {
+				errors = append(errors, string(diagnostic.Code)+" "+diagnostic.Message)
+			}
+			if got := strings.Join(errors, "\n"); got != test.errors {
+				t.Errorf("diagnostics %q, want %q", got, test.errors)
+			}
+
+			number := program.Body[0].(ast.ExpressionStmt).Expression.(ast.NumberExpr)
+			if number.Value != test.value || number.Integer != test.integer || number.Raw != test.source {
+				t.Errorf("parsed %+v, want value %v, integer %v and raw %s", number, test.value, test.integer, test.source)
+			}
+			if number.Loc.Start.Offset != 0 || number.Loc.End.Offset != len(test.source) {
+				t.Errorf("span %v, want the whole literal", number.Loc)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Grammar is the language extended with keywords, operators and handlers of its own,
+// for domain specific languages built on top of it. Build it once, then parse with it
+// from any number of goroutines:
+//
+//	g := parser.NewGrammar()
+//	unless := g.Keyword("unless")
+//	g.Stmt(unless, func(p *parser.Parser) ast.Stmt {
+//		start := p.Expect(unless)
+//		condition := p.ParseExpr(parser.BindLowest)
+//		body := p.ParseBlock()
+//		return UnlessStmt{Condition: condition, Body: body, Loc: p.SpanFrom(start)}
+//	})
+//	program, diagnostics := g.ParseScanner(g.Syntax().NewScanner(file, reader))
+//
+// - Handlers registered for a token kind the language already handles replace the built-in ones
+//
+// - Custom nodes embed ast.CustomStmt, ast.CustomExpr or ast.CustomType
+type Grammar struct {
+	rules  *grammar
+	syntax *lexer.Syntax
+}
+
+// NewGrammar returns a grammar with the keywords, operators and handlers of the language, ready to be extended
+func NewGrammar() *Grammar {
+	return &Grammar{
+		rules:  newGrammar(),
+		syntax: lexer.NewSyntax(),
+	}
+}
+
+// Parser is the state handed to the handlers of a Grammar.
+// Handlers move through tokens with Current, Advance and Expect, and parse the parts of
+// their construct with ParseExpr, ParseStmt, ParseBlock and ParseType.
+type Parser = parser
+
+// BindingPower is how tightly an operator holds on to its operands
+type BindingPower = binding_power
+
+// Binding powers of the language's operators, from loosest to tightest
+const (
+	BindLowest         = default_bp
+	BindAssignment     = assignment     // =, +=, -=
+	BindLogical        = logical        // &&, ||, ..
+	BindRelational     = relational     // ==, !=, <, >, <=, >=
+	BindAdditive       = additive       // +, -
+	BindMultiplicative = multiplicative // *, /, %
+	BindUnary          = unary          // -, !
+	BindCall           = call           // ()
+	BindMember         = member         // ., []
+)
+
+// StmtHandler parses a statement starting at the current token
+type StmtHandler func(p *Parser) ast.Stmt
+
+// NudHandler parses an expression starting at the current token: a literal or a prefix operator
+type NudHandler func(p *Parser) ast.Expr
+
+// LedHandler parses an infix or postfix operator at the current token, applied to left
+type LedHandler func(p *Parser, left ast.Expr, bp BindingPower) ast.Expr
+
+// TypeNudHandler parses a type starting at the current token
+type TypeNudHandler func(p *Parser) ast.Type
+
+// TypeLedHandler parses a type operator at the current token, applied to left
+type TypeLedHandler func(p *Parser, left ast.Type, bp BindingPower) ast.Type
+
+// Syntax returns the keywords and operators the grammar's tokens must be scanned with
+func (g *Grammar) Syntax() *lexer.Syntax {
+	return g.syntax
+}
+
+// Keyword reserves word and returns its token kind, allocating a new one unless word is already a keyword
+func (g *Grammar) Keyword(word string) lexer.TokenKind {
+	if kind, exists := g.syntax.Keyword(word); exists {
+		return kind
+	}
+
+	kind := lexer.NewTokenKind(word)
+	g.syntax.AddKeyword(word, kind)
+	return kind
+}
+
+// Operator adds symbol to the operators and returns its token kind, allocating a new one unless symbol is already an operator
+func (g *Grammar) Operator(symbol string) lexer.TokenKind {
+	if kind, exists := g.syntax.Operator(symbol); exists {
+		return kind
+	}
+
+	kind := lexer.NewTokenKind(symbol)
+	g.syntax.AddOperator(symbol, kind)
+	return kind
+}
+
+// Stmt registers the handler of statements starting with kind.
+// Error recovery stops in front of kind, like it does in front of the language's statement keywords.
+func (g *Grammar) Stmt(kind lexer.TokenKind, handler StmtHandler) {
+	g.rules.stmt(kind, stmt_handler(handler))
+	g.rules.boundary_lu[kind] = true
+}
+
+// Nud registers the handler of expressions starting with kind
+func (g *Grammar) Nud(kind lexer.TokenKind, handler NudHandler) {
+	g.rules.nud(kind, nud_handler(handler))
+}
+
+// Led registers the handler of kind used as an infix or postfix operator with binding power bp
+func (g *Grammar) Led(kind lexer.TokenKind, bp BindingPower, handler LedHandler) {
+	g.rules.led(kind, bp, led_handler(handler))
+}
+
+// TypeNud registers the handler of types starting with kind
+func (g *Grammar) TypeNud(kind lexer.TokenKind, handler TypeNudHandler) {
+	g.rules.type_nud(kind, type_nud_handler(handler))
+}
+
+// TypeLed registers the handler of kind used as a type operator with binding power bp
+func (g *Grammar) TypeLed(kind lexer.TokenKind, bp BindingPower, handler TypeLedHandler) {
+	g.rules.type_led(kind, bp, type_led_handler(handler))
+}
+
+// Parse is like the package's Parse, with this grammar.
+// tokens must come from the grammar's Syntax for the custom keywords and operators to be recognized.
+func (g *Grammar) Parse(tokens []lexer.Token) (ast.BlockStmt, []lexer.Diagnostic) {
+	p := createParser(tokens)
+	p.grammar = g.rules
+	return parse_program(p)
+}
+
+// ParseScanner is like the package's ParseScanner, with this grammar.
+// scanner must be created with the grammar's Syntax for the custom keywords and operators to be recognized.
+func (g *Grammar) ParseScanner(scanner *lexer.Scanner) (ast.BlockStmt, []lexer.Diagnostic) {
+	p := createStreamParser(scanner)
+	p.grammar = g.rules
+	program, diagnostics := parse_program(p)
+	return program, append(scanner.Diagnostics, diagnostics...)
+}
+
+// Current returns the token at the current position without advancing
+func (p *Parser) Current() lexer.Token {
+	return p.currentToken()
+}
+
+// Peek returns the token after the current one without advancing
+func (p *Parser) Peek() lexer.Token {
+	return p.peekToken()
+}
+
+// Advance moves to the next token and returns the current one
+func (p *Parser) Advance() lexer.Token {
+	return p.advance()
+}
+
+// Expect consumes and returns the current token if it has the given kind.
+// Otherwise it reports an error and the statement being parsed becomes an ast.BadStmt.
+func (p *Parser) Expect(kind lexer.TokenKind) lexer.Token {
+	return p.expect(kind)
+}
+
+// SpanFrom returns the span from start up to the most recently consumed token
+func (p *Parser) SpanFrom(start lexer.Token) lexer.Span {
+	return p.spanFrom(start)
+}
+
+// ParseExpr parses an expression whose operators bind tighter than bp
+func (p *Parser) ParseExpr(bp BindingPower) ast.Expr {
+	return parse_expr(p, bp)
+}
+
+// ParseStmt parses a statement, recovering from syntax errors inside it
+func (p *Parser) ParseStmt() ast.Stmt {
+	return parse_stmt(p)
+}
+
+// ParseBlock parses a block `{ ... }`
+func (p *Parser) ParseBlock() ast.BlockStmt {
+	return parse_block_stmt(p)
+}
+
+// ParseType parses a type whose operators bind tighter than bp
+func (p *Parser) ParseType(bp BindingPower) ast.Type {
+	return parse_type(p, bp)
+}
+
+// Report records a diagnostic without interrupting parsing
+func (p *Parser) Report(diagnostic lexer.Diagnostic) {
+	p.report(diagnostic)
+}
+
+// Fail records an error and abandons the statement being parsed, which becomes an ast.BadStmt
+func (p *Parser) Fail(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	p.fail(code, span, format, args...)
+}
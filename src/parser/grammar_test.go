@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Keywords named like one of the language's token kinds are kinds of their own
+func TestGrammarKeywordNamedLikeBuiltinKind(t *testing.T) {
+	for _, word := range []string{"and", "or", "not", "string", "number", "dot", "less"} {
+		t.Run(word, func(t *testing.T) {
+			g := NewGrammar()
+			kind := g.Keyword(word)
+
+			if builtin, _ := lexer.ParseTokenKind(word); kind == builtin {
+				t.Fatalf("Keyword(%q) returned the language's %s kind", word, word)
+			}
+			if got, want := lexer.TokenKindString(kind), "extension:"+word; got != want {
+				t.Errorf("TokenKindString = %q, want %q", got, want)
+			}
+			if parsed, exists := lexer.ParseTokenKind(lexer.TokenKindString(kind)); !exists || parsed != kind {
+				t.Errorf("ParseTokenKind(%q) = %v, %v, want %v", lexer.TokenKindString(kind), parsed, exists, kind)
+			}
+			if again := g.Keyword(word); again != kind {
+				t.Errorf("second Keyword(%q) = %v, want %v", word, again, kind)
+			}
+
+			// `word expr;` is an expression statement
+			g.Stmt(kind, func(p *Parser) ast.Stmt {
+				start := p.Expect(kind)
+				expression := p.ParseExpr(BindLowest)
+				p.Expect(lexer.SEMI_COLON)
+				return ast.ExpressionStmt{Expression: expression, Loc: p.SpanFrom(start)}
+			})
+
+			tokens, lexErrors := g.Syntax().Tokenize("test.lang", word+" 1 + 2;")
+			program, parseErrors := g.Parse(tokens)
+			if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+				t.Fatalf("diagnostics: %v", errors)
+			}
+			if len(program.Body) != 1 {
+				t.Fatalf("parsed %d statements, want 1", len(program.Body))
+			}
+			stmt, ok := program.Body[0].(ast.ExpressionStmt)
+			if !ok {
+				t.Fatalf("parsed %T, want ast.ExpressionStmt", program.Body[0])
+			}
+			if _, ok := stmt.Expression.(ast.BinaryExpr); !ok {
+				t.Errorf("parsed expression %T, want ast.BinaryExpr", stmt.Expression)
+			}
+		})
+	}
+}
+
+func TestGrammarOperator(t *testing.T) {
+	g := NewGrammar()
+	pipe := g.Operator("|>")
+	if again := g.Operator("|>"); again != pipe {
+		t.Errorf("second Operator = %v, want %v", again, pipe)
+	}
+	if plus := g.Operator("+"); plus != lexer.PLUS {
+		t.Errorf("Operator(\"+\") = %v, want the language's plus", plus)
+	}
+}
@@ -1,10 +1,50 @@
 package parser
 
-import "github.com/thutasann/go-parser/src/lexer"
+import (
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Returns the token at index i of the token stream, pulling from the scanner as needed.
+// Past the end of the stream it keeps returning the EOF token.
+func (p *parser) tokenAt(i int) lexer.Token {
+	for i-p.base >= len(p.tokens) && p.scanner != nil && (len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Kind != lexer.EOF) {
+		p.tokens = append(p.tokens, p.scanner.Next())
+	}
+
+	if i-p.base >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[i-p.base]
+}
+
+// Forgets the tokens before the previous one. Only called between top-level statements,
+// when nothing can refer back to older tokens.
+func (p *parser) release() {
+	if drop := p.pos - 1 - p.base; p.scanner != nil && drop > 0 {
+		p.tokens = append(p.tokens[:0], p.tokens[drop:]...)
+		p.base += drop
+	}
+}
 
 // Returns the token at the current position without advancing
 func (p *parser) currentToken() lexer.Token {
-	return p.tokens[p.pos]
+	return p.tokenAt(p.pos)
+}
+
+// Returns the token after the current one without advancing (EOF at the end of the list)
+func (p *parser) peekToken() lexer.Token {
+	return p.tokenAt(p.pos + 1)
+}
+
+// Returns the most recently consumed token
+func (p *parser) previousToken() lexer.Token {
+	return p.tokenAt(p.pos - 1)
+}
+
+// Returns a span from the start token up to the most recently consumed token
+func (p *parser) spanFrom(start lexer.Token) lexer.Span {
+	return start.Span.To(p.previousToken().Span)
 }
 
 // Returns the kind/type of the current token, e.g., lexer.LET, lexer.NUMBER, etc.
@@ -25,5 +65,28 @@ func (p *parser) advance() lexer.Token {
 //
 // - Current token is not the special EOF (End of File) token
 func (p *parser) hasTokens() bool {
-	return p.pos < len(p.tokens) && p.currentTokenKind() != lexer.EOF
+	return p.currentTokenKind() != lexer.EOF
+}
+
+// Doc comments of the declaration starting at the current token: the `//` comments directly
+// above it, without blank lines in between. A comment on the same line as the previous token
+// belongs to that token, not to the declaration.
+func (p *parser) docComment() *ast.CommentGroup {
+	token := p.currentToken()
+	comments := token.Comments
+
+	first, line := len(comments), token.Span.Start.Line
+	for first > 0 && !comments[first-1].IsBlock() && comments[first-1].Span.End.Line == line-1 {
+		first--
+		line = comments[first].Span.Start.Line
+	}
+
+	if first < len(comments) && p.pos > 0 && comments[first].Span.Start.Line == p.previousToken().Span.End.Line {
+		first++
+	}
+
+	if first == len(comments) {
+		return nil
+	}
+	return &ast.CommentGroup{List: comments[first:]}
 }
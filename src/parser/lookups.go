@@ -43,64 +43,117 @@ type led_lookup map[lexer.TokenKind]led_handler
 // Binding Power Lookup table for tokens
 type bp_lookup map[lexer.TokenKind]binding_power
 
-var (
-	bp_lu   = bp_lookup{}   // Token → binding power
-	nud_lu  = nud_lookup{}  // Token → nud handler
-	led_lu  = led_lookup{}  // Token → led handler
-	stmt_lu = stmt_lookup{} // Token → statement handler
-)
+// Lookup tables the parser dispatches on.
+// A grammar is only written while it is being built, so any number of parsers can share it.
+type grammar struct {
+	bp_lu   bp_lookup   // Token → binding power
+	nud_lu  nud_lookup  // Token → nud handler
+	led_lu  led_lookup  // Token → led handler
+	stmt_lu stmt_lookup // Token → statement handler
+
+	type_bp_lu  bp_lookup       // Token → binding power inside types
+	type_nud_lu type_nud_lookup // Token → type nud handler
+	type_led_lu type_led_lookup // Token → type led handler
+
+	boundary_lu map[lexer.TokenKind]bool // Tokens starting custom statements, where recovery stops
+}
+
+// Grammar of the language, built once when the package is initialized
+var base_grammar = newGrammar()
+
+// Creates a grammar with all the handlers and precedences of the language
+func newGrammar() *grammar {
+	g := &grammar{
+		bp_lu:       bp_lookup{},
+		nud_lu:      nud_lookup{},
+		led_lu:      led_lookup{},
+		stmt_lu:     stmt_lookup{},
+		type_bp_lu:  bp_lookup{},
+		type_nud_lu: type_nud_lookup{},
+		type_led_lu: type_led_lookup{},
+		boundary_lu: map[lexer.TokenKind]bool{},
+	}
+
+	g.createTokenLookups()
+	g.createTokenTypeLookups()
+	return g
+}
 
 // Register a left denotation (infix/postfix) handler
-func led(kind lexer.TokenKind, bp binding_power, led_fn led_handler) {
-	bp_lu[kind] = bp
-	led_lu[kind] = led_fn
+func (g *grammar) led(kind lexer.TokenKind, bp binding_power, led_fn led_handler) {
+	g.bp_lu[kind] = bp
+	g.led_lu[kind] = led_fn
 }
 
 // Register a null denotation (literal/prefix) handler
-func nud(kind lexer.TokenKind, nud_fn nud_handler) {
-	nud_lu[kind] = nud_fn
+func (g *grammar) nud(kind lexer.TokenKind, nud_fn nud_handler) {
+	g.nud_lu[kind] = nud_fn
 }
 
 // Register a statement handler
-func stmt(kind lexer.TokenKind, stmt_fn stmt_handler) {
-	bp_lu[kind] = default_bp
-	stmt_lu[kind] = stmt_fn
+func (g *grammar) stmt(kind lexer.TokenKind, stmt_fn stmt_handler) {
+	g.bp_lu[kind] = default_bp
+	g.stmt_lu[kind] = stmt_fn
 }
 
 // Initializes all token lookups with appropriate handlers and precedence
-func createTokenLookups() {
-	led(lexer.ASSIGNMENT, assignment, parse_assignment_expr)
-	led(lexer.PLUS_EQUALS, assignment, parse_assignment_expr)
-	led(lexer.MINUS_EQUALS, assignment, parse_assignment_expr)
+func (g *grammar) createTokenLookups() {
+	g.led(lexer.ASSIGNMENT, assignment, parse_assignment_expr)
+	g.led(lexer.PLUS_EQUALS, assignment, parse_assignment_expr)
+	g.led(lexer.MINUS_EQUALS, assignment, parse_assignment_expr)
 
 	// Logical
-	led(lexer.AND, logical, parse_binary_expr)
-	led(lexer.OR, logical, parse_binary_expr)
-	led(lexer.DOT_DOT, logical, parse_binary_expr)
+	g.led(lexer.AND, logical, parse_binary_expr)
+	g.led(lexer.OR, logical, parse_binary_expr)
+	g.led(lexer.DOT_DOT, logical, parse_range_expr)
 
 	// Relational
-	led(lexer.LESS, relational, parse_binary_expr)
-	led(lexer.LESS_EQUALS, relational, parse_binary_expr)
-	led(lexer.GREATER, relational, parse_binary_expr)
-	led(lexer.GREATER_EQUALS, relational, parse_binary_expr)
-	led(lexer.EQUALS, relational, parse_binary_expr)
-	led(lexer.NOT_EQUALS, relational, parse_binary_expr)
+	g.led(lexer.LESS, relational, parse_binary_expr)
+	g.led(lexer.LESS_EQUALS, relational, parse_binary_expr)
+	g.led(lexer.GREATER, relational, parse_binary_expr)
+	g.led(lexer.GREATER_EQUALS, relational, parse_binary_expr)
+	g.led(lexer.EQUALS, relational, parse_binary_expr)
+	g.led(lexer.NOT_EQUALS, relational, parse_binary_expr)
 
 	// Additive & Multiplicative
-	led(lexer.PLUS, additive, parse_binary_expr)
-	led(lexer.DASH, additive, parse_binary_expr)
-	led(lexer.STAR, multiplicative, parse_binary_expr)
-	led(lexer.SLASH, multiplicative, parse_binary_expr)
-	led(lexer.PERCENT, multiplicative, parse_binary_expr)
+	g.led(lexer.PLUS, additive, parse_binary_expr)
+	g.led(lexer.DASH, additive, parse_binary_expr)
+	g.led(lexer.STAR, multiplicative, parse_binary_expr)
+	g.led(lexer.SLASH, multiplicative, parse_binary_expr)
+	g.led(lexer.PERCENT, multiplicative, parse_binary_expr)
 
-	// Literals & symbols
-	nud(lexer.NUMBER, parse_primary_expr)
-	nud(lexer.STRING, parse_primary_expr)
-	nud(lexer.IDENTIFIER, parse_primary_expr)
+	// Call & member access
+	g.led(lexer.OPEN_PAREN, call, parse_call_expr)
+	g.led(lexer.DOT, member, parse_member_expr)
+	g.led(lexer.OPEN_BRACKET, member, parse_computed_expr)
 
-	nud(lexer.DASH, parse_prefix_expr)
+	// Literals & symbols
+	g.nud(lexer.NUMBER, parse_primary_expr)
+	g.nud(lexer.STRING, parse_primary_expr)
+	g.nud(lexer.TEMPLATE, parse_template_expr)
+	g.nud(lexer.TEMPLATE_HEAD, parse_template_expr)
+	g.nud(lexer.IDENTIFIER, parse_primary_expr)
+
+	g.nud(lexer.DASH, parse_prefix_expr)
+	g.nud(lexer.NOT, parse_prefix_expr)
+	g.nud(lexer.TYPEOF, parse_prefix_expr)
+	g.nud(lexer.OPEN_PAREN, parse_grouping_expr)
+	g.nud(lexer.FN, parse_fn_expr)
+	g.nud(lexer.NEW, parse_new_expr)
+	g.nud(lexer.OPEN_BRACKET, parse_array_literal_expr)
+	g.nud(lexer.OPEN_CURLY, parse_object_literal_expr)
 
 	// Statements
-	stmt(lexer.CONST, parse_var_decl_stmt)
-	stmt(lexer.LET, parse_var_decl_stmt)
+	g.stmt(lexer.CONST, parse_var_decl_stmt)
+	g.stmt(lexer.LET, parse_var_decl_stmt)
+	g.stmt(lexer.FN, parse_fn_decl_stmt)
+	g.stmt(lexer.RETURN, parse_return_stmt)
+	g.stmt(lexer.CLASS, parse_class_decl_stmt)
+	g.stmt(lexer.IF, parse_if_stmt)
+	g.stmt(lexer.WHILE, parse_while_stmt)
+	g.stmt(lexer.FOR, parse_for_stmt)
+	g.stmt(lexer.FOREACH, parse_foreach_stmt)
+	g.stmt(lexer.IMPORT, parse_import_stmt)
+	g.stmt(lexer.EXPORT, parse_export_stmt)
+	g.stmt(lexer.OPEN_CURLY, parse_nested_block_stmt) // a statement starting with { is a block, never an object literal
 }
@@ -7,24 +7,53 @@ import (
 	"github.com/thutasann/go-parser/src/lexer"
 )
 
-// Holds all the tokens from the lexer
-// pos: current position/index in the token list
+// Holds the tokens being parsed
+// grammar: lookup tables to dispatch on, shared between parsers
+// tokens: window of the token stream starting at index base (the whole list when parsing a slice)
+// scanner: where more tokens come from when parsing a stream, nil when parsing a slice
+// pos: current position/index in the token stream
+// diagnostics: errors reported so far
 type parser struct {
-	tokens []lexer.Token
-	pos    int
+	grammar     *grammar
+	tokens      []lexer.Token
+	base        int
+	scanner     *lexer.Scanner
+	pos         int
+	diagnostics []lexer.Diagnostic
 }
 
 // Creates a parser instance.
 //
-// - Calls createTokenLookups() → this registers all the nud/led/stmt handlers and operator precedence.
+// - Uses the grammar built once at package initialization → all the nud/led/stmt handlers and operator precedence.
 //
 // - Initializes pos to 0.
+//
+// - Makes sure the token list ends with EOF so the parser never runs off the end.
 func createParser(tokens []lexer.Token) *parser {
-	createTokenLookups()
-	createTokenTypeLookups()
+	if len(tokens) == 0 || tokens[len(tokens)-1].Kind != lexer.EOF {
+		var eof lexer.Token
+		if len(tokens) > 0 {
+			last := tokens[len(tokens)-1].Span
+			eof.Span = lexer.Span{File: last.File, Start: last.End, End: last.End}
+		}
+		eof.Kind, eof.Value = lexer.EOF, "EOF"
+		tokens = append(tokens[:len(tokens):len(tokens)], eof)
+	}
+
+	return &parser{
+		grammar: base_grammar,
+		tokens:  tokens,
+		pos:     0,
+	}
+}
+
+// Creates a parser that pulls tokens from scanner as it needs them
+func createStreamParser(scanner *lexer.Scanner) *parser {
 	return &parser{
-		tokens: tokens,
-		pos:    0,
+		grammar: base_grammar,
+		tokens:  make([]lexer.Token, 0),
+		scanner: scanner,
+		pos:     0,
 	}
 }
 
@@ -37,29 +66,49 @@ func createParser(tokens []lexer.Token) *parser {
 // - Adds each statement into Body
 //
 // - Returns a block statement, which wraps all the parsed statements
-func Parse(tokens []lexer.Token) ast.BlockStmt {
+//
+// - Syntax errors are returned as diagnostics; statements that failed to parse become ast.BadStmt
+func Parse(tokens []lexer.Token) (ast.BlockStmt, []lexer.Diagnostic) {
+	return parse_program(createParser(tokens))
+}
+
+// ParseScanner parses tokens pulled lazily from scanner, so parsing starts before the whole source is read.
+// Tokens are released after each top-level statement, so memory only grows with the size of the tree.
+//
+// - Returns the scanner's diagnostics followed by the parser's
+func ParseScanner(scanner *lexer.Scanner) (ast.BlockStmt, []lexer.Diagnostic) {
+	program, diagnostics := parse_program(createStreamParser(scanner))
+	return program, append(scanner.Diagnostics, diagnostics...)
+}
+
+// Parses top-level statements until EOF
+func parse_program(p *parser) (ast.BlockStmt, []lexer.Diagnostic) {
 	Body := make([]ast.Stmt, 0)
-	p := createParser(tokens)
+	start := p.currentToken()
 
 	for p.hasTokens() {
+		p.release()
 		Body = append(Body, parse_stmt(p))
 	}
 
 	return ast.BlockStmt{
 		Body: Body,
-	}
+		Loc:  start.Span.To(p.currentToken().Span),
+	}, p.diagnostics
 }
 
 // Expect Error
-func (p *parser) expectError(expectedKind lexer.TokenKind, err any) lexer.Token {
+//
+// - Consumes the current token if it has the expected kind, otherwise reports message (or a default one) and bails out
+func (p *parser) expectError(expectedKind lexer.TokenKind, message string) lexer.Token {
 	token := p.currentToken()
 	kind := token.Kind
 
 	if kind != expectedKind {
-		if err == nil {
-			err = fmt.Sprintf("Expected %s but received %s instead\n", lexer.TokenKindString(expectedKind), lexer.TokenKindString(kind))
+		if message == "" {
+			message = fmt.Sprintf("Expected %s but received %s instead", lexer.TokenKindString(expectedKind), describeToken(token))
 		}
-		panic(err)
+		p.failUnexpected(CodeUnexpectedToken, message, expectedKind)
 	}
 
 	return p.advance()
@@ -67,5 +116,5 @@ func (p *parser) expectError(expectedKind lexer.TokenKind, err any) lexer.Token
 
 // Expect fn
 func (p *parser) expect(expectedKind lexer.TokenKind) lexer.Token {
-	return p.expectError(expectedKind, nil)
+	return p.expectError(expectedKind, "")
 }
@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Parses source, failing the test on lexer and parser diagnostics
+func parseSource(t *testing.T, source string) ast.BlockStmt {
+	t.Helper()
+	tokens, lexErrors := lexer.Tokenize(source)
+	program, parseErrors := Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+		t.Fatalf("diagnostics for %q: %v", source, errors)
+	}
+	return program
+}
+
+var (
+	token_type = reflect.TypeOf(lexer.Token{})
+	span_type  = reflect.TypeOf(lexer.Span{})
+	node_type  = reflect.TypeOf((*ast.Node)(nil)).Elem()
+)
+
+// Compact rendering of a syntax tree without positions.
+//
+// - Nodes print as their type name followed by their non-empty fields, e.g. ReturnStmt{Value: x}
+//
+// - Symbols, numbers, strings and type names print as their value, tokens as their text
+//
+// - Doc comments print as their text
+func dump(value any) string {
+	return dumpValue(reflect.ValueOf(value))
+}
+
+func dumpValue(v reflect.Value) string {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "nil"
+		}
+		v = v.Elem()
+	}
+
+	switch node := v.Interface().(type) {
+	case ast.SymbolExpr:
+		return node.Value
+	case ast.NumberExpr:
+		return fmt.Sprint(node.Value)
+	case ast.StringExpr:
+		return fmt.Sprintf("%q", node.Value)
+	case ast.SymbolType:
+		return node.Name
+	case ast.CommentGroup:
+		return fmt.Sprintf("%q", node.Text())
+	case lexer.Token:
+		return node.Value
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var fields []string
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() || field.Type == span_type || v.Field(i).IsZero() || v.Field(i).Kind() == reflect.Slice && v.Field(i).Len() == 0 {
+				continue
+			}
+			fields = append(fields, field.Name+": "+dumpValue(v.Field(i)))
+		}
+		return v.Type().Name() + "{" + strings.Join(fields, ", ") + "}"
+	case reflect.Slice:
+		var elements []string
+		for i := 0; i < v.Len(); i++ {
+			elements = append(elements, dumpValue(v.Index(i)))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Source text covered by every node in the tree, in preorder
+func spans(source string, value any) []string {
+	var texts []string
+	var visit func(v reflect.Value)
+	visit = func(v reflect.Value) {
+		if v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return
+			}
+			v = v.Elem()
+		}
+		if v.Type() == token_type || v.Type() == span_type {
+			return
+		}
+		if v.Type().Implements(node_type) {
+			span := v.Interface().(ast.Node).Span()
+			texts = append(texts, source[span.Start.Offset:span.End.Offset])
+		}
+
+		switch v.Kind() {
+		case reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if v.Type().Field(i).IsExported() {
+					visit(v.Field(i))
+				}
+			}
+		case reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				visit(v.Index(i))
+			}
+		}
+	}
+	visit(reflect.ValueOf(value))
+	return texts
+}
+
+type parserTest struct {
+	source string
+	want   string   // dump of the parsed statements
+	spans  []string // source text of every node in the statements, in preorder
+}
+
+// Parses each test's source and compares the statements and their spans
+func runParserTests(t *testing.T, tests []parserTest) {
+	t.Helper()
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			program := parseSource(t, test.source)
+			if got := dump(program.Body); got != test.want {
+				t.Errorf("parsed\n%s\nwant\n%s", got, test.want)
+			}
+			if got := spans(test.source, program.Body); !reflect.DeepEqual(got, test.spans) {
+				t.Errorf("spans\n%q\nwant\n%q", got, test.spans)
+			}
+		})
+	}
+}
+
+// Pulling tokens from a scanner gives the same tree and diagnostics as parsing the tokenized source
+func TestParseScanner(t *testing.T) {
+	sources := []string{
+		"",
+		"let x = 1;\nfn add(a: number, b: number): number { return a + b; }\nprintln(add(x, 2));",
+		"class Point { let x: number; fn constructor(x: number) { this.x = x; } }\nlet p = new Point(1);",
+		"foreach i in 0..3 { if i > 1 { println(i); } }",
+		"let a = 1 +;\nlet = 5;\nlet é = 2;",
+		"fn broken( { let y = ; }\nprintln(1);",
+	}
+	files, _ := filepath.Glob("../../examples/*.lang")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, string(content))
+	}
+
+	for _, source := range sources {
+		tokens, lexErrors := lexer.Tokenize(source)
+		wantProgram, parseErrors := Parse(tokens)
+		want := append(lexErrors, parseErrors...)
+
+		program, got := ParseScanner(lexer.NewScanner("", iotest.OneByteReader(strings.NewReader(source))))
+		if !reflect.DeepEqual(program, wantProgram) {
+			t.Errorf("tree of %q differs\n%s\nwant\n%s", source, dump(program), dump(wantProgram))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("diagnostics of %q: %v, want %v", source, got, want)
+		}
+	}
+}
+
+// The examples and a few programs with syntax errors, so recovery runs concurrently too
+func parserSources(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("../../examples/*.lang")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no examples to parse: %v", err)
+	}
+
+	sources := []string{
+		"let x = ;\nprintln(x);",
+		"fn f( { return 1; }\nclass C { let a: = 1; }",
+		"unless x > 1 { println(x); }",
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, string(content))
+	}
+	return sources
+}
+
+type parseResult struct {
+	program     ast.BlockStmt
+	diagnostics []lexer.Diagnostic
+}
+
+// Parses every source from many goroutines at once with parse, comparing each result to parsing it alone.
+// Run with -race to check that parsers share nothing but read-only tables.
+func parseConcurrently(t *testing.T, sources []string, parse func(source string) parseResult) {
+	t.Helper()
+	want := make([]parseResult, len(sources))
+	for i, source := range sources {
+		want[i] = parse(source)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 16; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := 0; i < 4*len(sources); i++ {
+				index := (worker + i) % len(sources)
+				if got := parse(sources[index]); !reflect.DeepEqual(got, want[index]) {
+					t.Errorf("parsing %q concurrently gave a different result than parsing it alone", sources[index])
+					return
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+}
+
+func TestParseConcurrently(t *testing.T) {
+	sources := parserSources(t)
+
+	t.Run("Parse", func(t *testing.T) {
+		parseConcurrently(t, sources, func(source string) parseResult {
+			tokens, _ := lexer.Tokenize(source)
+			program, diagnostics := Parse(tokens)
+			return parseResult{program: program, diagnostics: diagnostics}
+		})
+	})
+
+	t.Run("ParseScanner", func(t *testing.T) {
+		parseConcurrently(t, sources, func(source string) parseResult {
+			program, diagnostics := ParseScanner(lexer.NewScanner("test.lang", strings.NewReader(source)))
+			return parseResult{program: program, diagnostics: diagnostics}
+		})
+	})
+
+	t.Run("Grammar", func(t *testing.T) {
+		g := NewGrammar()
+		unless := g.Keyword("unless")
+		g.Stmt(unless, func(p *Parser) ast.Stmt {
+			start := p.Expect(unless)
+			condition := p.ParseExpr(BindLowest)
+			p.ParseBlock()
+			return ast.ExpressionStmt{Expression: condition, Loc: p.SpanFrom(start)}
+		})
+
+		parseConcurrently(t, sources, func(source string) parseResult {
+			program, diagnostics := g.ParseScanner(g.Syntax().NewScanner("test.lang", strings.NewReader(source)))
+			return parseResult{program: program, diagnostics: diagnostics}
+		})
+	})
+}
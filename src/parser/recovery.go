@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Returns true for tokens that end or begin a statement, including the keywords of custom statements.
+// Recovery stops in front of these so the next statement can be parsed normally.
+func (p *parser) isStatementBoundary(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.EOF, lexer.SEMI_COLON, lexer.CLOSE_CURLY,
+		lexer.LET, lexer.CONST, lexer.FN, lexer.CLASS,
+		lexer.IF, lexer.WHILE, lexer.FOR, lexer.FOREACH,
+		lexer.IMPORT, lexer.EXPORT, lexer.RETURN:
+		return true
+	default:
+		return p.grammar.boundary_lu[kind]
+	}
+}
+
+// Panic-mode recovery after a statement starting at token index start failed to parse.
+//
+// - Skips tokens up to and including the next `;`, or up to (not including) a `}` or statement keyword
+//
+// - Nested `{ ... }` blocks are skipped as a whole, so a broken function header doesn't leak its body
+//
+// - Always consumes at least one token so the parser keeps making progress
+//
+// - Returns a placeholder covering everything that was skipped
+func (p *parser) synchronize(start int) ast.BadStmt {
+	startToken := p.tokenAt(start)
+
+	if p.pos == start && p.advance().Kind == lexer.SEMI_COLON {
+		return ast.BadStmt{Loc: p.spanFrom(startToken)}
+	}
+
+	for p.hasTokens() && !p.isStatementBoundary(p.currentTokenKind()) {
+		if p.advance().Kind == lexer.OPEN_CURLY {
+			p.skipBlock()
+		}
+	}
+
+	if p.currentTokenKind() == lexer.SEMI_COLON {
+		p.advance()
+	}
+
+	return ast.BadStmt{Loc: p.spanFrom(startToken)}
+}
+
+// Skips tokens up to and including the `}` matching an already consumed `{`
+func (p *parser) skipBlock() {
+	depth := 1
+	for p.hasTokens() && depth > 0 {
+		switch p.advance().Kind {
+		case lexer.OPEN_CURLY:
+			depth++
+		case lexer.CLOSE_CURLY:
+			depth--
+		}
+	}
+}
+
+// Returns true for tokens that close a group or list of expressions, or separate its elements
+func isExprDelimiter(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.CLOSE_PAREN, lexer.CLOSE_BRACKET, lexer.CLOSE_CURLY, lexer.COMMA:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reports a missing expression and returns a placeholder in its place.
+// The offending token is skipped unless it is a statement boundary or an expression delimiter,
+// which the enclosing statement, call or literal still expects.
+func parse_bad_expr(p *parser) ast.Expr {
+	token := p.currentToken()
+	p.reportUnexpected(CodeExpectedExpression, fmt.Sprintf("Expected expression but received %s instead", describeToken(token)))
+
+	if !p.isStatementBoundary(token.Kind) && !isExprDelimiter(token.Kind) {
+		p.advance()
+	}
+
+	return ast.BadExpr{Loc: token.Span}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+func TestRecovery(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string // messages of the expected diagnostics, in order
+	}{
+		{
+			source: "let a = 1 +;",
+			want:   []string{"Expected expression but received semi_colon instead"},
+		},
+		{
+			source: "let = 5;",
+			want:   []string{"Inside variable declaration expected to find variable name"},
+		},
+		{
+			source: "let a = 1 2;",
+			want:   []string{"Expected semi_colon but received number (2) instead"},
+		},
+		{
+			source: "let a = 1; let = ; const c;",
+			want:   []string{"Inside variable declaration expected to find variable name", "Missing either right-hand side in var declaration or explicit type."},
+		},
+		{
+			source: "println(1 +);",
+			want:   []string{"Expected expression but received close_paren instead"},
+		},
+		{
+			source: "let a = [1, +];",
+			want:   []string{"Expected expression but received plus instead"},
+		},
+		{
+			source: "let a = [1, , 2];",
+			want:   []string{"Expected expression but received comma instead"},
+		},
+		{
+			source: "let a = [1 -];",
+			want:   []string{"Expected expression but received close_bracket instead"},
+		},
+		{
+			source: "let b = f(, 2);",
+			want:   []string{"Expected expression but received comma instead"},
+		},
+		{
+			source: "let o = {a: , b: 1};",
+			want:   []string{"Expected expression but received comma instead"},
+		},
+		{
+			source: "let i = items[1 * ];",
+			want:   []string{"Expected expression but received close_bracket instead"},
+		},
+		{
+			source: "f(,);\n]",
+			want:   []string{"Expected expression but received comma instead", "Expected expression but received close_bracket instead"},
+		},
+		{
+			source: "fn f() { return 1 + }",
+			want:   []string{"Expected expression but received close_curly instead"},
+		},
+		{
+			source: "let t = `a ${x y} b`;",
+			want:   []string{"Expected } to close template interpolation but received identifier (y) instead"},
+		},
+		{
+			source: "let a = );",
+			want:   []string{"Expected expression but received close_paren instead"},
+		},
+		{
+			source: ");",
+			want:   []string{"Expected expression but received close_paren instead"},
+		},
+		{
+			source: "1 + ]; -}",
+			want:   []string{"Expected expression but received close_bracket instead", "Expected expression but received close_curly instead"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			// the statement after the broken one parses normally
+			tokens, lexErrors := lexer.Tokenize(test.source + "\nlet next = 1;")
+			if len(lexErrors) > 0 {
+				t.Fatalf("lexer errors: %v", lexErrors)
+			}
+			program, diagnostics := Parse(tokens)
+
+			var got []string
+			for _, diagnostic := range diagnostics {
+				got = append(got, diagnostic.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+
+			last, ok := program.Body[len(program.Body)-1].(ast.VarDeclStmt)
+			if !ok || last.VariableName != "next" {
+				t.Errorf("last statement %#v, want let next", program.Body[len(program.Body)-1])
+			}
+		})
+	}
+}
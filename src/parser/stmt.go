@@ -6,18 +6,59 @@ import (
 )
 
 // Parse Statement
-func parse_stmt(p *parser) ast.Stmt {
-	smt_fn, exists := stmt_lu[p.currentTokenKind()]
+//
+// - If the statement has a syntax error, recovers by skipping to the next statement boundary and returns an ast.BadStmt
+func parse_stmt(p *parser) (stmt ast.Stmt) {
+	startPos := p.pos
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			stmt = p.synchronize(startPos)
+		}
+	}()
+
+	smt_fn, exists := p.grammar.stmt_lu[p.currentTokenKind()]
 
 	if exists {
 		return smt_fn(p)
 	}
 
+	return parse_expression_stmt(p)
+}
+
+// Parse Expression Statement `expr;`
+func parse_expression_stmt(p *parser) ast.Stmt {
+	start := p.currentToken()
 	expression := parse_expr(p, default_bp)
 	p.expect(lexer.SEMI_COLON)
 
 	return ast.ExpressionStmt{
 		Expression: expression,
+		Loc:        p.spanFrom(start),
+	}
+}
+
+// Parse a nested Block Statement `{ ... }` in statement position
+func parse_nested_block_stmt(p *parser) ast.Stmt {
+	return parse_block_stmt(p)
+}
+
+// Parse Block Statement `{ ... }`
+func parse_block_stmt(p *parser) ast.BlockStmt {
+	start := p.expect(lexer.OPEN_CURLY)
+	body := make([]ast.Stmt, 0)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		body = append(body, parse_stmt(p))
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.BlockStmt{
+		Body: body,
+		Loc:  p.spanFrom(start),
 	}
 }
 
@@ -26,7 +67,9 @@ func parse_var_decl_stmt(p *parser) ast.Stmt {
 	var explicitType ast.Type
 	var assignedValue ast.Expr
 
-	isConstant := p.advance().Kind == lexer.CONST
+	doc := p.docComment()
+	start := p.advance()
+	isConstant := start.Kind == lexer.CONST
 	varName := p.expectError(lexer.IDENTIFIER, "Inside variable declaration expected to find variable name").Value
 
 	// Explicit type could be present
@@ -39,13 +82,13 @@ func parse_var_decl_stmt(p *parser) ast.Stmt {
 		p.expect(lexer.ASSIGNMENT)
 		assignedValue = parse_expr(p, assignment)
 	} else if explicitType == nil {
-		panic("Missing either right-hand side in var declaration or explicit type.")
+		p.fail(CodeMissingInitializer, p.spanFrom(start), "Missing either right-hand side in var declaration or explicit type.")
 	}
 
 	p.expect(lexer.SEMI_COLON)
 
 	if isConstant && assignedValue == nil {
-		panic("Cannot define constant without providing value")
+		p.fail(CodeConstWithoutValue, p.spanFrom(start), "Cannot define constant without providing value")
 	}
 
 	return ast.VarDeclStmt{
@@ -53,5 +96,289 @@ func parse_var_decl_stmt(p *parser) ast.Stmt {
 		IsConstant:    isConstant,
 		VariableName:  varName,
 		AssignedValue: assignedValue,
+		Doc:           doc,
+		Loc:           p.spanFrom(start),
+	}
+}
+
+// Parse Function Declaration Statement `fn name(a: T): R { ... }`
+//
+// - `fn` not followed by a name is an anonymous function used as an expression statement
+func parse_fn_decl_stmt(p *parser) ast.Stmt {
+	if p.peekToken().Kind != lexer.IDENTIFIER {
+		return parse_expression_stmt(p)
+	}
+
+	return parse_fn_decl(p)
+}
+
+// Parses a named function declaration; also used for class methods
+func parse_fn_decl(p *parser) ast.FunctionDeclStmt {
+	doc := p.docComment()
+	start := p.expect(lexer.FN)
+	name := p.expectError(lexer.IDENTIFIER, "Expected function name after fn").Value
+	parameters, returnType, body := parse_fn_params_and_body(p)
+
+	return ast.FunctionDeclStmt{
+		Name:       name,
+		Parameters: parameters,
+		ReturnType: returnType,
+		Body:       body,
+		Doc:        doc,
+		Loc:        p.spanFrom(start),
+	}
+}
+
+// Parses the `(a: T, b: T): R { ... }` part shared by function declarations and expressions
+func parse_fn_params_and_body(p *parser) ([]ast.Parameter, ast.Type, ast.BlockStmt) {
+	var returnType ast.Type
+	parameters := make([]ast.Parameter, 0)
+
+	p.expect(lexer.OPEN_PAREN)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_PAREN {
+		nameToken := p.expectError(lexer.IDENTIFIER, "Expected parameter name inside function parameter list")
+		p.expect(lexer.COLON)
+		paramType := parse_type(p, default_bp)
+
+		parameters = append(parameters, ast.Parameter{
+			Name: nameToken.Value,
+			Type: paramType,
+			Loc:  nameToken.Span.To(paramType.Span()),
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_PAREN {
+			p.expect(lexer.COMMA)
+		}
+	}
+	p.expect(lexer.CLOSE_PAREN)
+
+	// Explicit return type could be present
+	if p.currentTokenKind() == lexer.COLON {
+		p.advance() // eat the colon
+		returnType = parse_type(p, default_bp)
+	}
+
+	return parameters, returnType, parse_block_stmt(p)
+}
+
+// Parse Return Statement `return;` or `return expr;`
+func parse_return_stmt(p *parser) ast.Stmt {
+	var value ast.Expr
+
+	start := p.advance()
+	if p.currentTokenKind() != lexer.SEMI_COLON {
+		value = parse_expr(p, default_bp)
+	}
+	p.expect(lexer.SEMI_COLON)
+
+	return ast.ReturnStmt{
+		Value: value,
+		Loc:   p.spanFrom(start),
+	}
+}
+
+// Parse Class Declaration Statement `class Name { let field: T; fn method() { ... } }`
+//
+// - Members are parsed as statements so a broken member recovers without losing the rest of the class
+func parse_class_decl_stmt(p *parser) ast.Stmt {
+	doc := p.docComment()
+	start := p.advance()
+	name := p.expectError(lexer.IDENTIFIER, "Expected class name after class").Value
+	fields := make([]ast.VarDeclStmt, 0)
+	methods := make([]ast.FunctionDeclStmt, 0)
+
+	p.expect(lexer.OPEN_CURLY)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		switch member := parse_stmt(p).(type) {
+		case ast.VarDeclStmt:
+			fields = append(fields, member)
+		case ast.FunctionDeclStmt:
+			methods = append(methods, member)
+		case ast.BadStmt:
+			// already reported
+		default:
+			p.report(lexer.Errorf(CodeInvalidClassMember, member.Span(), "Classes may only contain field and method declarations"))
+		}
+	}
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.ClassDeclStmt{
+		Name:    name,
+		Fields:  fields,
+		Methods: methods,
+		Doc:     doc,
+		Loc:     p.spanFrom(start),
+	}
+}
+
+// Parse If Statement `if cond { ... } else if cond { ... } else { ... }`
+func parse_if_stmt(p *parser) ast.Stmt {
+	var alternate ast.Stmt
+
+	start := p.advance()
+	condition := parse_expr(p, default_bp)
+	consequent := parse_block_stmt(p)
+
+	if p.currentTokenKind() == lexer.ELSE {
+		p.advance() // eat the else
+
+		if p.currentTokenKind() == lexer.IF {
+			alternate = parse_if_stmt(p)
+		} else {
+			alternate = parse_block_stmt(p)
+		}
+	}
+
+	return ast.IfStmt{
+		Condition:  condition,
+		Consequent: consequent,
+		Alternate:  alternate,
+		Loc:        p.spanFrom(start),
+	}
+}
+
+// Parse While Statement `while cond { ... }`
+func parse_while_stmt(p *parser) ast.Stmt {
+	start := p.advance()
+	condition := parse_expr(p, default_bp)
+	body := parse_block_stmt(p)
+
+	return ast.WhileStmt{
+		Condition: condition,
+		Body:      body,
+		Loc:       p.spanFrom(start),
+	}
+}
+
+// Parse For Statement `for init; cond; post { ... }`
+//
+// - Every clause is optional and the clauses may be wrapped in parentheses: `for (let i = 0; i < n; i += 1) { ... }`
+func parse_for_stmt(p *parser) ast.Stmt {
+	var init ast.Stmt
+	var condition, post ast.Expr
+
+	start := p.advance()
+	parenthesized := p.currentTokenKind() == lexer.OPEN_PAREN
+	if parenthesized {
+		p.advance()
+	}
+
+	switch p.currentTokenKind() {
+	case lexer.SEMI_COLON:
+		p.advance()
+	case lexer.LET, lexer.CONST:
+		init = parse_var_decl_stmt(p)
+	default:
+		init = parse_expression_stmt(p)
+	}
+
+	if p.currentTokenKind() != lexer.SEMI_COLON {
+		condition = parse_expr(p, default_bp)
+	}
+	p.expect(lexer.SEMI_COLON)
+
+	if parenthesized {
+		if p.currentTokenKind() != lexer.CLOSE_PAREN {
+			post = parse_expr(p, default_bp)
+		}
+		p.expect(lexer.CLOSE_PAREN)
+	} else if p.currentTokenKind() != lexer.OPEN_CURLY {
+		post = parse_expr(p, default_bp)
+	}
+
+	body := parse_block_stmt(p)
+
+	return ast.ForStmt{
+		Init:      init,
+		Condition: condition,
+		Post:      post,
+		Body:      body,
+		Loc:       p.spanFrom(start),
+	}
+}
+
+// Parse Foreach Statement `foreach value in iterable { ... }`
+func parse_foreach_stmt(p *parser) ast.Stmt {
+	start := p.advance()
+	value := p.expectError(lexer.IDENTIFIER, "Expected loop variable after foreach").Value
+	p.expect(lexer.IN)
+	iterable := parse_expr(p, default_bp)
+	body := parse_block_stmt(p)
+
+	return ast.ForeachStmt{
+		Value:    value,
+		Iterable: iterable,
+		Body:     body,
+		Loc:      p.spanFrom(start),
+	}
+}
+
+// Parse Import Statement
+//
+// - `import { a, b } from "./path";` imports named declarations
+//
+// - `import name from "./path";` imports the whole module under an alias
+func parse_import_stmt(p *parser) ast.Stmt {
+	var alias string
+	names := make([]string, 0)
+
+	start := p.advance()
+	if p.currentTokenKind() == lexer.OPEN_CURLY {
+		p.advance()
+		for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+			names = append(names, p.expectError(lexer.IDENTIFIER, "Expected imported name inside import list").Value)
+
+			if p.currentTokenKind() != lexer.CLOSE_CURLY {
+				p.expect(lexer.COMMA)
+			}
+		}
+		p.expect(lexer.CLOSE_CURLY)
+	} else {
+		alias = p.expectError(lexer.IDENTIFIER, "Expected module alias or import list after import").Value
+	}
+
+	p.expect(lexer.FROM)
+	from := p.expectError(lexer.STRING, "Expected module path string after from").Value
+	p.expect(lexer.SEMI_COLON)
+
+	return ast.ImportStmt{
+		Names: names,
+		Alias: alias,
+		From:  from,
+		Loc:   p.spanFrom(start),
+	}
+}
+
+// Parse Export Statement `export let|const|fn|class ...`
+//
+// - Returns the declaration itself, marked as exported
+//
+// - Doc comments go above the export keyword
+func parse_export_stmt(p *parser) ast.Stmt {
+	doc := p.docComment()
+	start := p.advance()
+
+	switch p.currentTokenKind() {
+	case lexer.LET, lexer.CONST:
+		decl := parse_var_decl_stmt(p).(ast.VarDeclStmt)
+		decl.IsExported = true
+		decl.Doc = doc
+		decl.Loc = p.spanFrom(start)
+		return decl
+	case lexer.FN:
+		decl := parse_fn_decl(p)
+		decl.IsExported = true
+		decl.Doc = doc
+		decl.Loc = p.spanFrom(start)
+		return decl
+	case lexer.CLASS:
+		decl := parse_class_decl_stmt(p).(ast.ClassDeclStmt)
+		decl.IsExported = true
+		decl.Doc = doc
+		decl.Loc = p.spanFrom(start)
+		return decl
+	default:
+		p.failUnexpected(CodeUnexpectedToken, "Expected declaration after export", lexer.LET, lexer.CONST, lexer.FN, lexer.CLASS)
+		return nil
 	}
 }
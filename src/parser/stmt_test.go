@@ -0,0 +1,163 @@
+package parser
+
+import "testing"
+
+func TestFunctions(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "fn add(a: number, b: number): number { return a + b; }",
+			want:   `[FunctionDeclStmt{Name: "add", Parameters: [Parameter{Name: "a", Type: number}, Parameter{Name: "b", Type: number}], ReturnType: number, Body: BlockStmt{Body: [ReturnStmt{Value: BinaryExpr{Left: a, Operator: +, Right: b}}]}}]`,
+			spans:  []string{"fn add(a: number, b: number): number { return a + b; }", "a: number", "number", "b: number", "number", "number", "{ return a + b; }", "return a + b;", "a + b", "a", "b"},
+		},
+		{
+			source: "fn log(items: []string) { return; }",
+			want:   `[FunctionDeclStmt{Name: "log", Parameters: [Parameter{Name: "items", Type: ArrayType{Underlying: string}}], Body: BlockStmt{Body: [ReturnStmt{}]}}]`,
+			spans:  []string{"fn log(items: []string) { return; }", "items: []string", "[]string", "string", "{ return; }", "return;"},
+		},
+		{
+			source: "fn noop() {}",
+			want:   `[FunctionDeclStmt{Name: "noop", Body: BlockStmt{}}]`,
+			spans:  []string{"fn noop() {}", "{}"},
+		},
+		{
+			source: "let twice = fn (n: number): number { return n * 2; };",
+			want:   `[VarDeclStmt{VariableName: "twice", AssignedValue: FunctionExpr{Parameters: [Parameter{Name: "n", Type: number}], ReturnType: number, Body: BlockStmt{Body: [ReturnStmt{Value: BinaryExpr{Left: n, Operator: *, Right: 2}}]}}}]`,
+			spans:  []string{"let twice = fn (n: number): number { return n * 2; };", "fn (n: number): number { return n * 2; }", "n: number", "number", "number", "{ return n * 2; }", "return n * 2;", "n * 2", "n", "2"},
+		},
+		{
+			source: "fn outer() { fn inner() {} return inner; }",
+			want:   `[FunctionDeclStmt{Name: "outer", Body: BlockStmt{Body: [FunctionDeclStmt{Name: "inner", Body: BlockStmt{}}, ReturnStmt{Value: inner}]}}]`,
+			spans:  []string{"fn outer() { fn inner() {} return inner; }", "{ fn inner() {} return inner; }", "fn inner() {}", "{}", "return inner;", "inner"},
+		},
+	})
+}
+
+func TestClasses(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "class DirectoryReader { let path: string; const limit = 10; fn read(n: number): []string { return this.path; } }",
+			want:   `[ClassDeclStmt{Name: "DirectoryReader", Fields: [VarDeclStmt{VariableName: "path", ExplicitType: string}, VarDeclStmt{VariableName: "limit", IsConstant: true, AssignedValue: 10}], Methods: [FunctionDeclStmt{Name: "read", Parameters: [Parameter{Name: "n", Type: number}], ReturnType: ArrayType{Underlying: string}, Body: BlockStmt{Body: [ReturnStmt{Value: MemberExpr{Member: this, Property: "path"}}]}}]}]`,
+			spans:  []string{"class DirectoryReader { let path: string; const limit = 10; fn read(n: number): []string { return this.path; } }", "let path: string;", "string", "const limit = 10;", "10", "fn read(n: number): []string { return this.path; }", "n: number", "number", "[]string", "string", "{ return this.path; }", "return this.path;", "this.path", "this"},
+		},
+		{
+			source: "class Empty {}",
+			want:   `[ClassDeclStmt{Name: "Empty"}]`,
+			spans:  []string{"class Empty {}"},
+		},
+		{
+			source: "let reader = new DirectoryReader(\"/tmp\", 2);",
+			want:   `[VarDeclStmt{VariableName: "reader", AssignedValue: NewExpr{Instantiation: CallExpr{Method: DirectoryReader, Arguments: ["/tmp", 2]}}}]`,
+			spans:  []string{"let reader = new DirectoryReader(\"/tmp\", 2);", "new DirectoryReader(\"/tmp\", 2)", "DirectoryReader(\"/tmp\", 2)", "DirectoryReader", "\"/tmp\"", "2"},
+		},
+		{
+			source: "new Empty().read(1);",
+			want:   `[ExpressionStmt{Expression: CallExpr{Method: MemberExpr{Member: NewExpr{Instantiation: CallExpr{Method: Empty}}, Property: "read"}, Arguments: [1]}}]`,
+			spans:  []string{"new Empty().read(1);", "new Empty().read(1)", "new Empty().read", "new Empty()", "Empty()", "Empty", "1"},
+		},
+	})
+}
+
+func TestControlFlow(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "if x > 1 { f(); } else if x < 0 { g(); } else { h(); }",
+			want:   `[IfStmt{Condition: BinaryExpr{Left: x, Operator: >, Right: 1}, Consequent: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: f}}]}, Alternate: IfStmt{Condition: BinaryExpr{Left: x, Operator: <, Right: 0}, Consequent: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: g}}]}, Alternate: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: h}}]}}}]`,
+			spans:  []string{"if x > 1 { f(); } else if x < 0 { g(); } else { h(); }", "x > 1", "x", "1", "{ f(); }", "f();", "f()", "f", "if x < 0 { g(); } else { h(); }", "x < 0", "x", "0", "{ g(); }", "g();", "g()", "g", "{ h(); }", "h();", "h()", "h"},
+		},
+		{
+			source: "if ok {}",
+			want:   `[IfStmt{Condition: ok, Consequent: BlockStmt{}}]`,
+			spans:  []string{"if ok {}", "ok", "{}"},
+		},
+		{
+			source: "while i < 10 { i += 1; }",
+			want:   `[WhileStmt{Condition: BinaryExpr{Left: i, Operator: <, Right: 10}, Body: BlockStmt{Body: [ExpressionStmt{Expression: AssignmentExpr{Assigne: i, Operator: +=, Value: 1}}]}}]`,
+			spans:  []string{"while i < 10 { i += 1; }", "i < 10", "i", "10", "{ i += 1; }", "i += 1;", "i += 1", "i", "1"},
+		},
+		{
+			source: "for let i = 0; i < 3; i += 1 { f(i); }",
+			want:   `[ForStmt{Init: VarDeclStmt{VariableName: "i", AssignedValue: 0}, Condition: BinaryExpr{Left: i, Operator: <, Right: 3}, Post: AssignmentExpr{Assigne: i, Operator: +=, Value: 1}, Body: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: f, Arguments: [i]}}]}}]`,
+			spans:  []string{"for let i = 0; i < 3; i += 1 { f(i); }", "let i = 0;", "0", "i < 3", "i", "3", "i += 1", "i", "1", "{ f(i); }", "f(i);", "f(i)", "f", "i"},
+		},
+		{
+			source: "for (;;) {}",
+			want:   `[ForStmt{Body: BlockStmt{}}]`,
+			spans:  []string{"for (;;) {}", "{}"},
+		},
+		{
+			source: "foreach file in allFiles { println(file); }",
+			want:   `[ForeachStmt{Value: "file", Iterable: allFiles, Body: BlockStmt{Body: [ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [file]}}]}}]`,
+			spans:  []string{"foreach file in allFiles { println(file); }", "allFiles", "{ println(file); }", "println(file);", "println(file)", "println", "file"},
+		},
+		{
+			source: "foreach i in 0..n - 1 {}",
+			want:   `[ForeachStmt{Value: "i", Iterable: RangeExpr{Lower: 0, Upper: BinaryExpr{Left: n, Operator: -, Right: 1}}, Body: BlockStmt{}}]`,
+			spans:  []string{"foreach i in 0..n - 1 {}", "0..n - 1", "0", "n - 1", "n", "1", "{}"},
+		},
+	})
+}
+
+func TestModules(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "import { readDir, join } from \"./fs\";",
+			want:   `[ImportStmt{Names: ["readDir", "join"], From: "./fs"}]`,
+			spans:  []string{"import { readDir, join } from \"./fs\";"},
+		},
+		{
+			source: "import fs from \"../lib/fs.lang\";",
+			want:   `[ImportStmt{Alias: "fs", From: "../lib/fs.lang"}]`,
+			spans:  []string{"import fs from \"../lib/fs.lang\";"},
+		},
+		{
+			source: "export const limit: number = 10;",
+			want:   `[VarDeclStmt{VariableName: "limit", IsConstant: true, IsExported: true, AssignedValue: 10, ExplicitType: number}]`,
+			spans:  []string{"export const limit: number = 10;", "10", "number"},
+		},
+		{
+			source: "export fn read() {}",
+			want:   `[FunctionDeclStmt{Name: "read", IsExported: true, Body: BlockStmt{}}]`,
+			spans:  []string{"export fn read() {}", "{}"},
+		},
+		{
+			source: "export class Reader {}",
+			want:   `[ClassDeclStmt{Name: "Reader", IsExported: true}]`,
+			spans:  []string{"export class Reader {}"},
+		},
+	})
+}
+
+func TestDocComments(t *testing.T) {
+	runParserTests(t, []parserTest{
+		{
+			source: "// Adds two numbers\n// and returns the sum\nfn add(a: number, b: number): number { return a + b; }",
+			want:   `[FunctionDeclStmt{Name: "add", Parameters: [Parameter{Name: "a", Type: number}, Parameter{Name: "b", Type: number}], ReturnType: number, Body: BlockStmt{Body: [ReturnStmt{Value: BinaryExpr{Left: a, Operator: +, Right: b}}]}, Doc: "Adds two numbers\nand returns the sum"}]`,
+			spans:  []string{"fn add(a: number, b: number): number { return a + b; }", "a: number", "number", "b: number", "number", "number", "{ return a + b; }", "return a + b;", "a + b", "a", "b", "// Adds two numbers\n// and returns the sum"},
+		},
+		{
+			source: "// detached\n\n// Point in 2D\nclass Point {\n  // X coordinate\n  let x = 0;\n  /* not a doc */\n  fn len() {}\n}",
+			want:   `[ClassDeclStmt{Name: "Point", Fields: [VarDeclStmt{VariableName: "x", AssignedValue: 0, Doc: "X coordinate"}], Methods: [FunctionDeclStmt{Name: "len", Body: BlockStmt{}}], Doc: "Point in 2D"}]`,
+			spans:  []string{"class Point {\n  // X coordinate\n  let x = 0;\n  /* not a doc */\n  fn len() {}\n}", "let x = 0;", "0", "// X coordinate", "fn len() {}", "{}", "// Point in 2D"},
+		},
+		{
+			source: "let a = 1; // trailing\nlet b = 2;",
+			want:   `[VarDeclStmt{VariableName: "a", AssignedValue: 1}, VarDeclStmt{VariableName: "b", AssignedValue: 2}]`,
+			spans:  []string{"let a = 1;", "1", "let b = 2;", "2"},
+		},
+		{
+			source: "let a = 1; // trailing\n// doc for b\nlet b = 2;",
+			want:   `[VarDeclStmt{VariableName: "a", AssignedValue: 1}, VarDeclStmt{VariableName: "b", AssignedValue: 2, Doc: "doc for b"}]`,
+			spans:  []string{"let a = 1;", "1", "let b = 2;", "2", "// doc for b"},
+		},
+		{
+			source: "//Exported\nexport const limit = 10;",
+			want:   `[VarDeclStmt{VariableName: "limit", IsConstant: true, IsExported: true, AssignedValue: 10, Doc: "Exported"}]`,
+			spans:  []string{"export const limit = 10;", "10", "//Exported"},
+		},
+		{
+			source: "/* block */\nlet c = 3;\n// not above a declaration\nprintln(c);",
+			want:   `[VarDeclStmt{VariableName: "c", AssignedValue: 3}, ExpressionStmt{Expression: CallExpr{Method: println, Arguments: [c]}}]`,
+			spans:  []string{"let c = 3;", "3", "println(c);", "println(c)", "println", "c"},
+		},
+	})
+}
@@ -14,57 +14,56 @@ type type_nud_lookup map[lexer.TokenKind]type_nud_handler
 type type_led_lookup map[lexer.TokenKind]type_led_handler
 type type_bp_lookup map[lexer.TokenKind]binding_power
 
-var type_bp_lu = bp_lookup{}
-var type_nud_lu = type_nud_lookup{}
-var type_led_lu = type_led_lookup{}
-
-func type_led(kind lexer.TokenKind, bp binding_power, led_fn type_led_handler) {
-	type_bp_lu[kind] = bp
-	type_led_lu[kind] = led_fn
+func (g *grammar) type_led(kind lexer.TokenKind, bp binding_power, led_fn type_led_handler) {
+	g.type_bp_lu[kind] = bp
+	g.type_led_lu[kind] = led_fn
 }
 
-func type_nud(kind lexer.TokenKind, nud_fn type_nud_handler) {
-	type_nud_lu[kind] = nud_fn
+func (g *grammar) type_nud(kind lexer.TokenKind, nud_fn type_nud_handler) {
+	g.type_nud_lu[kind] = nud_fn
 }
 
-func createTokenTypeLookups() {
-	type_nud(lexer.IDENTIFIER, parse_symbol_type)
-	type_nud(lexer.OPEN_BRACKET, parse_array_type)
+func (g *grammar) createTokenTypeLookups() {
+	g.type_nud(lexer.IDENTIFIER, parse_symbol_type)
+	g.type_nud(lexer.OPEN_BRACKET, parse_array_type)
 }
 
 func parse_symbol_type(p *parser) ast.Type {
+	token := p.expect(lexer.IDENTIFIER)
 	return ast.SymbolType{
-		Name: p.expect(lexer.IDENTIFIER).Value,
+		Name: token.Value,
+		Loc:  token.Span,
 	}
 }
 
 func parse_array_type(p *parser) ast.Type {
-	p.advance()
+	start := p.advance()
 	p.expect(lexer.CLOSE_BRACKET)
 	var underlyingType = parse_type(p, default_bp)
 	return ast.ArrayType{
 		Underlying: underlyingType,
+		Loc:        start.Span.To(underlyingType.Span()),
 	}
 }
 
 func parse_type(p *parser, bp binding_power) ast.Type {
 	tokenKind := p.currentTokenKind()
-	nud_fn, exists := type_nud_lu[tokenKind]
+	nud_fn, exists := p.grammar.type_nud_lu[tokenKind]
 
 	if !exists {
-		panic(fmt.Sprintf("TYPE_NUD HANDLER EXPECTED FOR TOKEN %s\n", lexer.TokenKindString(tokenKind)))
+		p.failUnexpected(CodeExpectedType, fmt.Sprintf("Expected type but received %s instead", describeToken(p.currentToken())))
 	}
 
 	left := nud_fn(p)
-	for type_bp_lu[p.currentTokenKind()] > bp {
+	for p.grammar.type_bp_lu[p.currentTokenKind()] > bp {
 		tokenKind = p.currentTokenKind()
-		led_fn, exists := type_led_lu[tokenKind]
+		led_fn, exists := p.grammar.type_led_lu[tokenKind]
 
 		if !exists {
-			panic(fmt.Sprintf("TYPE_LED HANDLER EXPECTED FOR TOKEN %s\n", lexer.TokenKindString(tokenKind)))
+			p.failUnexpected(CodeExpectedType, fmt.Sprintf("Unexpected %s in type", describeToken(p.currentToken())))
 		}
 
-		left = led_fn(p, left, type_bp_lu[p.currentTokenKind()])
+		left = led_fn(p, left, p.grammar.type_bp_lu[p.currentTokenKind()])
 
 	}
 
@@ -0,0 +1,295 @@
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Operator precedence, mirroring the parser's binding powers
+const (
+	precLowest = iota
+	precAssignment
+	precLogical
+	precRelational
+	precAdditive
+	precMultiplicative
+	precUnary
+	precCall
+	precMember
+	precPrimary
+)
+
+// Precedence of the operator at the top of expr
+func precedence(expr ast.Expr) int {
+	switch n := expr.(type) {
+	case ast.AssignmentExpr:
+		return precAssignment
+	case ast.RangeExpr:
+		return precLogical
+	case ast.BinaryExpr:
+		switch n.Operator.Kind {
+		case lexer.AND, lexer.OR:
+			return precLogical
+		case lexer.PLUS, lexer.DASH:
+			return precAdditive
+		case lexer.STAR, lexer.SLASH, lexer.PERCENT:
+			return precMultiplicative
+		default:
+			return precRelational
+		}
+	case ast.PrefixExpr:
+		return precUnary
+	case ast.CallExpr:
+		return precCall
+	case ast.MemberExpr, ast.ComputedExpr:
+		return precMember
+	default:
+		return precPrimary
+	}
+}
+
+// Prints expr where the parser only keeps reading operators that bind tighter than outer,
+// adding parentheses when expr would otherwise be split up differently
+func (p *printer) expr(expr ast.Expr, outer int) {
+	if outer > precLowest && precedence(expr) <= outer {
+		p.write("(")
+		p.exprBody(expr)
+		p.write(")")
+		return
+	}
+	p.exprBody(expr)
+}
+
+// Operators are left associative, so a left operand only needs parentheses below the operator's precedence.
+// Calls and member accesses end on the left operand's last token and never need them.
+func (p *printer) left(expr ast.Expr, operator int) {
+	switch expr.(type) {
+	case ast.CallExpr, ast.MemberExpr, ast.ComputedExpr:
+		p.exprBody(expr)
+	default:
+		p.expr(expr, operator-1)
+	}
+}
+
+func (p *printer) exprBody(expr ast.Expr) {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		if n.Raw != "" {
+			p.write(n.Raw)
+		} else {
+			p.write(strconv.FormatFloat(n.Value, 'g', -1, 64))
+		}
+	case ast.StringExpr:
+		p.write(quoteString(n.Value))
+	case ast.TemplateExpr:
+		p.write("`" + quote(n.Strings[0], '`'))
+		for i, part := range n.Expressions {
+			p.write("${")
+			p.expr(part, precLowest)
+			p.write("}" + quote(n.Strings[i+1], '`'))
+		}
+		p.write("`")
+	case ast.SymbolExpr:
+		p.write(n.Value)
+	case ast.BinaryExpr:
+		prec := precedence(n)
+		p.left(n.Left, prec)
+		p.write(" " + n.Operator.Value + " ")
+		p.expr(n.Right, prec)
+	case ast.AssignmentExpr:
+		p.left(n.Assigne, precAssignment)
+		p.write(" " + n.Operator.Value + " ")
+		p.expr(n.Value, precAssignment)
+	case ast.RangeExpr:
+		p.left(n.Lower, precLogical)
+		p.write("..")
+		p.expr(n.Upper, precLogical)
+	case ast.PrefixExpr:
+		p.write(n.Operator.Value)
+		if n.Operator.Kind == lexer.TYPEOF {
+			p.write(" ")
+		}
+		p.expr(n.RightExpr, precUnary)
+	case ast.FunctionExpr:
+		p.write("fn ")
+		p.signature(n.Parameters, n.ReturnType)
+		p.write(" ")
+		p.block(n.Body)
+	case ast.CallExpr:
+		p.left(n.Method, precCall)
+		p.arguments(n.Arguments)
+	case ast.MemberExpr:
+		p.left(n.Member, precMember)
+		p.write("." + n.Property)
+	case ast.ComputedExpr:
+		p.left(n.Member, precMember)
+		p.write("[")
+		p.expr(n.Property, precLowest)
+		p.write("]")
+	case ast.NewExpr:
+		// the class expression ends at the first argument list
+		p.write("new ")
+		if hasCall(n.Instantiation.Method) {
+			p.write("(")
+			p.exprBody(n.Instantiation.Method)
+			p.write(")")
+		} else {
+			p.expr(n.Instantiation.Method, precCall)
+		}
+		p.arguments(n.Instantiation.Arguments)
+	case ast.ArrayLiteral:
+		p.write("[")
+		p.list(n.Contents)
+		p.write("]")
+	case ast.ObjectLiteral:
+		p.object(n)
+	case ast.BadExpr:
+		p.write("/* invalid expression */")
+	}
+}
+
+// `(a, b, c)`
+func (p *printer) arguments(arguments []ast.Expr) {
+	p.write("(")
+	p.list(arguments)
+	p.write(")")
+}
+
+// Comma separated expressions
+func (p *printer) list(exprs []ast.Expr) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expr(expr, precLowest)
+	}
+}
+
+// `{ key: value, [computed]: value, shorthand }`
+func (p *printer) object(n ast.ObjectLiteral) {
+	if len(n.Properties) == 0 {
+		p.write("{}")
+		return
+	}
+
+	p.write("{ ")
+	for i, property := range n.Properties {
+		if i > 0 {
+			p.write(", ")
+		}
+
+		switch {
+		case property.ComputedKey != nil:
+			p.write("[")
+			p.expr(property.ComputedKey, precLowest)
+			p.write("]")
+		case isIdentifier(property.Key):
+			p.write(property.Key)
+		default:
+			p.write(quote(property.Key, '"'))
+		}
+
+		if !property.Shorthand {
+			p.write(": ")
+			p.expr(property.Value, precLowest)
+		}
+	}
+	p.write(" }")
+}
+
+// Reports whether the printed expression would begin with `{`
+func startsWithObject(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case ast.ObjectLiteral:
+		return true
+	case ast.BinaryExpr:
+		return precedence(n.Left) >= precedence(n) && startsWithObject(n.Left)
+	case ast.AssignmentExpr:
+		return precedence(n.Assigne) >= precAssignment && startsWithObject(n.Assigne)
+	case ast.RangeExpr:
+		return precedence(n.Lower) >= precLogical && startsWithObject(n.Lower)
+	case ast.CallExpr:
+		return startsWithObject(n.Method)
+	case ast.MemberExpr:
+		return startsWithObject(n.Member)
+	case ast.ComputedExpr:
+		return startsWithObject(n.Member)
+	default:
+		return false
+	}
+}
+
+// Reports whether expr contains a call along its chain of member accesses
+func hasCall(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case ast.CallExpr:
+		return true
+	case ast.MemberExpr:
+		return hasCall(n.Member)
+	case ast.ComputedExpr:
+		return hasCall(n.Member)
+	default:
+		return false
+	}
+}
+
+// Reports whether name can be written without quotes as an object key
+func isIdentifier(name string) bool {
+	if name == "" || lexer.IsKeyword(name) {
+		return false
+	}
+	for i, ch := range name {
+		if !(ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || i > 0 && ch >= '0' && ch <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Quotes a string literal with double quotes, or as a raw string when that saves escaping backslashes
+func quoteString(value string) string {
+	if strings.Contains(value, `\`) && !strings.ContainsFunc(value, func(ch rune) bool { return ch == '"' || ch < ' ' || ch == 0x7f }) {
+		return `r"` + value + `"`
+	}
+	return quote(value, '"')
+}
+
+// Escapes value for a literal delimited by delim. The delimiters themselves are only added for quotes,
+// template pieces are written between the backtick and ${ by the caller.
+func quote(value string, delim byte) string {
+	var out strings.Builder
+	if delim != '`' {
+		out.WriteByte(delim)
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch ch := value[i]; {
+		case ch == '\\' || ch == delim:
+			out.WriteByte('\\')
+			out.WriteByte(ch)
+		case ch == '$' && delim == '`' && i+1 < len(value) && value[i+1] == '{':
+			out.WriteString(`\$`)
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case ch == '\r':
+			out.WriteString(`\r`)
+		case ch == 0:
+			out.WriteString(`\0`)
+		case ch < ' ' || ch == 0x7f:
+			fmt.Fprintf(&out, `\u{%X}`, ch)
+		default:
+			out.WriteByte(ch)
+		}
+	}
+
+	if delim != '`' {
+		out.WriteByte(delim)
+	}
+	return out.String()
+}
@@ -0,0 +1,380 @@
+package printer
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Indentation used for every nesting level
+const indentation = "  "
+
+// Holds the output being built
+// comments: source comments not printed yet, in source order
+// lastLine: source line where the last printed statement or comment ended, used to keep blank lines
+// first: nothing has been printed in the current block yet
+// blockEnd: offset of the `}` closing the current block, comments after it belong outside the block
+type printer struct {
+	buf      bytes.Buffer
+	indent   int
+	comments []lexer.Comment
+	lastLine int
+	first    bool
+	blockEnd int
+}
+
+// Format parses source and prints it back in canonical form.
+// Source with syntax errors is not formatted, the errors are returned instead.
+func Format(file string, source []byte) ([]byte, []lexer.Diagnostic) {
+	tokens, lexErrors := lexer.TokenizeFile(file, string(source))
+	program, parseErrors := parser.Parse(tokens)
+
+	diagnostics := append(lexErrors, parseErrors...)
+	if lexer.HasErrors(diagnostics) {
+		return nil, diagnostics
+	}
+
+	return Source(program, Comments(tokens)), diagnostics
+}
+
+// Comments collects the comments kept as trivia on tokens, in source order
+func Comments(tokens []lexer.Token) []lexer.Comment {
+	comments := make([]lexer.Comment, 0)
+	for _, token := range tokens {
+		comments = append(comments, token.Comments...)
+	}
+	return comments
+}
+
+// Source prints program as canonical source
+//
+// - Statements are indented by nesting level, one per line, with canonical spacing inside them
+//
+// - Blank lines between statements are kept, collapsed to one
+//
+// - comments are printed on their own line before the statement that follows them, or at the end
+// of the statement they trail; comments in the middle of a statement move after it, or to the end
+// of the `{` line when they come before one of its blocks
+func Source(program ast.BlockStmt, comments []lexer.Comment) []byte {
+	p := &printer{comments: comments, first: true, blockEnd: math.MaxInt}
+
+	for _, stmt := range program.Body {
+		p.stmt(stmt)
+	}
+	p.flushComments(math.MaxInt)
+
+	return p.buf.Bytes()
+}
+
+// Fprint writes the canonical source of program to w
+func Fprint(w io.Writer, program ast.BlockStmt, comments []lexer.Comment) error {
+	_, err := w.Write(Source(program, comments))
+	return err
+}
+
+func (p *printer) write(text string) {
+	p.buf.WriteString(text)
+}
+
+// Starts a new output line at the current indentation
+func (p *printer) startLine() {
+	p.write(strings.Repeat(indentation, p.indent))
+}
+
+// Keeps a single blank line if the source had at least one before line
+func (p *printer) separate(line int) {
+	if !p.first && line > p.lastLine+1 {
+		p.write("\n")
+	}
+	p.first = false
+}
+
+// Prints the comments that start before offset, each on its own line
+func (p *printer) flushComments(offset int) {
+	for len(p.comments) > 0 && p.comments[0].Span.Start.Offset < offset {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.separate(comment.Span.Start.Line)
+		p.startLine()
+		p.write(comment.Text)
+		p.write("\n")
+		p.lastLine = comment.Span.End.Line
+	}
+}
+
+// Prints the comments inside the statement that just ended or on its last line, up to the first line comment.
+// Comments after the `}` of the enclosing block stay outside it, even when the block is on one line.
+func (p *printer) trailingComments(end lexer.Position) {
+	for len(p.comments) > 0 && p.comments[0].Span.Start.Offset < p.blockEnd && (p.comments[0].Span.Start.Offset < end.Offset || p.comments[0].Span.Start.Line == end.Line) {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.write(" ")
+		p.write(comment.Text)
+		p.lastLine = max(p.lastLine, comment.Span.End.Line)
+		if !comment.IsBlock() {
+			break // the line is over, the rest go on lines of their own
+		}
+	}
+}
+
+// Prints the comments that start before offset at the end of the current line, up to the first line comment
+func (p *printer) commentsBefore(offset int) {
+	for len(p.comments) > 0 && p.comments[0].Span.Start.Offset < offset {
+		comment := p.comments[0]
+		p.comments = p.comments[1:]
+
+		p.write(" ")
+		p.write(comment.Text)
+		p.lastLine = max(p.lastLine, comment.Span.End.Line)
+		if !comment.IsBlock() {
+			break // the line is over, the rest go on lines of their own
+		}
+	}
+}
+
+// Prints a statement on its own line(s), with the comments around it
+func (p *printer) stmt(stmt ast.Stmt) {
+	span := stmt.Span()
+
+	p.flushComments(span.Start.Offset)
+	p.separate(span.Start.Line)
+	p.startLine()
+	p.stmtBody(stmt)
+	p.lastLine = span.End.Line
+	p.trailingComments(span.End)
+	p.write("\n")
+}
+
+// Prints `{ statements }`, leaving the cursor after the closing brace.
+// Comments in the header before the block, such as on parameters, and those after `{` on its
+// line go at the end of the `{` line.
+func (p *printer) block(block ast.BlockStmt) {
+	start, end := block.Span().Start, block.Span().End.Offset
+
+	first := end
+	if len(block.Body) > 0 {
+		first = block.Body[0].Span().Start.Offset
+	}
+	header, inside := start.Offset, false
+	for _, comment := range p.comments {
+		if comment.Span.Start.Offset >= start.Offset {
+			inside = comment.Span.Start.Offset < end
+			break
+		}
+	}
+	for _, comment := range p.comments {
+		if comment.Span.Start.Offset >= start.Offset && (comment.Span.Start.Line != start.Line || comment.Span.Start.Offset >= first) {
+			break
+		}
+		header = comment.Span.End.Offset
+	}
+
+	if len(block.Body) == 0 && !inside {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.commentsBefore(header)
+	p.write("\n")
+	p.indent++
+	p.first, p.lastLine = true, start.Line
+	outerEnd := p.blockEnd
+	p.blockEnd = end - 1
+
+	for _, stmt := range block.Body {
+		p.stmt(stmt)
+	}
+	p.flushComments(end - 1)
+
+	p.blockEnd = outerEnd
+	p.indent--
+	p.startLine()
+	p.write("}")
+}
+
+// Prints a statement without indentation or the line break after it
+func (p *printer) stmtBody(stmt ast.Stmt) {
+	switch n := stmt.(type) {
+	case ast.BlockStmt:
+		p.block(n)
+	case ast.ExpressionStmt:
+		if startsWithObject(n.Expression) {
+			// a statement starting with { would be read back as a block
+			p.write("(")
+			p.expr(n.Expression, precLowest)
+			p.write(");")
+		} else {
+			p.expr(n.Expression, precLowest)
+			p.write(";")
+		}
+	case ast.VarDeclStmt:
+		p.varDecl(n)
+	case ast.FunctionDeclStmt:
+		p.fnDecl(n)
+	case ast.ReturnStmt:
+		if n.Value == nil {
+			p.write("return;")
+		} else {
+			p.write("return ")
+			p.expr(n.Value, precLowest)
+			p.write(";")
+		}
+	case ast.ClassDeclStmt:
+		p.classDecl(n)
+	case ast.IfStmt:
+		p.write("if ")
+		p.expr(n.Condition, precLowest)
+		p.write(" ")
+		p.block(n.Consequent)
+		if n.Alternate == nil {
+			break
+		}
+		if alternate := n.Alternate.Span().Start.Offset; len(p.comments) > 0 && p.comments[0].Span.Start.Offset < alternate {
+			// comments after the consequent stay after it, with else on the next line
+			p.commentsBefore(alternate)
+			p.write("\n")
+			p.startLine()
+			p.write("else ")
+		} else {
+			p.write(" else ")
+		}
+		p.stmtBody(n.Alternate)
+	case ast.WhileStmt:
+		p.write("while ")
+		p.expr(n.Condition, precLowest)
+		p.write(" ")
+		p.block(n.Body)
+	case ast.ForStmt:
+		p.forStmt(n)
+	case ast.ForeachStmt:
+		p.write("foreach " + n.Value + " in ")
+		p.expr(n.Iterable, precLowest)
+		p.write(" ")
+		p.block(n.Body)
+	case ast.ImportStmt:
+		p.write("import ")
+		if n.Alias != "" {
+			p.write(n.Alias)
+		} else {
+			p.write("{ " + strings.Join(n.Names, ", ") + " }")
+		}
+		p.write(" from " + quote(n.From, '"') + ";")
+	case ast.BadStmt:
+		p.write("/* invalid statement */")
+	}
+}
+
+// `let name: T = value;`
+func (p *printer) varDecl(n ast.VarDeclStmt) {
+	if n.IsExported {
+		p.write("export ")
+	}
+	if n.IsConstant {
+		p.write("const ")
+	} else {
+		p.write("let ")
+	}
+
+	p.write(n.VariableName)
+	if n.ExplicitType != nil {
+		p.write(": ")
+		p.typ(n.ExplicitType)
+	}
+	if n.AssignedValue != nil {
+		p.write(" = ")
+		p.expr(n.AssignedValue, precAssignment)
+	}
+	p.write(";")
+}
+
+// `fn name(a: T): R { ... }`
+func (p *printer) fnDecl(n ast.FunctionDeclStmt) {
+	if n.IsExported {
+		p.write("export ")
+	}
+	p.write("fn " + n.Name)
+	p.signature(n.Parameters, n.ReturnType)
+	p.write(" ")
+	p.block(n.Body)
+}
+
+// `(a: T, b: T): R`
+func (p *printer) signature(parameters []ast.Parameter, returnType ast.Type) {
+	p.write("(")
+	for i, param := range parameters {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Name + ": ")
+		p.typ(param.Type)
+	}
+	p.write(")")
+
+	if returnType != nil {
+		p.write(": ")
+		p.typ(returnType)
+	}
+}
+
+// `class Name { ... }` with fields and methods in source order
+func (p *printer) classDecl(n ast.ClassDeclStmt) {
+	if n.IsExported {
+		p.write("export ")
+	}
+	p.write("class " + n.Name + " ")
+
+	members := make([]ast.Stmt, 0, len(n.Fields)+len(n.Methods))
+	for _, field := range n.Fields {
+		members = append(members, field)
+	}
+	for _, method := range n.Methods {
+		members = append(members, method)
+	}
+	sort.SliceStable(members, func(i, j int) bool {
+		return members[i].Span().Start.Offset < members[j].Span().Start.Offset
+	})
+
+	p.block(ast.BlockStmt{Body: members, Loc: n.Loc})
+}
+
+// `for init; condition; post { ... }`
+func (p *printer) forStmt(n ast.ForStmt) {
+	p.write("for ")
+	if n.Init != nil {
+		p.stmtBody(n.Init)
+	} else {
+		p.write(";")
+	}
+
+	if n.Condition != nil {
+		p.write(" ")
+		p.expr(n.Condition, precLowest)
+	}
+	p.write(";")
+
+	if n.Post != nil {
+		p.write(" ")
+		p.expr(n.Post, precLowest)
+	}
+	p.write(" ")
+	p.block(n.Body)
+}
+
+func (p *printer) typ(typ ast.Type) {
+	switch n := typ.(type) {
+	case ast.SymbolType:
+		p.write(n.Name)
+	case ast.ArrayType:
+		p.write("[]")
+		p.typ(n.Underlying)
+	}
+}
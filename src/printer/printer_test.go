@@ -0,0 +1,222 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Syntax tree of source without positions or comments, and its comments
+func parseTree(t *testing.T, source []byte) (any, []string) {
+	t.Helper()
+	tokens, lexErrors := lexer.Tokenize(string(source))
+	program, parseErrors := parser.Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); lexer.HasErrors(errors) {
+		t.Fatalf("syntax errors in\n%s\n%v", source, errors)
+	}
+
+	var comments []string
+	for _, comment := range Comments(tokens) {
+		comments = append(comments, comment.Text)
+	}
+	return withoutTrivia(reflect.ValueOf(program)), comments
+}
+
+var span_type = reflect.TypeOf(lexer.Span{})
+
+// Copy of a syntax tree as maps, slices and values, leaving out spans and the comments on tokens.
+// Doc comments become their text. Empty and nil slices are the same.
+func withoutTrivia(v reflect.Value) any {
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch node := v.Interface().(type) {
+	case lexer.Token:
+		return lexer.TokenKindString(node.Kind) + " " + node.Value
+	case ast.CommentGroup:
+		return node.Text()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := map[string]any{"type": v.Type().Name()}
+		for i := 0; i < v.NumField(); i++ {
+			if field := v.Type().Field(i); field.IsExported() && field.Type != span_type {
+				fields[field.Name] = withoutTrivia(v.Field(i))
+			}
+		}
+		return fields
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+		elements := make([]any, v.Len())
+		for i := range elements {
+			elements[i] = withoutTrivia(v.Index(i))
+		}
+		return elements
+	default:
+		return v.Interface()
+	}
+}
+
+// Printing keeps the syntax tree and the comments, and printing the result again changes nothing
+func checkRoundTrip(t *testing.T, source []byte) []byte {
+	t.Helper()
+	formatted, diagnostics := Format("test.lang", source)
+	if lexer.HasErrors(diagnostics) {
+		t.Fatalf("Format: %v", diagnostics)
+	}
+
+	tree, comments := parseTree(t, source)
+	formattedTree, formattedComments := parseTree(t, formatted)
+	if !reflect.DeepEqual(formattedTree, tree) {
+		t.Errorf("syntax tree changed by printing\n%s", formatted)
+	}
+	if !reflect.DeepEqual(formattedComments, comments) {
+		t.Errorf("comments %q became %q\n%s", comments, formattedComments, formatted)
+	}
+
+	again, _ := Format("test.lang", formatted)
+	if string(again) != string(formatted) {
+		t.Errorf("printing twice is not stable\nfirst:\n%s\nsecond:\n%s", formatted, again)
+	}
+	return formatted
+}
+
+func TestRoundTripExamples(t *testing.T) {
+	files, err := filepath.Glob("../../examples/*.lang")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no examples: %v", err)
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			source, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkRoundTrip(t, source)
+		})
+	}
+}
+
+// Prefix operators print next to their operand, except typeof which is a word. A prefix operand is parenthesised.
+func TestPrefixOperators(t *testing.T) {
+	source := "let a = - x + !(typeof  -y == \"number\");\nlet b = typeof typeof(a);\n"
+	want := "let a = -x + !(typeof (-y) == \"number\");\nlet b = typeof (typeof a);\n"
+	if got := checkRoundTrip(t, []byte(source)); string(got) != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "leading and trailing",
+			source: "// leading\nlet x = 1;   // trailing\n\n\n/* block */\nlet y = 2;\n// end\n",
+			want:   "// leading\nlet x = 1; // trailing\n\n/* block */\nlet y = 2;\n// end\n",
+		},
+		{
+			name:   "after if before else",
+			source: "if a {\n} // after if\nelse {\n  println(a);\n}\n",
+			want:   "if a {} // after if\nelse {\n  println(a);\n}\n",
+		},
+		{
+			name:   "between else if branches",
+			source: "if a {} /* a */ else if b {} // b\nelse {}\n",
+			want:   "if a {} /* a */\nelse if b {} // b\nelse {}\n",
+		},
+		{
+			name:   "on a parameter",
+			source: "fn f(a: number, // first\n  b: number) {\n  return a;\n}\n",
+			want:   "fn f(a: number, b: number) { // first\n  return a;\n}\n",
+		},
+		{
+			name:   "on a parameter of an empty function",
+			source: "fn f(a: number /* a */,\n  b: number) {}\n",
+			want:   "fn f(a: number, b: number) {} /* a */\n",
+		},
+		{
+			name:   "after the opening brace",
+			source: "while a { // loop\n  a = f(); // step\n}\nif a { // empty\n}\n",
+			want:   "while a { // loop\n  a = f(); // step\n}\nif a { // empty\n}\n",
+		},
+		{
+			name:   "after a line comment on the same line",
+			source: "fn f(a: number, // first\n  b: number /* second */) { // brace\n  return a;\n}\n",
+			want:   "fn f(a: number, b: number) { // first\n  /* second */\n  // brace\n  return a;\n}\n",
+		},
+		{
+			name:   "after a single-line block",
+			source: "if a { x(); } // after if\nwhile b { y(); /* in */ } /* after */ // while\nfn f() { return 1; } // f\n",
+			want:   "if a {\n  x();\n} // after if\nwhile b {\n  y(); /* in */\n} /* after */ // while\nfn f() {\n  return 1;\n} // f\n",
+		},
+		{
+			name:   "in the middle of a statement",
+			source: "let x = f(1, // one\n  2);\nlet y = 3;\n",
+			want:   "let x = f(1, 2); // one\nlet y = 3;\n",
+		},
+		{
+			name:   "in classes",
+			source: "class C { // class\n  let v = 1; // field\n\n  // method\n  fn m() {}\n}\n",
+			want:   "class C { // class\n  let v = 1; // field\n\n  // method\n  fn m() {}\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := checkRoundTrip(t, []byte(test.source))
+			if string(got) != test.want {
+				t.Errorf("printed:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+// Statements of every kind with a comment around each of their parts
+func TestRoundTripCommentsEverywhere(t *testing.T) {
+	source := `import { a, b } from "./lib"; // import
+export const limit: number = 10; /* limit */
+/* before */ fn add(x: number /* x */, y: number) /* returns */ : number /* body */ {
+  return /* value */ x + y; // sum
+}
+class Point { /* fields */
+  let x: number = 0; // x
+  // y
+  let y: number = 0;
+  fn norm(): number { // method
+    return this.x * this.x /* times */ + this.y * this.y;
+  }
+}
+foreach item /* item */ in [1, 2, 3] { // each
+  println(item);
+}
+for let i = 0; i < limit; /* post */ i = i + 1 { // for
+  if i > 5 { break_out(); } // break
+  else /* else */ { continue_on(); }
+}
+while /* condition */ limit > 0 {
+  // nothing
+}
+let f = fn(n: number) { /* closure */ return n; };
+// trailing
+`
+	formatted := checkRoundTrip(t, []byte(source))
+	if strings.Count(string(formatted), "//")+strings.Count(string(formatted), "/*") != strings.Count(source, "//")+strings.Count(source, "/*") {
+		t.Errorf("comments lost\n%s", formatted)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thutasann/go-parser/src/interp"
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/vm"
+)
+
+// Prints diagnostics along with the source line they point at
+// sources: lines of every file seen so far, read from disk the first time a file is needed
+type reporter struct {
+	out     io.Writer
+	sources map[string][]string
+}
+
+func newReporter(out io.Writer) *reporter {
+	return &reporter{
+		out:     out,
+		sources: map[string][]string{},
+	}
+}
+
+// Registers source that doesn't come from a file on disk, like standard input
+func (r *reporter) addSource(file string, source []byte) {
+	r.sources[file] = strings.Split(string(source), "\n")
+}
+
+// Returns the lines of file, or nil if it cannot be read
+func (r *reporter) lines(file string) []string {
+	if lines, exists := r.sources[file]; exists {
+		return lines
+	}
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		r.sources[file] = nil
+		return nil
+	}
+	r.addSource(file, source)
+	return r.sources[file]
+}
+
+// Prints every diagnostic and reports whether any of them is an error
+func (r *reporter) diagnostics(diagnostics []lexer.Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		r.print(fmt.Sprintf("%s[%s]", diagnostic.Severity, diagnostic.Code), diagnostic.Message, diagnostic.Span)
+	}
+	return lexer.HasErrors(diagnostics)
+}
+
+// Prints an error returned by the interpreter or the virtual machine
+func (r *reporter) runtimeError(err error) {
+	switch runtimeErr := err.(type) {
+	case *interp.RuntimeError:
+		r.print("runtime error", runtimeErr.Message, runtimeErr.Span)
+		return
+	case *vm.RuntimeError:
+		r.print("runtime error", runtimeErr.Message, runtimeErr.Span)
+		return
+	}
+	fmt.Fprintf(r.out, "runtime error: %v\n", err)
+}
+
+// Prints a message with the source it points at:
+//
+//	error[P0001]: Expected semi_colon but received eof instead
+//	 --> main.lang:3:9
+//	  |
+//	3 | let x = 1
+//	  |         ^
+func (r *reporter) print(header string, message string, span lexer.Span) {
+	fmt.Fprintf(r.out, "%s: %s\n", header, message)
+
+	file := span.File
+	if file == "" {
+		file = "<input>"
+	}
+	if span.Start.Line == 0 {
+		fmt.Fprintf(r.out, " --> %s\n", file)
+		return
+	}
+	fmt.Fprintf(r.out, " --> %s\n", span)
+
+	lines := r.lines(span.File)
+	if span.Start.Line > len(lines) {
+		return
+	}
+	line := strings.TrimSuffix(lines[span.Start.Line-1], "\r")
+
+	number := strconv.Itoa(span.Start.Line)
+	gutter := strings.Repeat(" ", len(number))
+	fmt.Fprintf(r.out, "%s |\n", gutter)
+	fmt.Fprintf(r.out, "%s | %s\n", number, line)
+	fmt.Fprintf(r.out, "%s | %s\n", gutter, caret(line, span))
+}
+
+// Underlines span on its first line, keeping tabs so the carets line up with the source
+func caret(line string, span lexer.Span) string {
+	start := min(span.Start.Column-1, len(line))
+	end := len(line)
+	if span.End.Line == span.Start.Line {
+		end = min(span.End.Column-1, len(line))
+	}
+
+	var out strings.Builder
+	for _, ch := range line[:start] {
+		if ch == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+	}
+	out.WriteString(strings.Repeat("^", max(utf8.RuneCountInString(line[start:end]), 1)))
+	return out.String()
+}
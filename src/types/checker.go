@@ -0,0 +1,402 @@
+package types
+
+import (
+	"sort"
+
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Type checker diagnostic codes
+const (
+	CodeUnknownType      lexer.DiagnosticCode = "T0001" // type name that is neither builtin nor a class
+	CodeTypeMismatch     lexer.DiagnosticCode = "T0002" // value not assignable to the expected type
+	CodeUndefinedName    lexer.DiagnosticCode = "T0003" // reference to an undeclared name
+	CodeInvalidOperation lexer.DiagnosticCode = "T0004" // operator applied to unsupported operand types
+	CodeArgumentCount    lexer.DiagnosticCode = "T0005" // call with the wrong number of arguments
+	CodeUnknownMember    lexer.DiagnosticCode = "T0006" // member that the type doesn't have
+	CodeAssignToConstant lexer.DiagnosticCode = "T0007" // assignment to a const variable
+	CodeNotCallable      lexer.DiagnosticCode = "T0008" // call of a value that is not a function
+	CodeRedeclared       lexer.DiagnosticCode = "T0009" // name declared twice in the same scope
+	CodeInvalidReturn    lexer.DiagnosticCode = "T0010" // return outside a function or without a value
+)
+
+// Info records the types Check found, for tools working on checked programs such as code generators
+//
+// - Types holds the type of every expression, keyed by its span
+//
+// - Defs holds the type of every declared name, keyed by the span of its declaration: variables,
+// functions, parameters, classes (as a *ClassRef), imports and foreach variables, the latter keyed
+// by the span of their foreach statement
+type Info struct {
+	Types map[lexer.Span]Type
+	Defs  map[lexer.Span]Type
+}
+
+// Holds the checking state
+// returnType: declared return type of the enclosing function, nil at the top level
+// hoisting: set while inferring field types, which may use names that are still pending
+// signatures: signature of every function and method declaration, keyed by its span,
+// so redeclarations have their bodies checked against their own parameters
+type checker struct {
+	diagnostics []lexer.Diagnostic
+	returnType  Type
+	info        *Info
+	signatures  map[lexer.Span]*Function
+	hoisting    bool
+}
+
+// Check resolves the declared types of a program, infers the types of its expressions
+// and reports every type error it finds
+func Check(program ast.BlockStmt) []lexer.Diagnostic {
+	_, diagnostics := CheckInfo(program)
+	return diagnostics
+}
+
+// CheckInfo checks a program like Check and also returns the types it found
+func CheckInfo(program ast.BlockStmt) (*Info, []lexer.Diagnostic) {
+	c := &checker{
+		info:       &Info{Types: map[lexer.Span]Type{}, Defs: map[lexer.Span]Type{}},
+		signatures: map[lexer.Span]*Function{},
+	}
+	c.checkBlock(program.Body, newScope(universe()))
+
+	// function bodies are checked after the statements around them
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		return c.diagnostics[i].Span.Start.Offset < c.diagnostics[j].Span.Start.Offset
+	})
+	return c.info, c.diagnostics
+}
+
+// Records a type error
+func (c *checker) errorf(code lexer.DiagnosticCode, span lexer.Span, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, lexer.Errorf(code, span, format, args...))
+}
+
+// Reports a mismatch unless value can be assigned to target
+func (c *checker) assignable(value Type, target Type, span lexer.Span, context string) {
+	if !AssignableTo(value, target) {
+		c.errorf(CodeTypeMismatch, span, "cannot use %s as %s in %s", value, target, context)
+	}
+}
+
+// Declares a name, reporting a redeclaration in the same scope
+func (c *checker) declare(s *scope, name string, typ Type, constant bool, span lexer.Span) {
+	if !s.declare(name, typ, constant) {
+		c.errorf(CodeRedeclared, span, "%s is already declared in this scope", name)
+		return
+	}
+	c.info.Defs[span] = typ
+}
+
+// Resolves a type written in the source
+func (c *checker) resolveType(t ast.Type, s *scope) Type {
+	switch n := t.(type) {
+	case ast.SymbolType:
+		switch n.Name {
+		case "number":
+			return Number
+		case "string":
+			return String
+		case "boolean":
+			return Boolean
+		case "void":
+			return Void
+		case "any":
+			return Any
+		}
+
+		if obj, exists := s.lookup(n.Name); exists {
+			if ref, ok := obj.typ.(*ClassRef); ok {
+				return ref.Class
+			}
+		}
+
+		c.errorf(CodeUnknownType, n.Span(), "unknown type %s", n.Name)
+		return Any
+	case ast.ArrayType:
+		return &Array{Elem: c.resolveType(n.Underlying, s)}
+	default:
+		return Any
+	}
+}
+
+// Builds the type of a function from its parameters and optional return type
+func (c *checker) signature(parameters []ast.Parameter, returnType ast.Type, s *scope) *Function {
+	fn := &Function{Return: Any}
+	for _, parameter := range parameters {
+		fn.Params = append(fn.Params, c.resolveType(parameter.Type, s))
+	}
+	if returnType != nil {
+		fn.Return = c.resolveType(returnType, s)
+	}
+	return fn
+}
+
+// Checks a list of statements in scope s, following the runtimes: function and class
+// declarations create their names when they are reached, and function bodies look names up
+// when they run. Classes and functions are declared up front so their types are known, but
+// stay pending until reached, and their bodies are checked once the whole block has been.
+func (c *checker) checkBlock(body []ast.Stmt, s *scope) {
+	for _, stmt := range body {
+		if decl, ok := stmt.(ast.ClassDeclStmt); ok {
+			class := &Class{Name: decl.Name, Fields: map[string]Type{}, Methods: map[string]*Function{}}
+			c.hoist(s, decl.Name, &ClassRef{Class: class}, decl.Span())
+		}
+	}
+
+	for _, stmt := range body {
+		if decl, ok := stmt.(ast.FunctionDeclStmt); ok {
+			fn := c.signature(decl.Parameters, decl.ReturnType, s)
+			c.signatures[decl.Span()] = fn
+			c.hoist(s, decl.Name, fn, decl.Span())
+		}
+	}
+
+	// after the functions, since field types may be inferred from calls
+	for _, stmt := range body {
+		if decl, ok := stmt.(ast.ClassDeclStmt); ok {
+			c.declareMembers(decl, s)
+		}
+	}
+
+	var deferred []ast.Stmt
+	for _, stmt := range body {
+		switch decl := stmt.(type) {
+		case ast.FunctionDeclStmt:
+			c.reach(s, decl.Name)
+			deferred = append(deferred, stmt)
+		case ast.ClassDeclStmt:
+			c.reach(s, decl.Name)
+			deferred = append(deferred, stmt)
+		default:
+			c.checkStmt(stmt, s)
+		}
+	}
+
+	for _, stmt := range deferred {
+		c.checkStmt(stmt, s)
+	}
+}
+
+// Declares a function or class before its declaration is reached
+func (c *checker) hoist(s *scope, name string, typ Type, span lexer.Span) {
+	if _, exists := s.objects[name]; exists {
+		c.errorf(CodeRedeclared, span, "%s is already declared in this scope", name)
+		return
+	}
+	c.declare(s, name, typ, true, span)
+	s.objects[name].pending = true
+}
+
+// Marks a hoisted name as declared once the statement declaring it is reached
+func (c *checker) reach(s *scope, name string) {
+	if obj, exists := s.objects[name]; exists {
+		obj.pending = false
+	}
+}
+
+// Finds a name, reporting uses of undeclared names and of functions and classes
+// before their declaration is reached
+func (c *checker) lookup(name string, s *scope, span lexer.Span) (*object, bool) {
+	obj, exists := s.lookup(name)
+	if !exists {
+		c.errorf(CodeUndefinedName, span, "%s is not defined", name)
+		return nil, false
+	}
+	if obj.pending && !c.hoisting {
+		c.errorf(CodeUndefinedName, span, "%s is used before its declaration", name)
+	}
+	return obj, true
+}
+
+// Fills in the field and method types of a hoisted class
+func (c *checker) declareMembers(decl ast.ClassDeclStmt, s *scope) {
+	obj, _ := s.lookup(decl.Name)
+	ref, ok := obj.typ.(*ClassRef)
+	if !ok {
+		return
+	}
+
+	// field initializers run when instances are created, after the block's declarations
+	c.hoisting = true
+	defer func() { c.hoisting = false }()
+
+	for _, field := range decl.Fields {
+		if _, exists := ref.Class.Fields[field.VariableName]; exists {
+			c.errorf(CodeRedeclared, field.Span(), "%s is already declared in class %s", field.VariableName, decl.Name)
+			continue
+		}
+		if field.ExplicitType != nil {
+			ref.Class.Fields[field.VariableName] = c.resolveType(field.ExplicitType, s)
+		} else {
+			ref.Class.Fields[field.VariableName] = widen(c.expr(field.AssignedValue, s))
+		}
+	}
+
+	for _, method := range decl.Methods {
+		fn := c.signature(method.Parameters, method.ReturnType, s)
+		c.signatures[method.Span()] = fn
+		if _, exists := ref.Class.Methods[method.Name]; exists {
+			c.errorf(CodeRedeclared, method.Span(), "%s is already declared in class %s", method.Name, decl.Name)
+			continue
+		}
+		ref.Class.Methods[method.Name] = fn
+	}
+}
+
+func (c *checker) checkStmt(stmt ast.Stmt, s *scope) {
+	switch n := stmt.(type) {
+	case ast.ExpressionStmt:
+		c.expr(n.Expression, s)
+	case ast.BlockStmt:
+		c.checkBlock(n.Body, newScope(s))
+	case ast.VarDeclStmt:
+		c.checkVarDecl(n, s)
+	case ast.FunctionDeclStmt:
+		c.checkFunctionBody(n.Parameters, c.signatures[n.Span()], n.Body, s, nil)
+	case ast.ClassDeclStmt:
+		c.checkClass(n, s)
+	case ast.ReturnStmt:
+		c.checkReturn(n, s)
+	case ast.IfStmt:
+		c.condition(n.Condition, s)
+		c.checkBlock(n.Consequent.Body, newScope(s))
+		if n.Alternate != nil {
+			c.checkStmt(n.Alternate, s)
+		}
+	case ast.WhileStmt:
+		c.condition(n.Condition, s)
+		c.checkBlock(n.Body.Body, newScope(s))
+	case ast.ForStmt:
+		scope := newScope(s)
+		if n.Init != nil {
+			c.checkStmt(n.Init, scope)
+		}
+		if n.Condition != nil {
+			c.condition(n.Condition, scope)
+		}
+		if n.Post != nil {
+			c.expr(n.Post, scope)
+		}
+		c.checkBlock(n.Body.Body, newScope(scope))
+	case ast.ForeachStmt:
+		scope := newScope(s)
+		c.declare(scope, n.Value, c.elementType(n.Iterable, s), false, n.Span())
+		c.checkBlock(n.Body.Body, scope)
+	case ast.ImportStmt:
+		// imported declarations are not checked across modules yet
+		if n.Alias != "" {
+			c.declare(s, n.Alias, Any, true, n.Span())
+		}
+		for _, name := range n.Names {
+			c.declare(s, name, Any, true, n.Span())
+		}
+	}
+}
+
+func (c *checker) checkVarDecl(n ast.VarDeclStmt, s *scope) {
+	var declared, value Type
+
+	if n.ExplicitType != nil {
+		declared = c.resolveType(n.ExplicitType, s)
+	}
+	if n.AssignedValue != nil {
+		value = c.expr(n.AssignedValue, s)
+	}
+
+	typ := declared
+	if declared != nil && value != nil {
+		c.assignable(value, declared, n.AssignedValue.Span(), "variable declaration")
+	} else if declared == nil {
+		typ = widen(value)
+	}
+
+	c.declare(s, n.VariableName, typ, n.IsConstant, n.Span())
+}
+
+// Checks field initializers and method bodies, with `this` bound to the class
+func (c *checker) checkClass(n ast.ClassDeclStmt, s *scope) {
+	obj, _ := s.lookup(n.Name)
+	ref, ok := obj.typ.(*ClassRef)
+	if !ok {
+		return
+	}
+
+	for _, field := range n.Fields {
+		if field.ExplicitType != nil && field.AssignedValue != nil {
+			c.assignable(c.expr(field.AssignedValue, s), ref.Class.Fields[field.VariableName], field.AssignedValue.Span(), "field declaration")
+		}
+	}
+
+	for _, method := range n.Methods {
+		c.checkFunctionBody(method.Parameters, c.signatures[method.Span()], method.Body, s, ref.Class)
+	}
+}
+
+// Checks a function body in a new scope holding its parameters (and `this` for methods)
+func (c *checker) checkFunctionBody(parameters []ast.Parameter, fn *Function, body ast.BlockStmt, s *scope, this *Class) {
+	scope := newScope(s)
+	if this != nil {
+		scope.declare("this", this, true)
+	}
+	for i, parameter := range parameters {
+		c.declare(scope, parameter.Name, fn.Params[i], false, parameter.Span())
+	}
+
+	outer := c.returnType
+	c.returnType = fn.Return
+	c.checkBlock(body.Body, scope)
+	c.returnType = outer
+}
+
+func (c *checker) checkReturn(n ast.ReturnStmt, s *scope) {
+	if c.returnType == nil {
+		c.errorf(CodeInvalidReturn, n.Span(), "return outside of function")
+		return
+	}
+
+	if n.Value == nil {
+		if c.returnType != Void && c.returnType != Any {
+			c.errorf(CodeInvalidReturn, n.Span(), "missing return value of type %s", c.returnType)
+		}
+		return
+	}
+
+	value := c.expr(n.Value, s)
+	if c.returnType == Void {
+		c.errorf(CodeInvalidReturn, n.Value.Span(), "void function cannot return a value")
+		return
+	}
+	c.assignable(value, c.returnType, n.Value.Span(), "return statement")
+}
+
+// Returns the type of the loop variable for `foreach x in iterable`
+func (c *checker) elementType(iterable ast.Expr, s *scope) Type {
+	if r, ok := iterable.(ast.RangeExpr); ok {
+		c.operand(r.Lower, s, Number, "..")
+		c.operand(r.Upper, s, Number, "..")
+		return Number
+	}
+
+	switch t := c.expr(iterable, s).(type) {
+	case *Array:
+		return t.Elem
+	default:
+		if t == String {
+			return String
+		}
+		if t != Any {
+			c.errorf(CodeInvalidOperation, iterable.Span(), "cannot iterate over %s", t)
+		}
+		return Any
+	}
+}
+
+// null on its own says nothing about the variable it initializes, so such variables become any
+func widen(t Type) Type {
+	if t == Null {
+		return Any
+	}
+	return t
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/lexer"
+	"github.com/thutasann/go-parser/src/parser"
+)
+
+// Parses and checks source, failing the test on syntax errors
+func check(t *testing.T, source string) []lexer.Diagnostic {
+	t.Helper()
+	tokens, lexErrors := lexer.Tokenize(source)
+	program, parseErrors := parser.Parse(tokens)
+	if errors := append(lexErrors, parseErrors...); len(errors) > 0 {
+		t.Fatalf("syntax errors in %q: %v", source, errors)
+	}
+	return Check(program)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string // messages of the expected diagnostics, in order
+	}{
+		{
+			name:   "declared type mismatch",
+			source: "let x: string = 5;\nconst flag: boolean = 0;\nlet n: number = \"1\";\nlet ok: boolean = 1 < 2;",
+			want:   []string{"cannot use number as string in variable declaration", "cannot use number as boolean in variable declaration", "cannot use string as number in variable declaration"},
+		},
+		{
+			name:   "inferred types",
+			source: "let x = 5;\nlet s: string = x;\nlet copy = s;\nlet n: number = copy;",
+			want:   []string{"cannot use number as string in variable declaration", "cannot use string as number in variable declaration"},
+		},
+		{
+			name:   "arithmetic on strings",
+			source: "let a = \"a\" - 1;\nlet b = \"a\" * \"b\";\nlet c = -\"a\";\nlet ok: string = \"a\" + 1;",
+			want:   []string{"cannot apply - to string and number", "cannot apply * to string and string", "operator - expects number but found string"},
+		},
+		{
+			name:   "typeof is a string",
+			source: "let t: number = typeof 1;\nlet s: string = typeof (1 - \"a\");\nlet ok: string = typeof println;",
+			want:   []string{"cannot use string as number in variable declaration", "cannot apply - to number and string"},
+		},
+		{
+			name:   "wrong argument count",
+			source: "fn add(a: number, b: number): number { return a + b; }\nadd(1);\nadd(1, 2, 3);",
+			want:   []string{"expected 2 arguments but received 1", "expected 2 arguments but received 3"},
+		},
+		{
+			name:   "wrong argument type",
+			source: "fn greet(name: string) {}\ngreet(42);\nfn add(a: number, b: number): number { return a + b; }\nlet s: string = add(1, \"2\");",
+			want:   []string{"cannot use number as string in argument", "cannot use number as string in variable declaration", "cannot use string as number in argument"},
+		},
+		{
+			name:   "assignment to const",
+			source: "const limit = 10;\nlimit = 11;\nlimit += 1;\nfn f() { const c = \"a\"; c = \"b\"; }",
+			want:   []string{"cannot assign to constant limit", "cannot assign to constant limit", "cannot assign to constant c"},
+		},
+		{
+			name:   "assignment of the wrong type",
+			source: "let x = 1;\nx = \"a\";\nlet s = \"a\";\ns += 1;\nx -= \"b\";",
+			want:   []string{"cannot use string as number in assignment", "cannot apply -= to number and string"},
+		},
+		{
+			name:   "unknown types and names",
+			source: "let p: Point = 1;\nprintln(missing);",
+			want:   []string{"unknown type Point", "missing is not defined"},
+		},
+		{
+			name:   "array and object literals",
+			source: "let names: []string = [1, 2];\nlet words = [\"a\"];\nlet empty: []number = [];\nlet o = { size: 1 };\nlet s: string = o.size;\nlet first: number = words[0];",
+			want:   []string{"cannot use []number as []string in variable declaration", "cannot use number as string in variable declaration", "cannot use string as number in variable declaration"},
+		},
+		{
+			name:   "function redeclared with more parameters",
+			source: "fn f() {}\nfn f(a: number) { println(a); }",
+			want:   []string{"f is already declared in this scope"},
+		},
+		{
+			name:   "function redeclared with fewer parameters",
+			source: "fn f(a: number, b: string) {}\nfn f(a: number) { let x: string = a; }",
+			want:   []string{"f is already declared in this scope", "cannot use number as string in variable declaration"},
+		},
+		{
+			name:   "method redeclared",
+			source: "class C { fn m() {} fn m(x: string) { println(x); } }",
+			want:   []string{"m is already declared in class C"},
+		},
+		{
+			name:   "redeclared method body uses its own parameters",
+			source: "class C { fn m(x: number) {} fn m(x: string) { let y: number = x; } }",
+			want:   []string{"m is already declared in class C", "cannot use string as number in variable declaration"},
+		},
+		{
+			name:   "function used before its declaration",
+			source: "main();\nfn main() {}",
+			want:   []string{"main is used before its declaration"},
+		},
+		{
+			name:   "class used before its declaration",
+			source: "let p = new Point();\nclass Point {}",
+			want:   []string{"Point is used before its declaration"},
+		},
+		{
+			name:   "function bodies see later declarations",
+			source: "fn a(): number { return b() + limit; }\nfn b(): number { return 1; }\nlet limit = 2;\nprintln(a());",
+		},
+		{
+			name:   "nested functions see later declarations",
+			source: "fn outer() { fn a() { return b(); } fn b() { return 1; } return a(); }\nprintln(outer());",
+		},
+		{
+			name:   "field initializers see later declarations",
+			source: "class C { let v = make(); }\nfn make(): number { return 1; }\nprintln(new C());",
+		},
+		{
+			name:   "diagnostics in source order",
+			source: "fn f() { let x: number = \"a\"; }\nlet y: string = 1;",
+			want:   []string{"cannot use string as number in variable declaration", "cannot use number as string in variable declaration"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, diagnostic := range check(t, test.source) {
+				got = append(got, diagnostic.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+// Diagnostics carry their code and point at the offending code
+func TestCheckSpans(t *testing.T) {
+	tests := []struct {
+		source string
+		code   lexer.DiagnosticCode
+		at     string // source text the diagnostic points at
+	}{
+		{"let x: string = 5;", CodeTypeMismatch, "5"},
+		{"let a = \"a\" - 1;", CodeInvalidOperation, "\"a\" - 1"},
+		{"fn f(a: number) {}\nf(\"a\");", CodeTypeMismatch, "\"a\""},
+		{"fn f(a: number) {}\nf();", CodeArgumentCount, "f()"},
+		{"const c = 1;\nc = 2;", CodeAssignToConstant, "c = 2"},
+		{"let p: Point;", CodeUnknownType, "Point"},
+		{"println(y);", CodeUndefinedName, "y"},
+		{"let n = 1;\nn();", CodeNotCallable, "n()"},
+		{"let x = 1;\nlet x = 2;", CodeRedeclared, "let x = 2;"},
+		{"return 1;", CodeInvalidReturn, "return 1;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			diagnostics := check(t, test.source)
+			if len(diagnostics) != 1 {
+				t.Fatalf("diagnostics %v, want one", diagnostics)
+			}
+			diagnostic := diagnostics[0]
+			if diagnostic.Code != test.code || diagnostic.Severity != lexer.SeverityError {
+				t.Errorf("%s %s, want error %s", diagnostic.Severity, diagnostic.Code, test.code)
+			}
+			span := diagnostic.Span
+			if at := test.source[span.Start.Offset:span.End.Offset]; at != test.at {
+				t.Errorf("diagnostic points at %q, want %q", at, test.at)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package types
+
+import (
+	"github.com/thutasann/go-parser/src/ast"
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// Infers the type of an expression, reporting errors inside it
+func (c *checker) expr(expr ast.Expr, s *scope) Type {
+	t := c.infer(expr, s)
+	c.info.Types[expr.Span()] = t
+	return t
+}
+
+func (c *checker) infer(expr ast.Expr, s *scope) Type {
+	switch n := expr.(type) {
+	case ast.NumberExpr:
+		return Number
+	case ast.StringExpr:
+		return String
+	case ast.TemplateExpr:
+		for _, expr := range n.Expressions {
+			c.expr(expr, s)
+		}
+		return String
+	case ast.SymbolExpr:
+		obj, exists := c.lookup(n.Value, s, n.Span())
+		if !exists {
+			return Any
+		}
+		return obj.typ
+	case ast.PrefixExpr:
+		switch n.Operator.Kind {
+		case lexer.NOT:
+			return c.operand(n.RightExpr, s, Boolean, n.Operator.Value)
+		case lexer.TYPEOF:
+			c.expr(n.RightExpr, s)
+			return String
+		}
+		return c.operand(n.RightExpr, s, Number, n.Operator.Value)
+	case ast.BinaryExpr:
+		return c.binary(n.Operator, c.expr(n.Left, s), c.expr(n.Right, s), n.Span())
+	case ast.AssignmentExpr:
+		return c.assignment(n, s)
+	case ast.FunctionExpr:
+		fn := c.signature(n.Parameters, n.ReturnType, s)
+		c.checkFunctionBody(n.Parameters, fn, n.Body, s, nil)
+		return fn
+	case ast.CallExpr:
+		return c.call(c.expr(n.Method, s), n.Arguments, s, n.Span())
+	case ast.MemberExpr:
+		return c.member(c.expr(n.Member, s), n.Property, n.Span())
+	case ast.ComputedExpr:
+		return c.index(c.expr(n.Member, s), n.Property, s, n.Span())
+	case ast.NewExpr:
+		return c.instantiate(n, s)
+	case ast.ArrayLiteral:
+		return c.arrayLiteral(n, s)
+	case ast.ObjectLiteral:
+		return c.objectLiteral(n, s)
+	case ast.RangeExpr:
+		c.errorf(CodeInvalidOperation, n.Span(), "ranges can only be used in foreach loops")
+		return Any
+	default:
+		return Any
+	}
+}
+
+// Checks that a condition is a boolean
+func (c *checker) condition(expr ast.Expr, s *scope) {
+	if t := c.expr(expr, s); !AssignableTo(t, Boolean) || t == Null {
+		c.errorf(CodeTypeMismatch, expr.Span(), "condition must be boolean but is %s", t)
+	}
+}
+
+// Checks that the operand of an operator has the wanted type and returns that type
+func (c *checker) operand(expr ast.Expr, s *scope, want Type, operator string) Type {
+	if t := c.expr(expr, s); t != Any && t != want {
+		c.errorf(CodeInvalidOperation, expr.Span(), "operator %s expects %s but found %s", operator, want, t)
+	}
+	return want
+}
+
+// Result type of a binary operator, mirroring what the interpreter accepts
+func (c *checker) binary(operator lexer.Token, left Type, right Type, span lexer.Span) Type {
+	invalid := func() Type {
+		c.errorf(CodeInvalidOperation, span, "cannot apply %s to %s and %s", operator.Value, left, right)
+		return Any
+	}
+
+	switch operator.Kind {
+	case lexer.EQUALS, lexer.NOT_EQUALS:
+		return Boolean
+	case lexer.AND, lexer.OR:
+		if !AssignableTo(left, Boolean) || !AssignableTo(right, Boolean) || left == Null || right == Null {
+			return invalid()
+		}
+		return Boolean
+	case lexer.LESS, lexer.LESS_EQUALS, lexer.GREATER, lexer.GREATER_EQUALS:
+		// numbers compare with numbers, strings with strings
+		comparable := func(t Type) bool { return t == Any || t == Number || t == String }
+		if comparable(left) && comparable(right) && (left == right || left == Any || right == Any) {
+			return Boolean
+		}
+		return invalid()
+	case lexer.PLUS:
+		// a string on either side makes + a concatenation
+		if left == String || right == String {
+			return String
+		}
+		fallthrough
+	default:
+		if (left == Number || left == Any) && (right == Number || right == Any) {
+			return Number
+		}
+		return invalid()
+	}
+}
+
+func (c *checker) assignment(n ast.AssignmentExpr, s *scope) Type {
+	var target Type
+
+	switch assigne := n.Assigne.(type) {
+	case ast.SymbolExpr:
+		obj, exists := c.lookup(assigne.Value, s, assigne.Span())
+		if !exists {
+			target = Any
+		} else {
+			if obj.constant {
+				c.errorf(CodeAssignToConstant, n.Span(), "cannot assign to constant %s", assigne.Value)
+			}
+			target = obj.typ
+		}
+	case ast.MemberExpr:
+		object := c.expr(assigne.Member, s)
+		target = c.member(object, assigne.Property, assigne.Span())
+		if class, ok := object.(*Class); ok {
+			if _, isField := class.Fields[assigne.Property]; !isField {
+				c.errorf(CodeInvalidOperation, assigne.Span(), "cannot assign to method %s", assigne.Property)
+			}
+		}
+	case ast.ComputedExpr:
+		target = c.index(c.expr(assigne.Member, s), assigne.Property, s, assigne.Span())
+	default:
+		c.errorf(CodeInvalidOperation, n.Assigne.Span(), "invalid assignment target")
+		target = Any
+	}
+	c.info.Types[n.Assigne.Span()] = target
+
+	value := c.expr(n.Value, s)
+	switch n.Operator.Kind {
+	case lexer.PLUS_EQUALS:
+		value = c.binary(lexer.NewTokenAt(lexer.PLUS, "+=", n.Operator.Span), target, value, n.Span())
+	case lexer.MINUS_EQUALS:
+		value = c.binary(lexer.NewTokenAt(lexer.DASH, "-=", n.Operator.Span), target, value, n.Span())
+	}
+
+	c.assignable(value, target, n.Value.Span(), "assignment")
+	return target
+}
+
+// Checks the arguments of a call against the callee's signature and returns its result type
+func (c *checker) call(callee Type, arguments []ast.Expr, s *scope, span lexer.Span) Type {
+	argTypes := make([]Type, len(arguments))
+	for i, argument := range arguments {
+		argTypes[i] = c.expr(argument, s)
+	}
+
+	switch fn := callee.(type) {
+	case *Function:
+		if fn.Variadic {
+			return fn.Return
+		}
+		if len(arguments) != len(fn.Params) {
+			c.errorf(CodeArgumentCount, span, "expected %d arguments but received %d", len(fn.Params), len(arguments))
+			return fn.Return
+		}
+		for i, argument := range arguments {
+			c.assignable(argTypes[i], fn.Params[i], argument.Span(), "argument")
+		}
+		return fn.Return
+	case *ClassRef:
+		c.errorf(CodeNotCallable, span, "class %s must be instantiated with new", fn.Class.Name)
+		return fn.Class
+	default:
+		if callee != Any {
+			c.errorf(CodeNotCallable, span, "%s is not callable", callee)
+		}
+		return Any
+	}
+}
+
+// Type of `object.property`
+func (c *checker) member(object Type, property string, span lexer.Span) Type {
+	switch t := object.(type) {
+	case *Class:
+		if field, exists := t.Fields[property]; exists {
+			return field
+		}
+		if method, exists := t.Methods[property]; exists {
+			return method
+		}
+	case *Object:
+		if field, exists := t.Fields[property]; exists {
+			return field
+		}
+	case *Array:
+		switch property {
+		case "length":
+			return Number
+		case "push":
+			return &Function{Params: []Type{t.Elem}, Return: Number}
+		}
+	}
+
+	if object != Any {
+		c.errorf(CodeUnknownMember, span, "%s has no member %s", object, property)
+	}
+	return Any
+}
+
+// Type of `object[property]`
+func (c *checker) index(object Type, property ast.Expr, s *scope, span lexer.Span) Type {
+	if _, isObject := object.(*Object); isObject {
+		c.operand(property, s, String, "[]")
+	} else {
+		c.operand(property, s, Number, "[]")
+	}
+
+	switch t := object.(type) {
+	case *Array:
+		return t.Elem
+	case *Object:
+		return Any
+	default:
+		if t == String {
+			return String
+		}
+		if t != Any {
+			c.errorf(CodeInvalidOperation, span, "cannot index %s", t)
+		}
+		return Any
+	}
+}
+
+// Type of `new Class(args...)`, checking the arguments against the constructor method
+func (c *checker) instantiate(n ast.NewExpr, s *scope) Type {
+	callee := c.expr(n.Instantiation.Method, s)
+	ref, ok := callee.(*ClassRef)
+	if !ok {
+		if callee != Any {
+			c.errorf(CodeNotCallable, n.Instantiation.Method.Span(), "%s is not a class", callee)
+		}
+		return Any
+	}
+
+	if constructor, exists := ref.Class.Methods["constructor"]; exists {
+		c.call(constructor, n.Instantiation.Arguments, s, n.Span())
+	} else if len(n.Instantiation.Arguments) > 0 {
+		c.errorf(CodeArgumentCount, n.Span(), "class %s has no constructor but received %d arguments", ref.Class.Name, len(n.Instantiation.Arguments))
+	}
+
+	return ref.Class
+}
+
+// Array literals whose elements all have the same type are arrays of that type, anything else is []any
+func (c *checker) arrayLiteral(n ast.ArrayLiteral, s *scope) Type {
+	var elem Type
+
+	for _, element := range n.Contents {
+		t := widen(c.expr(element, s))
+		if elem == nil {
+			elem = t
+		} else if !Identical(elem, t) {
+			elem = Any
+		}
+	}
+
+	if elem == nil {
+		elem = Any
+	}
+	return &Array{Elem: elem}
+}
+
+// Object literals get an object type, unless a computed key makes the fields unknowable
+func (c *checker) objectLiteral(n ast.ObjectLiteral, s *scope) Type {
+	object := &Object{Fields: map[string]Type{}}
+	computed := false
+
+	for _, property := range n.Properties {
+		if property.ComputedKey != nil {
+			computed = true
+			c.operand(property.ComputedKey, s, String, "[]")
+		}
+		object.Fields[property.Key] = widen(c.expr(property.Value, s))
+	}
+
+	if computed {
+		return Any
+	}
+	return object
+}
@@ -0,0 +1,54 @@
+package types
+
+// A declared name and its type
+// pending: a hoisted function or class whose declaration hasn't been reached yet, which
+// only code running later, such as function bodies, may use
+type object struct {
+	typ      Type
+	constant bool
+	pending  bool
+}
+
+// Scope maps names to their types, nested like the interpreter environments
+type scope struct {
+	parent  *scope
+	objects map[string]*object
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{
+		parent:  parent,
+		objects: map[string]*object{},
+	}
+}
+
+// Declares a name in this scope. Returns false if it is already declared here.
+func (s *scope) declare(name string, typ Type, constant bool) bool {
+	if _, exists := s.objects[name]; exists {
+		return false
+	}
+	s.objects[name] = &object{typ: typ, constant: constant}
+	return true
+}
+
+// Finds a name in this scope or the closest enclosing one
+func (s *scope) lookup(name string) (*object, bool) {
+	for current := s; current != nil; current = current.parent {
+		if obj, exists := current.objects[name]; exists {
+			return obj, true
+		}
+	}
+	return nil, false
+}
+
+// Scope holding the builtin types, constants and functions
+func universe() *scope {
+	s := newScope(nil)
+	s.declare("true", Boolean, true)
+	s.declare("false", Boolean, true)
+	s.declare("null", Null, true)
+	s.declare("println", &Function{Return: Void, Variadic: true}, true)
+	s.declare("print", &Function{Return: Void, Variadic: true}, true)
+	s.declare("len", &Function{Params: []Type{Any}, Return: Number}, true)
+	return s
+}
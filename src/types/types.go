@@ -0,0 +1,144 @@
+package types
+
+import (
+	"sort"
+	"strings"
+)
+
+// Type is the static type of a value
+type Type interface {
+	String() string
+}
+
+// Builtin scalar type such as number or string
+type Basic struct {
+	Name string
+}
+
+func (t *Basic) String() string { return t.Name }
+
+// Array of T, written []T
+type Array struct {
+	Elem Type
+}
+
+func (t *Array) String() string { return "[]" + t.Elem.String() }
+
+// Object literal type with known fields
+type Object struct {
+	Fields map[string]Type
+}
+
+func (t *Object) String() string {
+	names := make([]string, 0, len(t.Fields))
+	for name := range t.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = name + ": " + t.Fields[name].String()
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// Function signature
+// Variadic functions such as println accept any number of arguments of any type and ignore Params.
+type Function struct {
+	Params   []Type
+	Return   Type
+	Variadic bool
+}
+
+func (t *Function) String() string {
+	if t.Variadic {
+		return "fn(...any): " + t.Return.String()
+	}
+
+	params := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		params[i] = param.String()
+	}
+	return "fn(" + strings.Join(params, ", ") + "): " + t.Return.String()
+}
+
+// Instances of a user declared class
+type Class struct {
+	Name    string
+	Fields  map[string]Type
+	Methods map[string]*Function
+}
+
+func (t *Class) String() string { return t.Name }
+
+// The class itself, as a value that can be instantiated with new
+type ClassRef struct {
+	Class *Class
+}
+
+func (t *ClassRef) String() string { return "class " + t.Class.Name }
+
+// Builtin types
+var (
+	Number  = &Basic{Name: "number"}
+	String  = &Basic{Name: "string"}
+	Boolean = &Basic{Name: "boolean"}
+	Null    = &Basic{Name: "null"}
+	Void    = &Basic{Name: "void"}
+
+	// Any is the type of values the checker knows nothing about, e.g. imported names.
+	// It is compatible with every type, which also stops one error from causing many.
+	Any = &Basic{Name: "any"}
+)
+
+// Identical reports whether two types are the same
+func Identical(a Type, b Type) bool {
+	if a == b {
+		return true
+	}
+
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && Identical(a.Elem, b.Elem)
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok || a.Variadic != b.Variadic || len(a.Params) != len(b.Params) || !Identical(a.Return, b.Return) {
+			return false
+		}
+		for i := range a.Params {
+			if !Identical(a.Params[i], b.Params[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// AssignableTo reports whether a value of type value can be stored where target is expected
+func AssignableTo(value Type, target Type) bool {
+	if value == Any || target == Any {
+		return true
+	}
+
+	// null can stand in for any value that is not a scalar
+	if value == Null {
+		switch target {
+		case Number, String, Boolean:
+			return false
+		default:
+			return true
+		}
+	}
+
+	if valueArray, ok := value.(*Array); ok {
+		if targetArray, ok := target.(*Array); ok {
+			return AssignableTo(valueArray.Elem, targetArray.Elem)
+		}
+	}
+
+	return Identical(value, target)
+}
@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Installs the global constants and builtin functions
+func (vm *VM) defineGlobals() {
+	vm.globals = map[string]value{
+		"true":  boolean(true),
+		"false": boolean(false),
+		"null":  null,
+	}
+
+	builtins := []*builtin{
+		{name: "println", fn: builtinPrintln},
+		{name: "print", fn: builtinPrint},
+		{name: "len", fn: builtinLen},
+	}
+	for _, b := range builtins {
+		vm.globals[b.name] = object(b)
+	}
+}
+
+// println(args...) prints the arguments separated by spaces, followed by a newline
+func builtinPrintln(vm *VM, args []value) (value, error) {
+	_, err := fmt.Fprintln(vm.Stdout, joinValues(args))
+	return null, err
+}
+
+// print(args...) prints the arguments separated by spaces
+func builtinPrint(vm *VM, args []value) (value, error) {
+	_, err := fmt.Fprint(vm.Stdout, joinValues(args))
+	return null, err
+}
+
+// len(value) returns the number of characters in a string or elements in an array
+func builtinLen(vm *VM, args []value) (value, error) {
+	if len(args) != 1 {
+		return null, fmt.Errorf("len expects 1 argument but received %d", len(args))
+	}
+
+	if args[0].kind == stringValue {
+		return number(float64(utf8.RuneCountInString(args[0].ref.(string)))), nil
+	}
+	if a, ok := args[0].ref.(*array); ok {
+		return number(float64(len(a.elements))), nil
+	}
+	return null, fmt.Errorf("len is not defined for %s", typeName(args[0]))
+}
+
+// array.push(args...) appends the arguments and returns the new length
+func arrayPush(a *array) *builtin {
+	return &builtin{name: "push", fn: func(vm *VM, args []value) (value, error) {
+		a.elements = append(a.elements, args...)
+		return number(float64(len(a.elements))), nil
+	}}
+}
+
+func joinValues(args []value) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = stringify(arg)
+	}
+	return strings.Join(parts, " ")
+}
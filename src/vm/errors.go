@@ -0,0 +1,28 @@
+package vm
+
+import (
+	"fmt"
+
+	"github.com/thutasann/go-parser/src/lexer"
+)
+
+// RuntimeError is an error raised while running a program, located at the instruction that caused it
+type RuntimeError struct {
+	Message string
+	Span    lexer.Span
+}
+
+// Error formats the error as file:line:column: runtime error: message
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s: runtime error: %s", e.Span, e.Message)
+}
+
+// Panic value aborting the run loop. run recovers it and locates it at the current instruction.
+type failure struct {
+	message string
+}
+
+// Aborts execution with a runtime error
+func throw(format string, args ...any) {
+	panic(failure{message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,199 @@
+package vm
+
+import (
+	"math"
+
+	"github.com/thutasann/go-parser/src/compiler"
+)
+
+// Source spelling of the binary operators, for error messages
+var operator_symbols = map[compiler.Opcode]string{
+	compiler.OpAdd:          "+",
+	compiler.OpSubtract:     "-",
+	compiler.OpMultiply:     "*",
+	compiler.OpDivide:       "/",
+	compiler.OpModulo:       "%",
+	compiler.OpLess:         "<",
+	compiler.OpLessEqual:    "<=",
+	compiler.OpGreater:      ">",
+	compiler.OpGreaterEqual: ">=",
+}
+
+// Applies an arithmetic, comparison or concatenation operator to two values
+func binary(op compiler.Opcode, left value, right value) value {
+	if left.kind == numberValue && right.kind == numberValue {
+		l, r := left.number, right.number
+		switch op {
+		case compiler.OpAdd:
+			return number(l + r)
+		case compiler.OpSubtract:
+			return number(l - r)
+		case compiler.OpMultiply:
+			return number(l * r)
+		case compiler.OpDivide:
+			if r == 0 {
+				throw("division by zero")
+			}
+			return number(l / r)
+		case compiler.OpModulo:
+			if r == 0 {
+				throw("division by zero")
+			}
+			return number(math.Mod(l, r))
+		case compiler.OpLess:
+			return boolean(l < r)
+		case compiler.OpLessEqual:
+			return boolean(l <= r)
+		case compiler.OpGreater:
+			return boolean(l > r)
+		case compiler.OpGreaterEqual:
+			return boolean(l >= r)
+		}
+	}
+
+	// String concatenation: a string on either side of + joins both sides as text
+	if op == compiler.OpAdd && (left.kind == stringValue || right.kind == stringValue) {
+		return str(stringify(left) + stringify(right))
+	}
+
+	if left.kind == stringValue && right.kind == stringValue {
+		l, r := left.ref.(string), right.ref.(string)
+		switch op {
+		case compiler.OpLess:
+			return boolean(l < r)
+		case compiler.OpLessEqual:
+			return boolean(l <= r)
+		case compiler.OpGreater:
+			return boolean(l > r)
+		case compiler.OpGreaterEqual:
+			return boolean(l >= r)
+		}
+	}
+
+	throw("cannot apply %s to %s and %s", operator_symbols[op], typeName(left), typeName(right))
+	return null
+}
+
+// Value of a condition, which must be a boolean
+func condition(v value) bool {
+	if v.kind != boolValue {
+		throw("condition must be a boolean but is %s", typeName(v))
+	}
+	return v.number != 0
+}
+
+// Starts iterating over the elements of an array or the characters of a string
+func iterate(iterable value) *iterator {
+	if iterable.kind == stringValue {
+		return &iterator{over: iterateString, runes: []rune(iterable.ref.(string))}
+	}
+	if a, ok := iterable.ref.(*array); ok {
+		return &iterator{over: iterateArray, elements: a.elements}
+	}
+
+	throw("cannot iterate over %s", typeName(iterable))
+	return nil
+}
+
+// Reads `target.name`; methods come back bound to their instance
+func member(target value, name string) value {
+	switch o := target.ref.(type) {
+	case *instance:
+		if v, exists := o.fields[name]; exists {
+			return v
+		}
+		if method, exists := o.class.methods[name]; exists {
+			return object(&boundMethod{receiver: target, method: method})
+		}
+		throw("%s has no member %s", o.class.name, name)
+	case *module:
+		if v, exists := o.exports[name]; exists {
+			return v
+		}
+		throw("module %s does not export %s", o.path, name)
+	case *objectLiteral:
+		if v, exists := o.fields[name]; exists {
+			return v
+		}
+		throw("object has no member %s", name)
+	case *array:
+		switch name {
+		case "length":
+			return number(float64(len(o.elements)))
+		case "push":
+			return object(arrayPush(o))
+		}
+		throw("array has no member %s", name)
+	default:
+		throw("%s has no member %s", typeName(target), name)
+	}
+	return null
+}
+
+// Writes `target.name = v`
+func setMember(target value, name string, v value) {
+	switch o := target.ref.(type) {
+	case *instance:
+		if _, exists := o.fields[name]; !exists {
+			throw("%s has no field %s", o.class.name, name)
+		}
+		o.fields[name] = v
+	case *objectLiteral:
+		o.set(name, v)
+	default:
+		throw("cannot assign to a member of %s", typeName(target))
+	}
+}
+
+// Reads `target[property]`
+func index(target value, property value) value {
+	if target.kind == stringValue {
+		runes := []rune(target.ref.(string))
+		i := property.number
+		if property.kind != numberValue || i != math.Trunc(i) || i < 0 || int(i) >= len(runes) {
+			throw("string index %s out of range", stringify(property))
+		}
+		return str(string(runes[int(i)]))
+	}
+
+	switch o := target.ref.(type) {
+	case *array:
+		return o.elements[arrayIndex(o, property)]
+	case *objectLiteral:
+		if property.kind != stringValue {
+			throw("object keys must be strings but found %s", typeName(property))
+		}
+		v, exists := o.fields[property.ref.(string)]
+		if !exists {
+			throw("object has no member %s", property.ref.(string))
+		}
+		return v
+	default:
+		throw("cannot index %s", typeName(target))
+		return null
+	}
+}
+
+// Writes `target[property] = v`
+func setIndex(target value, property value, v value) {
+	switch o := target.ref.(type) {
+	case *array:
+		o.elements[arrayIndex(o, property)] = v
+	case *objectLiteral:
+		if property.kind != stringValue {
+			throw("object keys must be strings but found %s", typeName(property))
+		}
+		o.set(property.ref.(string), v)
+	default:
+		throw("cannot assign to an index of %s", typeName(target))
+	}
+}
+
+// Validates an array index and converts it to int
+func arrayIndex(a *array, property value) int {
+	i := property.number
+	if property.kind != numberValue || i != math.Trunc(i) || i < 0 || int(i) >= len(a.elements) {
+		throw("array index %s out of range [0, %d)", stringify(property), len(a.elements))
+	}
+	return int(i)
+}
@@ -0,0 +1,286 @@
+package vm
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/compiler"
+)
+
+// Kinds of values. Numbers and booleans are stored unboxed, so arithmetic doesn't allocate.
+type valueKind uint8
+
+const (
+	nullValue valueKind = iota
+	boolValue
+	numberValue
+	stringValue
+	objectValue    // functions, classes, instances, arrays, objects, modules and iterators
+	undefinedValue // slot of a variable whose declaration has not run yet
+)
+
+// A runtime value
+// number: the number, or 1 and 0 for true and false
+// ref: the string, or the pointer of an object
+type value struct {
+	kind   valueKind
+	number float64
+	ref    any
+}
+
+var (
+	null      = value{kind: nullValue}
+	undefined = value{kind: undefinedValue}
+)
+
+func number(n float64) value { return value{kind: numberValue, number: n} }
+func str(s string) value     { return value{kind: stringValue, ref: s} }
+func object(ref any) value   { return value{kind: objectValue, ref: ref} }
+
+func boolean(b bool) value {
+	if b {
+		return value{kind: boolValue, number: 1}
+	}
+	return value{kind: boolValue}
+}
+
+// A function ready to run: the compiled function with its constants converted to values
+type function struct {
+	proto     *compiler.Function
+	constants []value
+}
+
+// A function together with the variables it captured
+type closure struct {
+	fn       *function
+	upvalues []*upvalue
+}
+
+// A captured variable. It refers to a stack slot while the variable's scope is running,
+// and holds the value itself once the scope has ended.
+type upvalue struct {
+	slot   int
+	open   bool
+	closed value
+}
+
+// Go function exposed to scripts, e.g. println
+type builtin struct {
+	name string
+	fn   func(vm *VM, args []value) (value, error)
+}
+
+// A method taken from an instance, which runs with `this` bound to it
+type boundMethod struct {
+	receiver value
+	method   *closure
+}
+
+// Class declared with `class Name { ... }`; init sets the fields of new instances
+type class struct {
+	name    string
+	fields  []string
+	methods map[string]*closure
+	init    *closure
+}
+
+// Object created with `new Class(...)`
+type instance struct {
+	class  *class
+	fields map[string]value
+}
+
+// Array created with `[a, b, c]`
+type array struct {
+	elements []value
+}
+
+// Object created with `{ key: value }`; keys keeps the insertion order for printing
+type objectLiteral struct {
+	keys   []string
+	fields map[string]value
+}
+
+// Sets a field, remembering the order in which keys were first added
+func (o *objectLiteral) set(key string, v value) {
+	if _, exists := o.fields[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.fields[key] = v
+}
+
+// Module imported with `import alias from "path";`
+type module struct {
+	path    string
+	exports map[string]value
+}
+
+// What an iterator goes through
+type iteration uint8
+
+const (
+	iterateArray  iteration = iota // the elements of an array
+	iterateString                  // the characters of a string
+	iterateRange                   // the numbers from next up to end
+)
+
+// State of a foreach loop
+type iterator struct {
+	over     iteration
+	elements []value
+	runes    []rune
+	index    int
+	next     float64
+	end      float64
+}
+
+// Moves to the next element, reporting false when there is none left
+func (it *iterator) advance() (value, bool) {
+	switch it.over {
+	case iterateRange:
+		if it.next >= it.end {
+			return null, false
+		}
+		n := it.next
+		it.next++
+		return number(n), true
+	case iterateString:
+		if it.index >= len(it.runes) {
+			return null, false
+		}
+		it.index++
+		return str(string(it.runes[it.index-1])), true
+	default:
+		if it.index >= len(it.elements) {
+			return null, false
+		}
+		it.index++
+		return it.elements[it.index-1], true
+	}
+}
+
+// Returns the name of the runtime type of a value, used in error messages
+func typeName(v value) string {
+	switch v.kind {
+	case nullValue:
+		return "null"
+	case numberValue:
+		return "number"
+	case stringValue:
+		return "string"
+	case boolValue:
+		return "boolean"
+	case undefinedValue:
+		return "undefined"
+	}
+
+	switch o := v.ref.(type) {
+	case *closure, *builtin, *boundMethod:
+		return "function"
+	case *class:
+		return "class"
+	case *instance:
+		return o.class.name
+	case *module:
+		return "module"
+	case *array:
+		return "array"
+	case *objectLiteral:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+// Returns the result of typeof for a value, which matches JavaScript's so compiled programs print the same
+func typeOf(v value) string {
+	switch v.kind {
+	case numberValue:
+		return "number"
+	case stringValue:
+		return "string"
+	case boolValue:
+		return "boolean"
+	case undefinedValue:
+		return "undefined"
+	}
+
+	switch v.ref.(type) {
+	case *closure, *builtin, *boundMethod, *class:
+		return "function"
+	default:
+		return "object"
+	}
+}
+
+// Formats a value the way println shows it
+func stringify(v value) string {
+	switch v.kind {
+	case nullValue, undefinedValue:
+		return "null"
+	case numberValue:
+		return formatNumber(v.number)
+	case stringValue:
+		return v.ref.(string)
+	case boolValue:
+		return strconv.FormatBool(v.number != 0)
+	}
+
+	switch o := v.ref.(type) {
+	case *closure:
+		return "fn " + o.fn.proto.Name
+	case *boundMethod:
+		return "fn " + o.method.fn.proto.Name
+	case *builtin:
+		return "fn " + o.name
+	case *class:
+		return "class " + o.name
+	case *instance:
+		fields := make([]string, 0, len(o.class.fields))
+		for _, field := range o.class.fields {
+			fields = append(fields, field+": "+stringify(o.fields[field]))
+		}
+		return o.class.name + " { " + strings.Join(fields, ", ") + " }"
+	case *module:
+		return "module " + o.path
+	case *array:
+		elements := make([]string, len(o.elements))
+		for i, element := range o.elements {
+			elements[i] = stringify(element)
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *objectLiteral:
+		fields := make([]string, len(o.keys))
+		for i, key := range o.keys {
+			fields[i] = key + ": " + stringify(o.fields[key])
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	default:
+		return "unknown"
+	}
+}
+
+// Integral numbers print without a fraction, everything else in the shortest exact form
+func formatNumber(n float64) string {
+	if n == math.Trunc(n) && math.Abs(n) < 1e21 {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(n, 'g', -1, 64)
+}
+
+// Value equality used by == and !=: primitives by value, everything else by identity
+func equals(left value, right value) bool {
+	if left.kind != right.kind {
+		return false
+	}
+
+	switch left.kind {
+	case nullValue:
+		return true
+	case boolValue, numberValue:
+		return left.number == right.number
+	default:
+		return left.ref == right.ref
+	}
+}
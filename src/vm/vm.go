@@ -0,0 +1,506 @@
+// Package vm runs the bytecode produced by package compiler on a stack machine.
+//
+// Every call gets a frame whose local slots are a window of the value stack; slot 0
+// holds the callee, or `this` in methods. Variables captured by closures stay on the
+// stack while their scope runs and move into the closure when it ends.
+package vm
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/thutasann/go-parser/src/compiler"
+)
+
+// Deepest allowed call nesting before a script is stopped with a runtime error
+const maxCallDepth = 10000
+
+// Size in bytes of the operand of every opcode, 0 when it has none
+var operand_widths = func() (widths [256]int) {
+	for op := range widths {
+		widths[op] = compiler.Opcode(op).Width() - 1
+	}
+	return widths
+}()
+
+// VM runs compiled programs
+//
+// - Stdout receives everything printed by the script
+//
+// - modules holds the exports of every module run by RunModule, keyed by file path
+type VM struct {
+	Stdout  io.Writer
+	globals map[string]value
+	modules map[string]*module
+	exports map[string]value // exports of the module being run
+	stack   []value
+	frames  []frame
+	open    []*upvalue // upvalues still referring to stack slots, ordered by slot
+}
+
+// A function call in progress
+// base: stack index of slot 0
+// construct: the call runs a constructor, which evaluates to its receiver
+type frame struct {
+	closure   *closure
+	ip        int
+	base      int
+	construct bool
+}
+
+// New creates a virtual machine that prints to stdout (os.Stdout if nil)
+func New(stdout io.Writer) *VM {
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	vm := &VM{
+		Stdout: stdout,
+		stack:  make([]value, 0, 256),
+	}
+	vm.defineGlobals()
+	return vm
+}
+
+// Run executes a compiled program. A failure is returned as a *RuntimeError pointing at the offending code.
+func (vm *VM) Run(fn *compiler.Function) error {
+	_, err := vm.run(fn)
+	return err
+}
+
+// RunModule executes a compiled module and keeps its exports under path, for the import
+// statements of the modules run after it. Modules must be run imported modules first.
+func (vm *VM) RunModule(path string, fn *compiler.Function) error {
+	if vm.modules == nil {
+		vm.modules = map[string]*module{}
+	}
+
+	exports, err := vm.run(fn)
+	if err != nil {
+		return err
+	}
+	vm.modules[path] = &module{path: path, exports: exports}
+	return nil
+}
+
+// Runs the top level of a program and returns what it exported
+func (vm *VM) run(fn *compiler.Function) (map[string]value, error) {
+	script := &closure{fn: load(fn)}
+	vm.exports = map[string]value{}
+	vm.stack = append(vm.stack[:0], object(script))
+	vm.frames = append(vm.frames[:0], frame{closure: script})
+
+	if err := vm.execute(); err != nil {
+		vm.stack, vm.frames, vm.open = vm.stack[:0], vm.frames[:0], nil
+		return nil, err
+	}
+	return vm.exports, nil
+}
+
+// Converts the constants of a compiled function, and of the functions nested in it, to values
+func load(proto *compiler.Function) *function {
+	fn := &function{proto: proto, constants: make([]value, len(proto.Constants))}
+
+	for i, constant := range proto.Constants {
+		switch c := constant.(type) {
+		case float64:
+			fn.constants[i] = number(c)
+		case string:
+			fn.constants[i] = str(c)
+		case *compiler.Function:
+			fn.constants[i] = object(load(c))
+		default:
+			fn.constants[i] = object(c)
+		}
+	}
+	return fn
+}
+
+func (vm *VM) push(v value) {
+	vm.stack = append(vm.stack, v)
+}
+
+func (vm *VM) pop() value {
+	v := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return v
+}
+
+// Returns the value n slots below the top of the stack
+func (vm *VM) peek(n int) value {
+	return vm.stack[len(vm.stack)-1-n]
+}
+
+// The fetch-decode-execute loop. It runs until the frame of the script returns;
+// calls and returns switch frames without recursing.
+func (vm *VM) execute() (err error) {
+	f := &vm.frames[len(vm.frames)-1]
+	code, constants := f.closure.fn.proto.Code, f.closure.fn.constants
+	ip, base := f.ip, f.base
+	start := 0 // offset of the instruction being executed
+
+	// Picks up the frame on top after a call or a return
+	switchFrame := func() {
+		f = &vm.frames[len(vm.frames)-1]
+		code, constants = f.closure.fn.proto.Code, f.closure.fn.constants
+		ip, base = f.ip, f.base
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			fail, ok := r.(failure)
+			if !ok {
+				panic(r)
+			}
+			err = &RuntimeError{Message: fail.message, Span: f.closure.fn.proto.SpanAt(start)}
+		}
+	}()
+
+	for {
+		start = ip
+		op := compiler.Opcode(code[ip])
+		ip++
+
+		arg := 0
+		switch operand_widths[op] {
+		case 1:
+			arg = int(code[ip])
+			ip++
+		case 2:
+			arg = int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+		}
+
+		switch op {
+		case compiler.OpConstant:
+			vm.push(constants[arg])
+		case compiler.OpNull:
+			vm.push(null)
+		case compiler.OpTrue:
+			vm.push(boolean(true))
+		case compiler.OpFalse:
+			vm.push(boolean(false))
+		case compiler.OpUndefined:
+			vm.push(undefined)
+		case compiler.OpPop:
+			vm.stack = vm.stack[:len(vm.stack)-1]
+		case compiler.OpDup:
+			vm.push(vm.peek(0))
+		case compiler.OpDup2:
+			vm.push(vm.peek(1))
+			vm.push(vm.peek(1))
+
+		case compiler.OpDefineLocal:
+			vm.stack[base+arg] = vm.pop()
+		case compiler.OpGetLocal:
+			slot := arg
+			v := vm.stack[base+slot]
+			if v.kind == undefinedValue {
+				throw("%s is not defined", f.closure.fn.proto.LocalName(slot, start))
+			}
+			vm.push(v)
+		case compiler.OpSetLocal:
+			slot := arg
+			if vm.stack[base+slot].kind == undefinedValue {
+				throw("%s is not defined", f.closure.fn.proto.LocalName(slot, start))
+			}
+			vm.stack[base+slot] = vm.peek(0)
+		case compiler.OpGetUpvalue:
+			index := arg
+			v := vm.upvalue(f.closure.upvalues[index])
+			if v.kind == undefinedValue {
+				throw("%s is not defined", f.closure.fn.proto.Upvalues[index].Name)
+			}
+			vm.push(v)
+		case compiler.OpSetUpvalue:
+			index := arg
+			uv := f.closure.upvalues[index]
+			if vm.upvalue(uv).kind == undefinedValue {
+				throw("%s is not defined", f.closure.fn.proto.Upvalues[index].Name)
+			}
+			if uv.open {
+				vm.stack[uv.slot] = vm.peek(0)
+			} else {
+				uv.closed = vm.peek(0)
+			}
+		case compiler.OpGetGlobal:
+			name := constants[arg].ref.(string)
+			v, exists := vm.globals[name]
+			if !exists {
+				throw("%s is not defined", name)
+			}
+			vm.push(v)
+		case compiler.OpSetGlobal:
+			name := constants[arg].ref.(string)
+			if _, exists := vm.globals[name]; exists {
+				throw("cannot assign to constant %s", name)
+			}
+			throw("%s is not defined", name)
+		case compiler.OpEndScope:
+			from := len(vm.stack) - arg
+			vm.closeUpvalues(from)
+			vm.stack = vm.stack[:from]
+
+		case compiler.OpAdd, compiler.OpSubtract, compiler.OpMultiply, compiler.OpDivide, compiler.OpModulo,
+			compiler.OpLess, compiler.OpLessEqual, compiler.OpGreater, compiler.OpGreaterEqual:
+			right := vm.pop()
+			left := &vm.stack[len(vm.stack)-1]
+			*left = binary(op, *left, right)
+		case compiler.OpEqual:
+			right := vm.pop()
+			vm.stack[len(vm.stack)-1] = boolean(equals(vm.peek(0), right))
+		case compiler.OpNotEqual:
+			right := vm.pop()
+			vm.stack[len(vm.stack)-1] = boolean(!equals(vm.peek(0), right))
+		case compiler.OpNegate:
+			top := &vm.stack[len(vm.stack)-1]
+			if top.kind != numberValue {
+				throw("expected number but found %s", typeName(*top))
+			}
+			top.number = -top.number
+		case compiler.OpNot:
+			vm.stack[len(vm.stack)-1] = boolean(!condition(vm.peek(0)))
+		case compiler.OpTypeof:
+			vm.stack[len(vm.stack)-1] = str(typeOf(vm.peek(0)))
+		case compiler.OpTemplate:
+			n := arg
+			var text strings.Builder
+			for _, part := range vm.stack[len(vm.stack)-n:] {
+				text.WriteString(stringify(part))
+			}
+			vm.stack = vm.stack[:len(vm.stack)-n]
+			vm.push(str(text.String()))
+
+		case compiler.OpJump:
+			ip += arg
+		case compiler.OpJumpIfFalse:
+			if !condition(vm.pop()) {
+				ip += arg
+			}
+		case compiler.OpJumpIfTrue:
+			if condition(vm.pop()) {
+				ip += arg
+			}
+		case compiler.OpLoop:
+			ip -= arg
+		case compiler.OpIterator:
+			vm.push(object(iterate(vm.pop())))
+		case compiler.OpRange:
+			upper, lower := vm.pop(), vm.pop()
+			if lower.kind != numberValue {
+				throw("expected number but found %s", typeName(lower))
+			}
+			if upper.kind != numberValue {
+				throw("expected number but found %s", typeName(upper))
+			}
+			vm.push(object(&iterator{over: iterateRange, next: lower.number, end: upper.number}))
+		case compiler.OpIterNext:
+			if v, ok := vm.peek(0).ref.(*iterator).advance(); ok {
+				vm.push(v)
+			} else {
+				ip += arg
+			}
+
+		case compiler.OpClosure:
+			fn := constants[arg].ref.(*function)
+			cl := &closure{fn: fn, upvalues: make([]*upvalue, len(fn.proto.Upvalues))}
+			for i, uv := range fn.proto.Upvalues {
+				if uv.Local {
+					cl.upvalues[i] = vm.capture(base + uv.Index)
+				} else {
+					cl.upvalues[i] = f.closure.upvalues[uv.Index]
+				}
+			}
+			vm.push(object(cl))
+		case compiler.OpCall:
+			argc := arg
+			f.ip = ip
+			if vm.call(vm.peek(argc), argc) {
+				switchFrame()
+			}
+		case compiler.OpReturn:
+			result := vm.pop()
+			vm.closeUpvalues(base)
+			if f.construct {
+				result = vm.stack[base]
+			}
+			vm.stack = vm.stack[:base]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == 0 {
+				return nil
+			}
+			vm.push(result)
+			switchFrame()
+
+		case compiler.OpArray:
+			n := arg
+			elements := make([]value, n)
+			copy(elements, vm.stack[len(vm.stack)-n:])
+			vm.stack = vm.stack[:len(vm.stack)-n]
+			vm.push(object(&array{elements: elements}))
+		case compiler.OpObject:
+			vm.push(object(&objectLiteral{fields: map[string]value{}}))
+		case compiler.OpInitProperty:
+			v, key := vm.pop(), vm.pop()
+			if key.kind != stringValue {
+				throw("object keys must be strings but found %s", typeName(key))
+			}
+			vm.peek(0).ref.(*objectLiteral).set(key.ref.(string), v)
+		case compiler.OpGetMember:
+			name := constants[arg].ref.(string)
+			vm.stack[len(vm.stack)-1] = member(vm.peek(0), name)
+		case compiler.OpSetMember:
+			name := constants[arg].ref.(string)
+			v := vm.pop()
+			setMember(vm.peek(0), name, v)
+			vm.stack[len(vm.stack)-1] = v
+		case compiler.OpGetIndex:
+			property := vm.pop()
+			vm.stack[len(vm.stack)-1] = index(vm.peek(0), property)
+		case compiler.OpSetIndex:
+			v, property := vm.pop(), vm.pop()
+			setIndex(vm.peek(0), property, v)
+			vm.stack[len(vm.stack)-1] = v
+		case compiler.OpClass:
+			vm.push(object(vm.class(constants[arg].ref.(*compiler.Class))))
+		case compiler.OpInitField:
+			name := constants[arg].ref.(string)
+			v := vm.pop()
+			vm.peek(0).ref.(*instance).fields[name] = v
+		case compiler.OpNew:
+			c, ok := vm.peek(0).ref.(*class)
+			if !ok {
+				throw("%s is not a class", typeName(vm.peek(0)))
+			}
+			vm.stack[len(vm.stack)-1] = object(&instance{class: c, fields: make(map[string]value, len(c.fields))})
+			if c.init != nil {
+				f.ip = ip
+				vm.enter(c.init, len(vm.stack)-1, 0, false)
+				switchFrame()
+			}
+		case compiler.OpConstruct:
+			argc := arg
+			receiver := vm.peek(argc).ref.(*instance)
+			if constructor, exists := receiver.class.methods["constructor"]; exists {
+				f.ip = ip
+				vm.enter(constructor, len(vm.stack)-1-argc, argc, true)
+				switchFrame()
+			} else if argc > 0 {
+				throw("class %s has no constructor but received %d arguments", receiver.class.name, argc)
+			}
+		case compiler.OpImport:
+			path := constants[arg].ref.(string)
+			if vm.modules == nil {
+				throw("imports are only supported when running a program loaded with its modules")
+			}
+			m, exists := vm.modules[path]
+			if !exists {
+				throw("module %q was not loaded", path)
+			}
+			vm.push(object(m))
+		case compiler.OpExport:
+			vm.exports[constants[arg].ref.(string)] = vm.pop()
+
+		default:
+			throw("unknown opcode %d", op)
+		}
+	}
+}
+
+// Calls a function, builtin or method whose argc arguments are on top of the stack.
+// Returns true when it entered a new frame rather than completing the call.
+func (vm *VM) call(callee value, argc int) bool {
+	base := len(vm.stack) - 1 - argc
+
+	switch fn := callee.ref.(type) {
+	case *closure:
+		vm.enter(fn, base, argc, false)
+		return true
+	case *boundMethod:
+		vm.stack[base] = fn.receiver
+		vm.enter(fn.method, base, argc, false)
+		return true
+	case *builtin:
+		result, err := fn.fn(vm, vm.stack[base+1:])
+		if err != nil {
+			throw("%s", err)
+		}
+		vm.stack = vm.stack[:base]
+		vm.push(result)
+		return false
+	case *class:
+		throw("class %s must be instantiated with new", fn.name)
+	default:
+		throw("%s is not callable", typeName(callee))
+	}
+	return false
+}
+
+// Pushes the frame of a call to cl, whose slot 0 is at base
+func (vm *VM) enter(cl *closure, base int, argc int, construct bool) {
+	proto := cl.fn.proto
+	if argc != proto.Arity {
+		throw("%s expects %d arguments but received %d", proto.Name, proto.Arity, argc)
+	}
+	if len(vm.frames) > maxCallDepth {
+		throw("maximum call depth of %d exceeded", maxCallDepth)
+	}
+
+	vm.frames = append(vm.frames, frame{closure: cl, base: base, construct: construct})
+}
+
+// Creates a class from its description and the closures on top of the stack
+func (vm *VM) class(proto *compiler.Class) *class {
+	n := len(proto.Methods)
+	if len(proto.Fields) > 0 {
+		n++
+	}
+	closures := vm.stack[len(vm.stack)-n:]
+
+	c := &class{name: proto.Name, fields: proto.Fields, methods: make(map[string]*closure, len(proto.Methods))}
+	for i, name := range proto.Methods {
+		c.methods[name] = closures[i].ref.(*closure)
+	}
+	if len(proto.Fields) > 0 {
+		c.init = closures[n-1].ref.(*closure)
+	}
+
+	vm.stack = vm.stack[:len(vm.stack)-n]
+	return c
+}
+
+// Current value of a captured variable
+func (vm *VM) upvalue(uv *upvalue) value {
+	if uv.open {
+		return vm.stack[uv.slot]
+	}
+	return uv.closed
+}
+
+// Returns the upvalue of a stack slot, shared by every closure capturing it
+func (vm *VM) capture(slot int) *upvalue {
+	i := len(vm.open)
+	for i > 0 && vm.open[i-1].slot >= slot {
+		if vm.open[i-1].slot == slot {
+			return vm.open[i-1]
+		}
+		i--
+	}
+
+	uv := &upvalue{slot: slot, open: true}
+	vm.open = append(vm.open, nil)
+	copy(vm.open[i+1:], vm.open[i:])
+	vm.open[i] = uv
+	return uv
+}
+
+// Moves the variables of the slots from `from` up into the upvalues capturing them
+func (vm *VM) closeUpvalues(from int) {
+	for len(vm.open) > 0 && vm.open[len(vm.open)-1].slot >= from {
+		uv := vm.open[len(vm.open)-1]
+		uv.closed = vm.stack[uv.slot]
+		uv.open = false
+		vm.open = vm.open[:len(vm.open)-1]
+	}
+}
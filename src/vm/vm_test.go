@@ -0,0 +1,142 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thutasann/go-parser/src/compiler"
+	"github.com/thutasann/go-parser/src/interp"
+	"github.com/thutasann/go-parser/src/loader"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected output in testdata with the interpreter's")
+
+// Loads the program at path with its imports, failing the test on loading and syntax errors
+func loadProgram(t *testing.T, path string, source string) *loader.Graph {
+	t.Helper()
+	graph, diagnostics := loader.LoadSource(path, source)
+	if len(diagnostics) > 0 {
+		t.Fatalf("loading %s: %v", path, diagnostics)
+	}
+	return graph
+}
+
+// Runs the program on the tree-walking interpreter. Returns what it printed and its runtime error.
+func runInterpreter(graph *loader.Graph) (string, error) {
+	var out bytes.Buffer
+	err := interp.New(&out).RunGraph(graph)
+	return out.String(), err
+}
+
+// Compiles the program and runs it on the virtual machine. Returns what it printed and its runtime error.
+func runVM(t *testing.T, graph *loader.Graph) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	machine := New(&out)
+
+	for _, module := range graph.Order {
+		fn, diagnostics := compiler.Compile(module.Program)
+		if len(diagnostics) > 0 {
+			t.Fatalf("compiling %s: %v", module.Path, diagnostics)
+		}
+		if err := machine.RunModule(module.Path, fn); err != nil {
+			return out.String(), err
+		}
+	}
+	return out.String(), nil
+}
+
+// Output of a run as kept in testdata: what the program printed, then its runtime error
+func transcript(output string, err error) string {
+	if err != nil {
+		return output + err.Error() + "\n"
+	}
+	return output
+}
+
+// The shared programs print the same on both runtimes, and for those in testdata what the .out file next to them holds
+func TestRuntimesAgree(t *testing.T) {
+	programs, _ := filepath.Glob("testdata/*.lang")
+	examples, _ := filepath.Glob("../../examples/*.lang")
+	if len(programs) == 0 || len(examples) == 0 {
+		t.Fatal("no programs to run")
+	}
+
+	for _, path := range append(programs, examples...) {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			interpreted := transcript(runInterpreter(loadProgram(t, path, string(source))))
+			compiled := transcript(runVM(t, loadProgram(t, path, string(source))))
+			if compiled != interpreted {
+				t.Errorf("virtual machine:\n%s\ninterpreter:\n%s", compiled, interpreted)
+			}
+
+			if !strings.HasPrefix(path, "testdata") {
+				return
+			}
+			golden := strings.TrimSuffix(path, ".lang") + ".out"
+			if *update {
+				if err := os.WriteFile(golden, []byte(interpreted), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if interpreted != string(want) {
+				t.Errorf("output:\n%s\nwant:\n%s", interpreted, want)
+			}
+		})
+	}
+}
+
+// Both runtimes fail with the same message at the same place
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"fn f(a: number) { return a.b; }\nprintln(\"before\");\nf(1);", "number has no member b"},
+		{"let a = [1, 2];\nprintln(a[5]);", "array index 5 out of range [0, 2)"},
+		{"fn r(n: number) { return r(n + 1); }\nr(0);", "maximum call depth of 10000 exceeded"},
+		{"class C { let x = 1; }\nnew C(1);", "class C has no constructor but received 1 arguments"},
+		{"println(y);\nlet y = 2;", "y is not defined"},
+		{"let x = 1;\nx();", "number is not callable"},
+		{"class C { let x = 1; }\nC();", "class C must be instantiated with new"},
+		{"if 1 { }", "condition must be a boolean but is number"},
+		{"let o = {};\no[1] = 2;", "object keys must be strings but found number"},
+		{"fn f(a: number, b: number) {}\nf(1);", "f expects 2 arguments but received 1"},
+		{"foreach x in 5 {}", "cannot iterate over number"},
+		{"let c = new 5();", "number is not a class"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			interpretedOutput, interpretedErr := runInterpreter(loadProgram(t, "test.lang", test.source))
+			compiledOutput, compiledErr := runVM(t, loadProgram(t, "test.lang", test.source))
+
+			var interpreterError *interp.RuntimeError
+			if !errors.As(interpretedErr, &interpreterError) || interpreterError.Message != test.want {
+				t.Errorf("interpreter error = %v, want %q", interpretedErr, test.want)
+			}
+			var machineError *RuntimeError
+			if !errors.As(compiledErr, &machineError) || machineError.Message != test.want {
+				t.Errorf("virtual machine error = %v, want %q", compiledErr, test.want)
+			}
+			if transcript(compiledOutput, compiledErr) != transcript(interpretedOutput, interpretedErr) {
+				t.Errorf("virtual machine:\n%s\ninterpreter:\n%s", transcript(compiledOutput, compiledErr), transcript(interpretedOutput, interpretedErr))
+			}
+		})
+	}
+}